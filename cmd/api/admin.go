@@ -0,0 +1,418 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go-rest-starter.jtbergman.me/internal/config"
+	"go-rest-starter.jtbergman.me/internal/models"
+	"go-rest-starter.jtbergman.me/internal/models/audit"
+	"go-rest-starter.jtbergman.me/internal/models/permissions"
+	"go-rest-starter.jtbergman.me/internal/models/tokens"
+	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/validator"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Commands
+// ============================================================================
+
+// An administrative command that writes its result as JSON
+type adminCommand struct {
+	usage string
+	run   func(adm *admin, args []string) (rest.Envelope, *xerrors.AppError)
+}
+
+// The available admin subcommands
+var adminCommands = map[string]adminCommand{
+	"create-user": {
+		usage: "-email=<email> -password=<password> [-activate]",
+		run:   (*admin).createUser,
+	},
+	"activate": {
+		usage: "-email=<email>",
+		run:   (*admin).activate,
+	},
+	"grant": {
+		usage: "-email=<email> -permissions=<code,...>",
+		run:   (*admin).grant,
+	},
+	"revoke": {
+		usage: "-email=<email> -permissions=<code,...>",
+		run:   (*admin).revoke,
+	},
+	"list": {
+		usage: "-permission=<code>",
+		run:   (*admin).list,
+	},
+	"revoke-tokens": {
+		usage: "-email=<email>",
+		run:   (*admin).revokeTokens,
+	},
+}
+
+// Runs an admin subcommand and returns the process exit code
+//
+//	api admin <command> -db-dsn=<dsn> [flags]
+//
+// Results are written to stdout and errors to stderr as JSON for scripting.
+func runAdmin(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		adminUsage(stderr)
+		return 2
+	}
+
+	command, ok := adminCommands[args[0]]
+	if !ok {
+		adminUsage(stderr)
+		return 2
+	}
+
+	adm := &admin{ctx: context.Background(), name: args[0], stderr: stderr}
+	defer adm.close()
+
+	data, err := command.run(adm, args[1:])
+	if adm.usage {
+		fmt.Fprintf(stderr, "Usage: api admin %s -db-dsn=<dsn> %s\n", args[0], command.usage)
+		return 2
+	}
+	if err != nil {
+		writeAdminJSON(stderr, rest.Envelope{"error": err.Data, "op": err.Op})
+		return 1
+	}
+
+	writeAdminJSON(stdout, data)
+	return 0
+}
+
+// Prints the available subcommands
+func adminUsage(w io.Writer) {
+	names := make([]string, 0, len(adminCommands))
+	for name := range adminCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: api admin <command> -db-dsn=<dsn> [flags]")
+	fmt.Fprintln(w, "")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-14s %s\n", name, adminCommands[name].usage)
+	}
+}
+
+// Writes one JSON document per line
+func writeAdminJSON(w io.Writer, data rest.Envelope) {
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		fmt.Fprintln(w, err.Error())
+	}
+}
+
+// ============================================================================
+// Admin
+// ============================================================================
+
+// Holds the dependencies for a single admin command
+type admin struct {
+	ctx    context.Context
+	db     *sql.DB
+	dsn    string
+	name   string
+	models *models.Models
+	stderr io.Writer
+	usage  bool
+}
+
+// Parses the shared and command flags
+//
+// The config is built from flags so the same DSN used by the server works.
+// Commands validate their flags before calling connect.
+func (adm *admin) parse(fs *flag.FlagSet, args []string) bool {
+	var cfg config.Config
+	fs.StringVar(&cfg.DB.DSN, "db-dsn", "", "Postgres DSN")
+	fs.SetOutput(io.Discard)
+
+	if err := fs.Parse(args); err != nil || cfg.DB.DSN == "" || fs.NArg() > 0 {
+		adm.usage = true
+		return false
+	}
+
+	adm.dsn = cfg.DB.DSN
+	return true
+}
+
+// Connects to the database parsed from -db-dsn
+func (adm *admin) connect() *xerrors.AppError {
+	db, err := openDatabase(adm.dsn)
+	if err != nil {
+		return xerrors.ServerError(fmt.Sprintf("admin.%s.connect", adm.name), err)
+	}
+
+	adm.db = db
+	adm.models = models.New(db)
+	return nil
+}
+
+// Closes the database if the command connected
+func (adm *admin) close() {
+	if adm.db != nil {
+		adm.db.Close()
+	}
+}
+
+// Returns a validation style error for a missing flag
+func (adm *admin) required(values map[string]string) *xerrors.AppError {
+	missing := map[string]string{}
+	for key, value := range values {
+		if value == "" {
+			missing[key] = "must be provided"
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return xerrors.ClientError(
+		http.StatusUnprocessableEntity,
+		missing,
+		fmt.Sprintf("admin.%s", adm.name),
+		xerrors.ErrFailedValidation,
+	)
+}
+
+// Returns a validation error for codes that are not permissions
+func (adm *admin) knownCodes(key string, codes []string) *xerrors.AppError {
+	v := validator.New()
+	for _, code := range codes {
+		v.Check(permissions.Valid(code), key, fmt.Sprintf("%s is not a permission", code))
+	}
+
+	return v.Valid(fmt.Sprintf("admin.%s", adm.name))
+}
+
+// Appends an event to the audit log with no actor since the CLI has no user
+//
+// Failures are reported but never fail the command.
+func (adm *admin) record(action string, subjectID int64, metadata map[string]any) {
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	metadata["source"] = "cli"
+
+	if err := adm.models.Audit.Insert(adm.ctx, audit.NewEvent(action, 0, subjectID, metadata)); err != nil {
+		writeAdminJSON(adm.stderr, rest.Envelope{"error": err.Data, "op": err.Op})
+	}
+}
+
+// Gets a user and their permissions for output
+func (adm *admin) userEnvelope(user *users.User) (rest.Envelope, *xerrors.AppError) {
+	perms, err := adm.models.Permissions.GetByID(adm.ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return rest.Envelope{"user": user, "permissions": perms}, nil
+}
+
+// ============================================================================
+// Users
+// ============================================================================
+
+// Creates a user, optionally activated
+func (adm *admin) createUser(args []string) (rest.Envelope, *xerrors.AppError) {
+	fs := flag.NewFlagSet(adm.name, flag.ContinueOnError)
+	email := fs.String("email", "", "User email")
+	password := fs.String("password", "", "User password")
+	activate := fs.Bool("activate", false, "Activate the user")
+	if !adm.parse(fs, args) {
+		return nil, nil
+	}
+	if err := adm.connect(); err != nil {
+		return nil, err
+	}
+
+	user, err := adm.models.Users.New(*email, *password)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Activated = *activate
+	if err := adm.models.Users.Insert(adm.ctx, user); err != nil {
+		return nil, err
+	}
+
+	adm.record(audit.ActionRegister, user.ID, nil)
+	if user.Activated {
+		adm.record(audit.ActionActivate, user.ID, nil)
+	}
+
+	return adm.userEnvelope(user)
+}
+
+// Activates a user without an activation token
+func (adm *admin) activate(args []string) (rest.Envelope, *xerrors.AppError) {
+	fs := flag.NewFlagSet(adm.name, flag.ContinueOnError)
+	email := fs.String("email", "", "User email")
+	if !adm.parse(fs, args) {
+		return nil, nil
+	}
+	if err := adm.required(map[string]string{"email": *email}); err != nil {
+		return nil, err
+	}
+	if err := adm.connect(); err != nil {
+		return nil, err
+	}
+
+	user, err := adm.models.Users.GetByEmail(adm.ctx, *email)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.Activated {
+		user.Activated = true
+		if err := adm.models.Users.Update(adm.ctx, user); err != nil {
+			return nil, err
+		}
+		adm.record(audit.ActionActivate, user.ID, nil)
+	}
+
+	if _, err := adm.models.Tokens.DeleteAllForScope(adm.ctx, user.ID, tokens.ScopeActivation); err != nil {
+		return nil, err
+	}
+
+	return adm.userEnvelope(user)
+}
+
+// Revokes every token for a user, logging them out everywhere
+func (adm *admin) revokeTokens(args []string) (rest.Envelope, *xerrors.AppError) {
+	fs := flag.NewFlagSet(adm.name, flag.ContinueOnError)
+	email := fs.String("email", "", "User email")
+	if !adm.parse(fs, args) {
+		return nil, nil
+	}
+	if err := adm.required(map[string]string{"email": *email}); err != nil {
+		return nil, err
+	}
+	if err := adm.connect(); err != nil {
+		return nil, err
+	}
+
+	user, err := adm.models.Users.GetByEmail(adm.ctx, *email)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := adm.models.Tokens.DeleteAllForUser(adm.ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	adm.record(audit.ActionRevokeTokens, user.ID, map[string]any{"revoked": revoked})
+
+	return rest.Envelope{"user": user, "revoked": revoked}, nil
+}
+
+// ============================================================================
+// Permissions
+// ============================================================================
+
+// Grants permission codes to a user
+func (adm *admin) grant(args []string) (rest.Envelope, *xerrors.AppError) {
+	return adm.changePermissions(args, true)
+}
+
+// Revokes permission codes from a user
+func (adm *admin) revoke(args []string) (rest.Envelope, *xerrors.AppError) {
+	return adm.changePermissions(args, false)
+}
+
+// Shared implementation for grant and revoke
+func (adm *admin) changePermissions(args []string, grant bool) (rest.Envelope, *xerrors.AppError) {
+	fs := flag.NewFlagSet(adm.name, flag.ContinueOnError)
+	email := fs.String("email", "", "User email")
+	codes := fs.String("permissions", "", "Comma separated permission codes")
+	if !adm.parse(fs, args) {
+		return nil, nil
+	}
+	if err := adm.required(map[string]string{"email": *email, "permissions": *codes}); err != nil {
+		return nil, err
+	}
+	if err := adm.knownCodes("permissions", splitCodes(*codes)); err != nil {
+		return nil, err
+	}
+	if err := adm.connect(); err != nil {
+		return nil, err
+	}
+
+	user, err := adm.models.Users.GetByEmail(adm.ctx, *email)
+	if err != nil {
+		return nil, err
+	}
+
+	action, change := audit.ActionPermissionRevoke, adm.models.Permissions.Delete
+	if grant {
+		action, change = audit.ActionPermissionGrant, adm.models.Permissions.Insert
+	}
+
+	changed, err := change(adm.ctx, user.ID, splitCodes(*codes)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if changed > 0 {
+		adm.record(action, user.ID, map[string]any{"permissions": splitCodes(*codes)})
+	}
+
+	env, err := adm.userEnvelope(user)
+	if err != nil {
+		return nil, err
+	}
+
+	env["changed"] = changed
+	return env, nil
+}
+
+// Lists the users with a permission
+func (adm *admin) list(args []string) (rest.Envelope, *xerrors.AppError) {
+	fs := flag.NewFlagSet(adm.name, flag.ContinueOnError)
+	code := fs.String("permission", "", "Permission code")
+	if !adm.parse(fs, args) {
+		return nil, nil
+	}
+	if err := adm.required(map[string]string{"permission": *code}); err != nil {
+		return nil, err
+	}
+	if err := adm.knownCodes("permission", []string{*code}); err != nil {
+		return nil, err
+	}
+	if err := adm.connect(); err != nil {
+		return nil, err
+	}
+
+	all, err := adm.models.Users.GetAllForPermission(adm.ctx, *code)
+	if err != nil {
+		return nil, err
+	}
+
+	return rest.Envelope{"users": all}, nil
+}
+
+// Splits a comma separated list of codes
+func splitCodes(codes string) []string {
+	all := []string{}
+	for _, code := range strings.Split(codes, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			all = append(all, code)
+		}
+	}
+	return all
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/mocks"
+	"go-rest-starter.jtbergman.me/internal/models/audit"
+)
+
+// Runs an admin command and decodes its output
+func runAdminTest(args ...string) (int, map[string]any, string) {
+	var stdout, stderr bytes.Buffer
+	code := runAdmin(args, &stdout, &stderr)
+
+	var result map[string]any
+	output := stdout.Bytes()
+	if code != 0 {
+		output = stderr.Bytes()
+	}
+	json.Unmarshal(output, &result)
+
+	return code, result, stderr.String()
+}
+
+func TestAdminFlags(t *testing.T) {
+	// Validation runs before connecting, so the DSN is never used
+	dsn := "-db-dsn=postgres://unused"
+
+	tests := []struct {
+		name  string
+		args  []string
+		code  int
+		usage string
+		err   map[string]any
+	}{
+		{"NoCommand", nil, 2, "Usage: api admin <command>", nil},
+		{"UnknownCommand", []string{"unknown", dsn}, 2, "Usage: api admin <command>", nil},
+		{"MissingDSN", []string{"activate", "-email=a@example.com"}, 2, "Usage: api admin activate", nil},
+		{"UnknownFlag", []string{"activate", dsn, "-name=a"}, 2, "Usage: api admin activate", nil},
+		{"ExtraArgs", []string{"activate", dsn, "extra"}, 2, "Usage: api admin activate", nil},
+		{"MissingEmail", []string{"activate", dsn}, 1, "", map[string]any{"email": "must be provided"}},
+		{"MissingPermissions", []string{"grant", dsn, "-email=a@example.com"}, 1, "", map[string]any{"permissions": "must be provided"}},
+		{"UnknownGrant", []string{"grant", dsn, "-email=a@example.com", "-permissions=admin,owner"}, 1, "", map[string]any{"permissions": "owner is not a permission"}},
+		{"UnknownRevoke", []string{"revoke", dsn, "-email=a@example.com", "-permissions=root"}, 1, "", map[string]any{"permissions": "root is not a permission"}},
+		{"UnknownList", []string{"list", dsn, "-permission=root"}, 1, "", map[string]any{"permission": "root is not a permission"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, result, stderr := runAdminTest(test.args...)
+			assert.Equal(t, code, test.code)
+
+			if test.usage != "" {
+				assert.True(t, strings.HasPrefix(stderr, test.usage))
+			}
+			for key, message := range test.err {
+				assert.Equal(t, result["error"].(map[string]any)[key], message)
+			}
+		})
+	}
+}
+
+func TestAdminCommands(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	dsn := "-db-dsn=" + app.Config.DB.DSN
+
+	// Returns the user from a result
+	user := func(result map[string]any) map[string]any {
+		return result["user"].(map[string]any)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		code  int
+		check func(t *testing.T, result map[string]any)
+	}{
+		{"CreateUser", []string{"create-user", dsn, "-email=a@example.com", "-password=password"}, 0, func(t *testing.T, result map[string]any) {
+			assert.Equal(t, user(result)["email"], "a@example.com")
+			assert.Equal(t, user(result)["activated"], false)
+		}},
+		{"CreateUser/Duplicate", []string{"create-user", dsn, "-email=a@example.com", "-password=password"}, 1, func(t *testing.T, result map[string]any) {
+			assert.Equal(t, result["error"], "A resource with that identity already exists")
+		}},
+		{"Activate", []string{"activate", dsn, "-email=a@example.com"}, 0, func(t *testing.T, result map[string]any) {
+			assert.Equal(t, user(result)["activated"], true)
+		}},
+		{"Activate/NotFound", []string{"activate", dsn, "-email=b@example.com"}, 1, func(t *testing.T, result map[string]any) {
+			assert.Equal(t, result["error"], "The requested resource does not exist")
+		}},
+		{"Grant", []string{"grant", dsn, "-email=a@example.com", "-permissions=admin,superadmin"}, 0, func(t *testing.T, result map[string]any) {
+			assert.Equal(t, result["changed"], any(float64(2)))
+			assert.Equal(t, len(result["permissions"].([]any)), 2)
+		}},
+		{"Grant/Again", []string{"grant", dsn, "-email=a@example.com", "-permissions=admin"}, 0, func(t *testing.T, result map[string]any) {
+			assert.Equal(t, result["changed"], any(float64(0)))
+		}},
+		{"List", []string{"list", dsn, "-permission=admin"}, 0, func(t *testing.T, result map[string]any) {
+			assert.Equal(t, len(result["users"].([]any)), 1)
+		}},
+		{"Revoke", []string{"revoke", dsn, "-email=a@example.com", "-permissions=superadmin"}, 0, func(t *testing.T, result map[string]any) {
+			assert.Equal(t, result["changed"], any(float64(1)))
+			assert.Equal(t, result["permissions"].([]any)[0], "admin")
+		}},
+		{"RevokeTokens", []string{"revoke-tokens", dsn, "-email=a@example.com"}, 0, func(t *testing.T, result map[string]any) {
+			assert.Equal(t, result["revoked"], any(float64(0)))
+
+			filters := audit.Filters{Action: audit.ActionRevokeTokens, Page: 1, PageSize: 10}
+			events, _, err := app.Models.Audit.GetAll(context.Background(), filters)
+			assert.Check(t, err == nil)
+			assert.Equal(t, len(events), 1)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, result, _ := runAdminTest(test.args...)
+			assert.Equal(t, code, test.code)
+			test.check(t, result)
+		})
+	}
+}
@@ -11,18 +11,28 @@ import (
 
 // Opens a connection to the database using the provided DSN
 func OpenDatabase(dsn string) *sql.DB {
-	db, err := sql.Open("postgres", dsn)
+	db, err := openDatabase(dsn)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 
+	return db
+}
+
+// Opens and pings the database, returning an error instead of exiting
+func openDatabase(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err = db.PingContext(ctx); err != nil {
 		db.Close()
-		log.Fatal(err.Error())
+		return nil, err
 	}
 
-	return db
+	return db, nil
 }
@@ -5,35 +5,78 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"time"
 
 	"go-rest-starter.jtbergman.me/internal/app"
 	"go-rest-starter.jtbergman.me/internal/config"
 	"go-rest-starter.jtbergman.me/internal/mailer"
+	"go-rest-starter.jtbergman.me/internal/metrics"
 	"go-rest-starter.jtbergman.me/internal/models"
+	"go-rest-starter.jtbergman.me/internal/pubsub"
+	"go-rest-starter.jtbergman.me/internal/ratelimit"
 	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/tracing"
+	"go-rest-starter.jtbergman.me/internal/xlogger"
 )
 
 func main() {
+	// Administrative subcommands share the database but not the server
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		os.Exit(runAdmin(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	// Create dependencies
 	config := config.New()
 	database := OpenDatabase(config.DB.DSN)
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	logger := slog.New(xlogger.NewHandler(slog.NewTextHandler(os.Stdout, nil)))
 
 	// Log if successful connection
 	logger.Info("database connection pool established")
 
+	// Metrics for the runtime and connection pool, other subsystems register
+	// their own
+	registry := metrics.NewRegistry()
+	registry.Register(metrics.Runtime())
+	registry.Register(metrics.DB(database))
+
+	// Event streams are served until shutdown
+	hub := pubsub.NewHub(config.Events.History)
+	registry.GaugeFunc("event_stream_subscribers", "Open event stream subscriptions.", func() float64 {
+		return float64(hub.Subscribers())
+	})
+
+	// Rate limit buckets are evicted in the background until shutdown
+	limits := ratelimit.NewMemoryStore(time.Minute)
+
 	// Create App
 	app := app.New(
-		app.NewBackground(logger),
+		app.NewBackground(logger, registry),
 		config,
+		hub,
+		limits,
 		logger,
-		mailer.New(config, logger),
+		mailer.New(config, logger, registry),
+		registry,
 		models.New(database),
 		rest.New(logger),
+		newTracer(config),
 	)
 
-	if err := serve(app); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	err := serve(app)
+	limits.Close()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		app.Logger.Error(err.Error())
 		os.Exit(1)
 	}
 }
+
+// Creates a tracer for the configured exporter or nil if tracing is disabled
+func newTracer(cfg config.Config) *tracing.Tracer {
+	switch cfg.Trace.Exporter {
+	case config.TraceExporterStdout:
+		return tracing.New(tracing.NewJSONExporter(os.Stdout))
+
+	default:
+		return nil
+	}
+}
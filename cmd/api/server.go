@@ -12,6 +12,7 @@ import (
 	"time"
 
 	app "go-rest-starter.jtbergman.me/internal/app"
+	"go-rest-starter.jtbergman.me/internal/config"
 	"go-rest-starter.jtbergman.me/internal/routes"
 )
 
@@ -23,10 +24,20 @@ func serve(app *app.App) error {
 		Handler:      routes.Mux(app),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		WriteTimeout: config.ServerWriteTimeout,
 		ErrorLog:     slog.NewLogLogger(app.Logger.Handler(), slog.LevelError),
 	}
 
+	// Shutdown waits for open requests, so end event streams when it begins
+	srv.RegisterOnShutdown(app.Events.Close)
+
+	// Optionally serve metrics on a separate listener, e.g. a private port
+	metrics := metricsServer(app)
+
+	// Delete expired idempotency keys until shutdown
+	stopPurge := make(chan struct{})
+	go purgeIdempotencyKeys(app, stopPurge)
+
 	// Create a shutdown channel to receive errors from the Shutdown() function
 	shutdownError := make(chan error)
 
@@ -41,11 +52,15 @@ func serve(app *app.App) error {
 		app.Logger.Info("shutting down server", "signal", s.String())
 
 		// Begin shutdown
+		close(stopPurge)
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 		if err := srv.Shutdown(ctx); err != nil {
 			shutdownError <- srv.Shutdown(ctx)
 		}
+		if metrics != nil {
+			metrics.Shutdown(ctx)
+		}
 
 		// Log a message to say we're waiting for any background tasks
 		app.Logger.Info("completing background tasks", "addr", srv.Addr)
@@ -68,3 +83,53 @@ func serve(app *app.App) error {
 	app.Logger.Info("stopped server", "addr", srv.Addr)
 	return nil
 }
+
+// Starts a metrics server if a separate metrics address is configured
+func metricsServer(app *app.App) *http.Server {
+	if app.Config.Metrics.Addr == "" || app.Config.Metrics.Path == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET "+app.Config.Metrics.Path, app.Metrics.Handler())
+
+	srv := &http.Server{
+		Addr:         app.Config.Metrics.Addr,
+		Handler:      mux,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		ErrorLog:     slog.NewLogLogger(app.Logger.Handler(), slog.LevelError),
+	}
+
+	go func() {
+		app.Logger.Info("starting metrics server", "addr", srv.Addr)
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			app.Logger.Error(err.Error(), "addr", srv.Addr)
+		}
+	}()
+
+	return srv
+}
+
+// Deletes expired idempotency keys every hour until stop is closed
+func purgeIdempotencyKeys(app *app.App, stop <-chan struct{}) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			deleted, err := app.Models.Idempotency.DeleteExpired(ctx)
+			cancel()
+			if err != nil {
+				app.Logger.Error(err.Error())
+				continue
+			}
+			app.Logger.Info("deleted expired idempotency keys", "count", deleted)
+		}
+	}
+}
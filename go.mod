@@ -7,9 +7,16 @@ require (
 	golang.org/x/crypto v0.22.0 // direct
 )
 
-require github.com/go-mail/mail/v2 v2.3.0
+require (
+	github.com/andybalholm/brotli v1.1.1
+	github.com/fxamacker/cbor/v2 v2.9.2
+	github.com/go-mail/mail/v2 v2.3.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+)
 
 require (
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
 	gopkg.in/alexcesaro/quotedprintable.v3 v3.0.0-20150716171945-2caba252f4dc // indirect
 	gopkg.in/mail.v2 v2.3.1 // indirect
 )
@@ -3,36 +3,52 @@ package app
 import (
 	"go-rest-starter.jtbergman.me/internal/config"
 	"go-rest-starter.jtbergman.me/internal/mailer"
+	"go-rest-starter.jtbergman.me/internal/metrics"
 	"go-rest-starter.jtbergman.me/internal/models"
+	"go-rest-starter.jtbergman.me/internal/pubsub"
+	"go-rest-starter.jtbergman.me/internal/ratelimit"
 	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/tracing"
 	"go-rest-starter.jtbergman.me/internal/xlogger"
 )
 
 // Container for app wide dependencies
 type App struct {
-	BG     Backgrounder
-	Config config.Config
-	Logger xlogger.Logger
-	Mailer mailer.Mailer
-	Models *models.Models
-	Rest   *rest.Rest
+	BG      Backgrounder
+	Config  config.Config
+	Events  *pubsub.Hub
+	Limits  ratelimit.Store
+	Logger  xlogger.Logger
+	Mailer  mailer.Mailer
+	Metrics *metrics.Registry
+	Models  *models.Models
+	Rest    *rest.Rest
+	Tracer  *tracing.Tracer
 }
 
 // Create a new App struct
 func New(
 	backgrounder Backgrounder,
 	config config.Config,
+	events *pubsub.Hub,
+	limits ratelimit.Store,
 	logger xlogger.Logger,
 	mailer mailer.Mailer,
+	metrics *metrics.Registry,
 	models *models.Models,
 	rest *rest.Rest,
+	tracer *tracing.Tracer,
 ) *App {
 	return &App{
-		BG:     backgrounder,
-		Config: config,
-		Logger: logger,
-		Mailer: mailer,
-		Models: models,
-		Rest:   rest,
+		BG:      backgrounder,
+		Config:  config,
+		Events:  events,
+		Limits:  limits,
+		Logger:  logger,
+		Mailer:  mailer,
+		Metrics: metrics,
+		Models:  models,
+		Rest:    rest,
+		Tracer:  tracer,
 	}
 }
@@ -1,9 +1,12 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
+	"go-rest-starter.jtbergman.me/internal/metrics"
 	"go-rest-starter.jtbergman.me/internal/xerrors"
 	"go-rest-starter.jtbergman.me/internal/xlogger"
 )
@@ -14,7 +17,7 @@ import (
 
 // Defines a type that can run background tasks
 type Backgrounder interface {
-	Run(fn func())
+	Run(ctx context.Context, fn func(ctx context.Context))
 	Wait()
 }
 
@@ -24,13 +27,24 @@ type Backgrounder interface {
 
 // Concrete implementation that runs background tasks with a wait group
 type Background struct {
-	logger xlogger.Logger
-	wg     sync.WaitGroup
+	logger  xlogger.Logger
+	running atomic.Int64
+	tasks   *metrics.Counter
+	wg      sync.WaitGroup
 }
 
-// Creates a new Background instance
-func NewBackground(logger xlogger.Logger) *Background {
-	return &Background{logger: logger}
+// Creates a new Background instance that reports task counts to registry
+func NewBackground(logger xlogger.Logger, registry *metrics.Registry) *Background {
+	bg := &Background{
+		logger: logger,
+		tasks:  registry.Counter("background_tasks_total", "Background tasks finished by status.", "status"),
+	}
+
+	registry.GaugeFunc("background_tasks_running", "Background tasks currently running.", func() float64 {
+		return float64(bg.running.Load())
+	})
+
+	return bg
 }
 
 // ============================================================================
@@ -38,23 +52,31 @@ func NewBackground(logger xlogger.Logger) *Background {
 // ============================================================================
 
 // Runs a background task
-func (bg *Background) Run(fn func()) {
+//
+// The task receives a copy of ctx that is not canceled when the request
+// ends, so it keeps values such as the request ID for logging.
+func (bg *Background) Run(ctx context.Context, fn func(ctx context.Context)) {
+	ctx = context.WithoutCancel(ctx)
 	bg.wg.Add(1)
+	bg.running.Add(1)
 
 	go func() {
 		defer bg.wg.Done()
+		defer bg.running.Add(-1)
 
 		defer func() {
 			if err := recover(); err != nil {
+				bg.tasks.Inc("panicked")
 				serverError := xerrors.ServerError(
 					"app.Background",
 					fmt.Errorf("%w: %v", xerrors.ErrServerInternal, err),
 				)
-				bg.logger.Error(serverError.Error())
+				bg.logger.ErrorContext(ctx, serverError.Error())
 			}
 		}()
 
-		fn()
+		fn(ctx)
+		bg.tasks.Inc("completed")
 	}()
 }
 
@@ -39,11 +39,13 @@ type HandlerTestFunc[T any] func(t *testing.T, result T)
 
 // Test case that allows verifying a specific body result
 type HandlerTestCase[T any] struct {
-	Name   string
-	Auth   string
-	Body   string
-	Status int
-	FN     HandlerTestFunc[T]
+	Name            string
+	Auth            string
+	Body            string
+	Headers         map[string]string
+	Status          int
+	ResponseHeaders map[string]string
+	FN              HandlerTestFunc[T]
 }
 
 func RunHandlerTestCase[T any](
@@ -61,12 +63,18 @@ func RunHandlerTestCase[T any](
 		if tc.Auth != "" {
 			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tc.Auth))
 		}
+		for key, value := range tc.Headers {
+			req.Header.Set(key, value)
+		}
 
 		handler.ServeHTTP(rr, req)
 		resp := rr.Result()
 		defer resp.Body.Close()
 
 		Equal(t, resp.StatusCode, tc.Status)
+		for key, value := range tc.ResponseHeaders {
+			Equal(t, resp.Header.Get(key), value)
+		}
 
 		// Verify response is JSON unless no content
 		if tc.FN != nil {
@@ -4,7 +4,10 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 )
 
 // ============================================================================
@@ -17,6 +20,27 @@ const (
 	EnvProd  = "prod"
 )
 
+const (
+	TraceExporterStdout = "stdout"
+)
+
+// The server's write timeout, which bounds every request timeout
+const ServerWriteTimeout = 10 * time.Second
+
+// The default Content-Security-Policy. JSON responses load nothing, and the
+// pages in static/ may only run scripts with the per-request nonce and call
+// the API on the same origin.
+const DefaultCSP = "default-src 'none'; script-src 'nonce-{nonce}'; style-src 'nonce-{nonce}'; " +
+	"connect-src 'self'; img-src 'self'; form-action 'self'; base-uri 'none'; frame-ancestors 'none'"
+
+// The default request headers allowed in cross-origin requests. Conditional
+// headers are included since some routes, e.g. admin user updates, require
+// If-Match, and Last-Event-ID lets event streams resume.
+var DefaultCORSAllowedHeaders = []string{"Authorization", "Content-Type", "X-Organization-ID", "X-Request-ID", "Idempotency-Key", "If-Match", "If-None-Match", "Last-Event-ID"}
+
+// The default response headers readable by cross-origin clients
+var DefaultCORSExposedHeaders = []string{"X-Request-ID", "ETag", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Idempotent-Replayed"}
+
 // ============================================================================
 // Config
 // ============================================================================
@@ -28,6 +52,41 @@ type Config struct {
 	DB   struct {
 		DSN string
 	}
+	Log struct {
+		SampleRate float64
+		SkipPaths  []string
+	}
+	Metrics struct {
+		Addr string
+		Path string
+	}
+	Trace struct {
+		Exporter string
+	}
+	Timeout struct {
+		Request time.Duration
+	}
+	Compression struct {
+		MinSize int
+	}
+	Idempotency struct {
+		TTL time.Duration
+	}
+	Security struct {
+		CSP string
+	}
+	Events struct {
+		Heartbeat time.Duration
+		History   int
+	}
+	CORS struct {
+		AllowedOrigins   []string
+		AllowedMethods   []string
+		AllowedHeaders   []string
+		ExposedHeaders   []string
+		AllowCredentials bool
+		MaxAge           time.Duration
+	}
 	SMTP struct {
 		Host     string
 		Port     int
@@ -46,10 +105,61 @@ func New() Config {
 
 	// Server
 	flag.IntVar(&cfg.Port, "port", 0, "API server port")
+	flag.DurationVar(&cfg.Timeout.Request, "request-timeout", 5*time.Second, "Default request timeout, 0 for none")
 
 	// Database
 	flag.StringVar(&cfg.DB.DSN, "db-dsn", "", "Postgres DSN")
 
+	// Access log
+	flag.Float64Var(&cfg.Log.SampleRate, "log-sample-rate", 1, "Fraction of successful requests to log (0-1)")
+	flag.Func("log-skip-paths", "Comma separated paths to exclude from the access log", func(paths string) error {
+		cfg.Log.SkipPaths = strings.Split(paths, ",")
+		return nil
+	})
+
+	// Metrics
+	flag.StringVar(&cfg.Metrics.Addr, "metrics-addr", "", "Serve metrics on a separate listener (e.g. localhost:9090)")
+	flag.StringVar(&cfg.Metrics.Path, "metrics-path", "/metrics", "Metrics path, empty to disable")
+
+	// Tracing
+	flag.StringVar(&cfg.Trace.Exporter, "trace-exporter", "", "Trace exporter (stdout), empty to disable")
+
+	// Compression
+	flag.IntVar(&cfg.Compression.MinSize, "compress-min-size", 1024, "Smallest response body in bytes to compress")
+
+	// Idempotency keys
+	flag.DurationVar(&cfg.Idempotency.TTL, "idempotency-ttl", 24*time.Hour, "How long responses to requests with an Idempotency-Key are replayed")
+
+	// Security headers
+	flag.StringVar(&cfg.Security.CSP, "csp", DefaultCSP, "Content-Security-Policy, {nonce} is replaced per request, empty to disable")
+
+	// Event streams
+	flag.DurationVar(&cfg.Events.Heartbeat, "events-heartbeat", 15*time.Second, "How often idle event streams send a heartbeat")
+	flag.IntVar(&cfg.Events.History, "events-history", 1000, "Recent events kept for clients resuming with Last-Event-ID")
+
+	// CORS
+	cfg.CORS.AllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	cfg.CORS.AllowedHeaders = DefaultCORSAllowedHeaders
+	cfg.CORS.ExposedHeaders = DefaultCORSExposedHeaders
+	flag.Func("cors-allowed-origins", "Comma separated origins allowed to make cross-origin requests (e.g. https://*.example.com), empty to disable", func(origins string) error {
+		cfg.CORS.AllowedOrigins = splitList(origins)
+		return nil
+	})
+	flag.Func("cors-allowed-methods", "Comma separated methods allowed in cross-origin requests", func(methods string) error {
+		cfg.CORS.AllowedMethods = splitList(methods)
+		return nil
+	})
+	flag.Func("cors-allowed-headers", "Comma separated request headers allowed in cross-origin requests", func(headers string) error {
+		cfg.CORS.AllowedHeaders = splitList(headers)
+		return nil
+	})
+	flag.Func("cors-exposed-headers", "Comma separated response headers readable by cross-origin clients", func(headers string) error {
+		cfg.CORS.ExposedHeaders = splitList(headers)
+		return nil
+	})
+	flag.BoolVar(&cfg.CORS.AllowCredentials, "cors-allow-credentials", false, "Allow cross-origin requests with credentials")
+	flag.DurationVar(&cfg.CORS.MaxAge, "cors-max-age", 10*time.Minute, "How long browsers may cache preflight responses")
+
 	// SMTP
 	flag.StringVar(&cfg.SMTP.Host, "smtp-host", "", "SMTP host")
 	flag.IntVar(&cfg.SMTP.Port, "smtp-port", 0, "SMTP port")
@@ -106,6 +216,56 @@ func (config *Config) validate() (bool, string) {
 		}
 	}
 
+	// Validate floats
+	if config.Log.SampleRate < 0 || config.Log.SampleRate > 1 {
+		return false, "Invalid log-sample-rate flag (0-1)"
+	}
+
+	if config.Metrics.Path != "" && !strings.HasPrefix(config.Metrics.Path, "/") {
+		return false, "Invalid metrics-path flag (must start with /)"
+	}
+
+	switch config.Trace.Exporter {
+	case "", TraceExporterStdout:
+		break
+
+	default:
+		return false, fmt.Sprintf("Invalid trace-exporter flag (%s)", TraceExporterStdout)
+	}
+
+	for _, origin := range config.CORS.AllowedOrigins {
+		if !validOrigin(origin) {
+			return false, fmt.Sprintf("Invalid cors-allowed-origins flag (%s)", origin)
+		}
+		if origin == "*" && config.CORS.AllowCredentials {
+			return false, "Invalid cors-allowed-origins flag (* cannot be used with cors-allow-credentials)"
+		}
+	}
+
+	if config.Timeout.Request < 0 || config.Timeout.Request >= ServerWriteTimeout {
+		return false, fmt.Sprintf("Invalid request-timeout flag (0-%s)", ServerWriteTimeout)
+	}
+
+	if config.Compression.MinSize < 0 {
+		return false, "Invalid compress-min-size flag (must not be negative)"
+	}
+
+	if config.Idempotency.TTL <= 0 {
+		return false, "Invalid idempotency-ttl flag (must be positive)"
+	}
+
+	if config.Events.Heartbeat <= 0 {
+		return false, "Invalid events-heartbeat flag (must be positive)"
+	}
+
+	if config.Events.History < 0 {
+		return false, "Invalid events-history flag (must not be negative)"
+	}
+
+	if config.CORS.MaxAge < 0 {
+		return false, "Invalid cors-max-age flag (must not be negative)"
+	}
+
 	// Validate strings
 	if !config.IsLocal() {
 		switch "" {
@@ -128,3 +288,40 @@ func (config *Config) validate() (bool, string) {
 
 	return true, ""
 }
+
+// ============================================================================
+// Helper
+// ============================================================================
+
+// Splits a comma separated flag, dropping empty values
+func splitList(s string) []string {
+	values := []string{}
+	for _, value := range strings.Split(s, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Returns true for "*" or a scheme and host with an optional port, where the
+// host may start with "*." to match any subdomain
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+
+	u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") &&
+		u.Host != "" &&
+		!strings.Contains(u.Host, "*") &&
+		u.User == nil &&
+		u.Path == "" &&
+		u.RawQuery == "" &&
+		u.Fragment == "" &&
+		origin == strings.ToLower(origin)
+}
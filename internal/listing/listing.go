@@ -0,0 +1,297 @@
+package listing
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/validator"
+)
+
+// ============================================================================
+// Spec
+// ============================================================================
+
+// The type of a field's values, used to parse filters
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Bool
+	Time
+)
+
+// A filter comparison, e.g. filter[created_at][gte]=...
+type Operator string
+
+const (
+	Eq       Operator = "eq"
+	Ne       Operator = "ne"
+	Gt       Operator = "gt"
+	Gte      Operator = "gte"
+	Lt       Operator = "lt"
+	Lte      Operator = "lte"
+	Contains Operator = "contains"
+)
+
+// The SQL for each operator
+var operators = map[Operator]string{
+	Eq:       "=",
+	Ne:       "<>",
+	Gt:       ">",
+	Gte:      ">=",
+	Lt:       "<",
+	Lte:      "<=",
+	Contains: "ILIKE",
+}
+
+// A field clients may filter or sort on
+//
+// Column is written into SQL as is, so it must never come from the client.
+// Fields without Operators cannot be filtered.
+type Field struct {
+	Column    string
+	Type      Type
+	Operators []Operator
+	Sortable  bool
+}
+
+// The safelist of fields for a resource, keyed by the name clients use
+//
+// DefaultSort is used when the client does not sort, and results are always
+// ordered by Tiebreaker last (usually a unique id column) so pages are stable.
+type Spec struct {
+	Fields      map[string]Field
+	DefaultSort string
+	Tiebreaker  string
+}
+
+// ============================================================================
+// Query
+// ============================================================================
+
+// A validated filter, e.g. created_at >= 2024-01-01
+type Filter struct {
+	Field    string
+	Operator Operator
+	Value    any
+}
+
+// A validated sort field
+type Sort struct {
+	Field      string
+	Descending bool
+}
+
+// The filters and sort order requested by the client
+type Query struct {
+	Filters []Filter
+	Sorts   []Sort
+	spec    Spec
+}
+
+// The query string parameters read by Parse
+const (
+	SortParam   = "sort"
+	FilterParam = "filter"
+)
+
+// Reads ?sort=-created_at,email and filter[field]=value or
+// filter[field][op]=value from the query string, adding an error to the
+// validator for every field, operator, or value the spec does not allow
+func Parse(qs url.Values, spec Spec, v *validator.Validator) Query {
+	query := Query{spec: spec}
+
+	// Sort by the request, then the default
+	sort := qs.Get(SortParam)
+	if sort == "" {
+		sort = spec.DefaultSort
+	}
+	query.Sorts = parseSort(sort, spec, v)
+
+	// Filters are read in a stable order so the SQL is deterministic
+	keys := []string{}
+	for key := range qs {
+		if strings.HasPrefix(key, FilterParam+"[") {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if filter, ok := parseFilter(key, qs.Get(key), spec, v); ok {
+			query.Filters = append(query.Filters, filter)
+		}
+	}
+
+	return query
+}
+
+// Parses a comma separated list of fields, each optionally prefixed by "-"
+// for descending order
+func parseSort(sort string, spec Spec, v *validator.Validator) []Sort {
+	sorts := []Sort{}
+	seen := map[string]bool{}
+
+	for _, name := range strings.Split(sort, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		s := Sort{Field: strings.TrimPrefix(name, "-"), Descending: strings.HasPrefix(name, "-")}
+		field, ok := spec.Fields[s.Field]
+
+		switch {
+		case !ok || !field.Sortable:
+			v.AddError(SortParam, fmt.Sprintf("cannot sort by %s", s.Field))
+
+		case seen[s.Field]:
+			v.AddError(SortParam, fmt.Sprintf("must not repeat %s", s.Field))
+
+		default:
+			seen[s.Field] = true
+			sorts = append(sorts, s)
+		}
+	}
+
+	return sorts
+}
+
+// Parses filter[field] or filter[field][op] and its value
+func parseFilter(key, raw string, spec Spec, v *validator.Validator) (Filter, bool) {
+	name, op, ok := parseFilterKey(key)
+	if !ok {
+		v.AddError(key, "is malformed")
+		return Filter{}, false
+	}
+
+	field, ok := spec.Fields[name]
+	if !ok || len(field.Operators) == 0 {
+		v.AddError(key, "is not a filterable field")
+		return Filter{}, false
+	}
+
+	if !slices.Contains(field.Operators, op) {
+		v.AddError(key, fmt.Sprintf("does not support %s", op))
+		return Filter{}, false
+	}
+
+	value, message := parseValue(field.Type, raw)
+	if message != "" {
+		v.AddError(key, message)
+		return Filter{}, false
+	}
+
+	return Filter{Field: name, Operator: op, Value: value}, true
+}
+
+// Splits filter[field][op] into the field and operator, which defaults to eq
+func parseFilterKey(key string) (string, Operator, bool) {
+	name, op, ok := strings.Cut(strings.TrimPrefix(key, FilterParam+"["), "]")
+	if !ok || name == "" {
+		return "", "", false
+	}
+
+	if op == "" {
+		return name, Eq, true
+	}
+
+	if len(op) < 3 || !strings.HasPrefix(op, "[") || !strings.HasSuffix(op, "]") {
+		return "", "", false
+	}
+
+	return name, Operator(op[1 : len(op)-1]), true
+}
+
+// Parses a filter value as the field's type or returns a validation message
+func parseValue(t Type, raw string) (any, string) {
+	switch t {
+	case Int:
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, "must be an integer value"
+		}
+		return i, ""
+
+	case Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, "must be true or false"
+		}
+		return b, ""
+
+	case Time:
+		timestamp, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, "must be an RFC 3339 timestamp"
+		}
+		return timestamp, ""
+
+	default:
+		if raw == "" {
+			return nil, "must be provided"
+		}
+		return raw, ""
+	}
+}
+
+// ============================================================================
+// SQL
+// ============================================================================
+
+// Returns the filters joined by AND, e.g. "activated = $3 AND email ILIKE $4",
+// with placeholders numbered from start, and the arguments for them. Without
+// filters the condition is "TRUE".
+func (q Query) Where(start int) (string, []any) {
+	if len(q.Filters) == 0 {
+		return "TRUE", nil
+	}
+
+	conditions := make([]string, len(q.Filters))
+	args := make([]any, len(q.Filters))
+
+	for i, filter := range q.Filters {
+		column := q.spec.Fields[filter.Field].Column
+		conditions[i] = fmt.Sprintf("%s %s $%d", column, operators[filter.Operator], start+i)
+		args[i] = filter.Value
+
+		if filter.Operator == Contains {
+			args[i] = "%" + escapeLike(fmt.Sprint(filter.Value)) + "%"
+		}
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// Returns the ORDER BY list, e.g. "created_at DESC, email ASC, id ASC"
+func (q Query) OrderBy() string {
+	columns := []string{}
+	tiebreaker := false
+
+	for _, sort := range q.Sorts {
+		column := q.spec.Fields[sort.Field].Column
+		tiebreaker = tiebreaker || column == q.spec.Tiebreaker
+
+		direction := "ASC"
+		if sort.Descending {
+			direction = "DESC"
+		}
+		columns = append(columns, column+" "+direction)
+	}
+
+	if !tiebreaker && q.spec.Tiebreaker != "" {
+		columns = append(columns, q.spec.Tiebreaker+" ASC")
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+// Escapes the LIKE wildcards in a value so it matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,93 @@
+package listing
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/validator"
+)
+
+var spec = Spec{
+	Fields: map[string]Field{
+		"id":         {Column: "id", Type: Int, Operators: []Operator{Eq, Gt}, Sortable: true},
+		"email":      {Column: "users.email", Type: String, Operators: []Operator{Eq, Contains}, Sortable: true},
+		"activated":  {Column: "activated", Type: Bool, Operators: []Operator{Eq}},
+		"created_at": {Column: "created_at", Type: Time, Operators: []Operator{Gte, Lt}, Sortable: true},
+		"version":    {Column: "version", Type: Int},
+	},
+	DefaultSort: "-created_at",
+	Tiebreaker:  "id",
+}
+
+func TestParse(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		v := validator.New()
+		qs := url.Values{
+			"sort":                    {"-created_at,email"},
+			"filter[activated]":       {"true"},
+			"filter[created_at][gte]": {"2024-01-01T00:00:00Z"},
+			"filter[email][contains]": {"50%_off"},
+			"page":                    {"2"},
+		}
+
+		query := Parse(qs, spec, v)
+		assert.Check(t, len(v.Errors) == 0)
+
+		where, args := query.Where(3)
+		assert.Equal(t, where, "activated = $3 AND created_at >= $4 AND users.email ILIKE $5")
+		assert.Check(t, len(args) == 3)
+		assert.Equal(t, args[0].(bool), true)
+		assert.True(t, args[1].(time.Time).Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+		assert.Equal(t, args[2].(string), `%50\%\_off%`)
+
+		assert.Equal(t, query.OrderBy(), "created_at DESC, users.email ASC, id ASC")
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		query := Parse(url.Values{}, spec, validator.New())
+
+		where, args := query.Where(1)
+		assert.Equal(t, where, "TRUE")
+		assert.Check(t, len(args) == 0)
+		assert.Equal(t, query.OrderBy(), "created_at DESC, id ASC")
+	})
+
+	t.Run("Tiebreaker", func(t *testing.T) {
+		query := Parse(url.Values{"sort": {"-id"}}, spec, validator.New())
+		assert.Equal(t, query.OrderBy(), "id DESC")
+	})
+
+	tests := []struct {
+		name  string
+		qs    url.Values
+		key   string
+		error string
+	}{
+		{name: "Sort/Unknown", qs: url.Values{"sort": {"password"}}, key: "sort", error: "cannot sort by password"},
+		{name: "Sort/NotSortable", qs: url.Values{"sort": {"activated"}}, key: "sort", error: "cannot sort by activated"},
+		{name: "Sort/Repeated", qs: url.Values{"sort": {"email,-email"}}, key: "sort", error: "must not repeat email"},
+		{name: "Filter/Unknown", qs: url.Values{"filter[password]": {"x"}}, key: "filter[password]", error: "is not a filterable field"},
+		{name: "Filter/NotFilterable", qs: url.Values{"filter[version]": {"1"}}, key: "filter[version]", error: "is not a filterable field"},
+		{name: "Filter/Operator", qs: url.Values{"filter[id][lt]": {"1"}}, key: "filter[id][lt]", error: "does not support lt"},
+		{name: "Filter/Malformed", qs: url.Values{"filter[id": {"1"}}, key: "filter[id", error: "is malformed"},
+		{name: "Filter/MalformedOperator", qs: url.Values{"filter[id]gt": {"1"}}, key: "filter[id]gt", error: "is malformed"},
+		{name: "Filter/Int", qs: url.Values{"filter[id]": {"one"}}, key: "filter[id]", error: "must be an integer value"},
+		{name: "Filter/Bool", qs: url.Values{"filter[activated]": {"yes"}}, key: "filter[activated]", error: "must be true or false"},
+		{name: "Filter/Time", qs: url.Values{"filter[created_at][lt]": {"today"}}, key: "filter[created_at][lt]", error: "must be an RFC 3339 timestamp"},
+		{name: "Filter/Empty", qs: url.Values{"filter[email]": {""}}, key: "filter[email]", error: "must be provided"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := validator.New()
+			query := Parse(test.qs, spec, v)
+			assert.Equal(t, v.Errors[test.key], test.error)
+
+			// Invalid fields never reach the SQL
+			where, _ := query.Where(1)
+			assert.Equal(t, where, "TRUE")
+		})
+	}
+}
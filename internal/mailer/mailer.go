@@ -2,6 +2,7 @@ package mailer
 
 import (
 	"bytes"
+	"context"
 	"embed"
 	"fmt"
 	"text/template"
@@ -9,6 +10,8 @@ import (
 
 	"github.com/go-mail/mail/v2"
 	"go-rest-starter.jtbergman.me/internal/config"
+	"go-rest-starter.jtbergman.me/internal/metrics"
+	"go-rest-starter.jtbergman.me/internal/tracing"
 	"go-rest-starter.jtbergman.me/internal/xerrors"
 	"go-rest-starter.jtbergman.me/internal/xlogger"
 )
@@ -21,8 +24,9 @@ var templateFS embed.FS
 // ============================================================================
 
 type Mailer interface {
-	SendWelcomeEmail(recipient string, data map[string]string) *xerrors.AppError
-	SendPasswordResetEmail(recipientemail string, data map[string]string) *xerrors.AppError
+	SendWelcomeEmail(ctx context.Context, recipient string, data map[string]string) *xerrors.AppError
+	SendPasswordResetEmail(ctx context.Context, recipient string, data map[string]string) *xerrors.AppError
+	SendInvitationEmail(ctx context.Context, recipient string, data map[string]string) *xerrors.AppError
 }
 
 // ============================================================================
@@ -32,6 +36,7 @@ type Mailer interface {
 // The Mailer struct defines an SMTP server and the sender information
 type Mail struct {
 	dialer *mail.Dialer
+	emails *metrics.Counter
 	logger xlogger.Logger
 	sender string
 	skip   bool
@@ -44,10 +49,11 @@ type Mail struct {
 const (
 	welcomeTemplate       = "user_welcome.tmpl"
 	passwordResetTemplate = "password_reset.tmpl"
+	invitationTemplate    = "organization_invitation.tmpl"
 )
 
-// Creates a new Mailer
-func New(cfg config.Config, logger xlogger.Logger) Mailer {
+// Creates a new Mailer that reports sends to registry
+func New(cfg config.Config, logger xlogger.Logger, registry *metrics.Registry) Mailer {
 	isLocal := cfg.IsLocal()
 	var dialer *mail.Dialer
 
@@ -63,6 +69,7 @@ func New(cfg config.Config, logger xlogger.Logger) Mailer {
 
 	return &Mail{
 		dialer: dialer,
+		emails: registry.Counter("mailer_emails_total", "Emails by template and status (sent, failed, or skipped).", "template", "status"),
 		logger: logger,
 		sender: cfg.SMTP.Sender,
 		skip:   isLocal,
@@ -70,29 +77,58 @@ func New(cfg config.Config, logger xlogger.Logger) Mailer {
 }
 
 // Sends a welcome email
-func (m Mail) SendWelcomeEmail(recipient string, data map[string]string) *xerrors.AppError {
+func (m Mail) SendWelcomeEmail(ctx context.Context, recipient string, data map[string]string) *xerrors.AppError {
 	if m.skip {
-		m.logger.Info("Welcome Email", "token", data["activateToken"])
+		m.logger.InfoContext(ctx, "Welcome Email", "token", data["activateToken"])
+		m.emails.Inc(welcomeTemplate, "skipped")
 		return nil
 	}
-	return m.send(recipient, welcomeTemplate, data)
+	return m.send(ctx, recipient, welcomeTemplate, data)
 }
 
 // Sends a password reset email
-func (m Mail) SendPasswordResetEmail(recipient string, data map[string]string) *xerrors.AppError {
+func (m Mail) SendPasswordResetEmail(ctx context.Context, recipient string, data map[string]string) *xerrors.AppError {
 	if m.skip {
-		m.logger.Info("Password Reset", "token", data["passwordResetToken"])
+		m.logger.InfoContext(ctx, "Password Reset", "token", data["passwordResetToken"])
+		m.emails.Inc(passwordResetTemplate, "skipped")
 		return nil
 	}
-	return m.send(recipient, passwordResetTemplate, data)
+	return m.send(ctx, recipient, passwordResetTemplate, data)
+}
+
+// Sends an organization invitation email
+func (m Mail) SendInvitationEmail(ctx context.Context, recipient string, data map[string]string) *xerrors.AppError {
+	if m.skip {
+		m.logger.InfoContext(ctx, "Invitation", "token", data["invitationToken"])
+		m.emails.Inc(invitationTemplate, "skipped")
+		return nil
+	}
+	return m.send(ctx, recipient, invitationTemplate, data)
 }
 
 // ============================================================================
 // Private
 // ============================================================================
 
-// Sends an email to a recipient using the specified template
-func (m Mail) send(recipient, templateFile string, data any) *xerrors.AppError {
+// Sends an email to a recipient using the specified template, counting and
+// tracing the result
+func (m Mail) send(ctx context.Context, recipient, templateFile string, data any) *xerrors.AppError {
+	_, span := tracing.Start(ctx, "mailer.Send")
+	span.SetAttribute("mailer.template", templateFile)
+	defer span.End()
+
+	err := m.deliver(recipient, templateFile, data)
+	if err != nil {
+		m.emails.Inc(templateFile, "failed")
+		span.RecordError(err)
+	} else {
+		m.emails.Inc(templateFile, "sent")
+	}
+	return err
+}
+
+// Renders and delivers an email, retrying failed deliveries
+func (m Mail) deliver(recipient, templateFile string, data any) *xerrors.AppError {
 	tmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
 	if err != nil {
 		return xerrors.ServerError(
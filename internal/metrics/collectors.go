@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"database/sql"
+	"runtime"
+)
+
+// ============================================================================
+// Runtime
+// ============================================================================
+
+// Collects Go runtime metrics
+type runtimeCollector struct{}
+
+// Creates a collector for goroutines, memory, and garbage collection
+func Runtime() Collector {
+	return runtimeCollector{}
+}
+
+func (runtimeCollector) Collect(w *Writer) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	w.Family("go_info", "Information about the Go environment.", "gauge")
+	w.Sample("go_info", []string{"version"}, []string{runtime.Version()}, 1)
+
+	w.Family("go_goroutines", "Number of goroutines that currently exist.", "gauge")
+	w.Sample("go_goroutines", nil, nil, float64(runtime.NumGoroutine()))
+
+	w.Family("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", "gauge")
+	w.Sample("go_memstats_alloc_bytes", nil, nil, float64(stats.Alloc))
+
+	w.Family("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", "gauge")
+	w.Sample("go_memstats_heap_inuse_bytes", nil, nil, float64(stats.HeapInuse))
+
+	w.Family("go_memstats_sys_bytes", "Number of bytes obtained from system.", "gauge")
+	w.Sample("go_memstats_sys_bytes", nil, nil, float64(stats.Sys))
+
+	w.Family("go_gc_cycles_total", "Number of completed GC cycles.", "counter")
+	w.Sample("go_gc_cycles_total", nil, nil, float64(stats.NumGC))
+
+	w.Family("go_gc_pause_seconds_total", "Total time spent in GC stop-the-world pauses.", "counter")
+	w.Sample("go_gc_pause_seconds_total", nil, nil, float64(stats.PauseTotalNs)/1e9)
+}
+
+// ============================================================================
+// Database
+// ============================================================================
+
+// Collects connection pool statistics
+type dbCollector struct {
+	db *sql.DB
+}
+
+// Creates a collector for the connection pool stats of db
+func DB(db *sql.DB) Collector {
+	return dbCollector{db: db}
+}
+
+func (c dbCollector) Collect(w *Writer) {
+	stats := c.db.Stats()
+
+	gauges := []struct {
+		name  string
+		help  string
+		value int
+	}{
+		{"go_sql_max_open_connections", "Maximum number of open connections to the database.", stats.MaxOpenConnections},
+		{"go_sql_open_connections", "The number of established connections both in use and idle.", stats.OpenConnections},
+		{"go_sql_in_use_connections", "The number of connections currently in use.", stats.InUse},
+		{"go_sql_idle_connections", "The number of idle connections.", stats.Idle},
+	}
+	for _, g := range gauges {
+		w.Family(g.name, g.help, "gauge")
+		w.Sample(g.name, nil, nil, float64(g.value))
+	}
+
+	counters := []struct {
+		name  string
+		help  string
+		value int64
+	}{
+		{"go_sql_wait_count_total", "The total number of connections waited for.", stats.WaitCount},
+		{"go_sql_max_idle_closed_total", "The total number of connections closed due to SetMaxIdleConns.", stats.MaxIdleClosed},
+		{"go_sql_max_idle_time_closed_total", "The total number of connections closed due to SetConnMaxIdleTime.", stats.MaxIdleTimeClosed},
+		{"go_sql_max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime.", stats.MaxLifetimeClosed},
+	}
+	for _, c := range counters {
+		w.Family(c.name, c.help, "counter")
+		w.Sample(c.name, nil, nil, float64(c.value))
+	}
+
+	w.Family("go_sql_wait_duration_seconds_total", "The total time blocked waiting for a new connection.", "counter")
+	w.Sample("go_sql_wait_duration_seconds_total", nil, nil, stats.WaitDuration.Seconds())
+}
@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// ============================================================================
+// Counter
+// ============================================================================
+
+// A monotonically increasing value for each combination of label values
+type Counter struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+// The value for one combination of label values
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+func newCounter(name, help string, labels []string) *Counter {
+	return &Counter{name: name, help: help, labels: labels, values: map[string]*counterValue{}}
+}
+
+// Increments the counter for the label values by one
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Increments the counter for the label values by delta, which must not be
+// negative
+func (c *Counter) Add(delta float64, values ...string) {
+	checkLabels(c.name, c.labels, values)
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", c.name))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := labelKey(values)
+	v, ok := c.values[key]
+	if !ok {
+		v = &counterValue{labels: append([]string{}, values...)}
+		c.values[key] = v
+	}
+	v.value += delta
+}
+
+// Returns the current value for the label values
+func (c *Counter) Value(values ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if v, ok := c.values[labelKey(values)]; ok {
+		return v.value
+	}
+	return 0
+}
+
+func (c *Counter) Collect(w *Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w.Family(c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		v := c.values[key]
+		w.Sample(c.name, c.labels, v.labels, v.value)
+	}
+}
+
+// ============================================================================
+// Histogram
+// ============================================================================
+
+// The default buckets in seconds, suitable for request latencies
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Counts observations into buckets for each combination of label values
+type Histogram struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	values map[string]*histogramValue
+}
+
+// The buckets for one combination of label values
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(name, help string, buckets []float64, labels []string) *Histogram {
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+
+	return &Histogram{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		values:  map[string]*histogramValue{},
+	}
+}
+
+// Records an observation for the label values
+func (h *Histogram) Observe(value float64, values ...string) {
+	checkLabels(h.name, h.labels, values)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := labelKey(values)
+	v, ok := h.values[key]
+	if !ok {
+		v = &histogramValue{labels: append([]string{}, values...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = v
+	}
+
+	// Buckets are stored individually and accumulated when collected
+	i := sort.SearchFloat64s(h.buckets, value)
+	if i < len(h.buckets) {
+		v.counts[i]++
+	}
+	v.count++
+	v.sum += value
+}
+
+func (h *Histogram) Collect(w *Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	w.Family(h.name, h.help, "histogram")
+	labels := append(append([]string{}, h.labels...), "le")
+
+	for _, key := range sortedKeys(h.values) {
+		v := h.values[key]
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += v.counts[i]
+			w.Sample(h.name+"_bucket", labels, append(append([]string{}, v.labels...), formatFloat(bound)), float64(cumulative))
+		}
+		w.Sample(h.name+"_bucket", labels, append(append([]string{}, v.labels...), formatFloat(math.Inf(1))), float64(v.count))
+		w.Sample(h.name+"_sum", h.labels, v.labels, v.sum)
+		w.Sample(h.name+"_count", h.labels, v.labels, float64(v.count))
+	}
+}
+
+// ============================================================================
+// Helper
+// ============================================================================
+
+// Panics if the label values do not match the label names, which is always a
+// programming error
+func checkLabels(name string, labels, values []string) {
+	if len(labels) != len(values) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", name, len(labels), len(values)))
+	}
+}
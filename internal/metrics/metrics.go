@@ -0,0 +1,182 @@
+// metrics provides counters, histograms, and collectors exposed in the
+// Prometheus text exposition format
+//
+// Instruments are created on a Registry, which is served with Handler.
+// Label values must come from a small, fixed set (e.g. route patterns, not
+// raw paths) to keep the number of series bounded.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ============================================================================
+// Registry
+// ============================================================================
+
+// Writes one or more metric families when the registry is scraped
+type Collector interface {
+	Collect(w *Writer)
+}
+
+// Holds the collectors exposed by the metrics endpoint
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+// Creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Adds a collector to the registry
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+// Creates and registers a counter with the given label names
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := newCounter(name, help, labels)
+	r.Register(c)
+	return c
+}
+
+// Creates and registers a histogram with the given buckets and label names
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := newHistogram(name, help, buckets, labels)
+	r.Register(h)
+	return h
+}
+
+// Registers a gauge whose value is read when scraped
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.Register(funcCollector{name: name, help: help, typ: "gauge", fn: fn})
+}
+
+// Registers a counter whose value is read when scraped
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.Register(funcCollector{name: name, help: help, typ: "counter", fn: fn})
+}
+
+// Writes every collector in the text exposition format
+func (r *Registry) Write() []byte {
+	r.mu.Lock()
+	collectors := append([]Collector{}, r.collectors...)
+	r.mu.Unlock()
+
+	w := &Writer{}
+	for _, c := range collectors {
+		c.Collect(w)
+	}
+
+	return w.buf.Bytes()
+}
+
+// Serves the registry in the text exposition format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(r.Write())
+	})
+}
+
+// ============================================================================
+// Writer
+// ============================================================================
+
+// Formats metric families in the text exposition format
+type Writer struct {
+	buf bytes.Buffer
+}
+
+// Writes the HELP and TYPE lines that start a metric family
+func (w *Writer) Family(name, help, typ string) {
+	fmt.Fprintf(&w.buf, "# HELP %s %s\n", name, escapeHelp(help))
+	fmt.Fprintf(&w.buf, "# TYPE %s %s\n", name, typ)
+}
+
+// Writes a single sample with matching label names and values
+func (w *Writer) Sample(name string, labels, values []string, value float64) {
+	w.buf.WriteString(name)
+
+	if len(labels) > 0 {
+		w.buf.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			fmt.Fprintf(&w.buf, `%s="%s"`, label, escapeLabel(values[i]))
+		}
+		w.buf.WriteByte('}')
+	}
+
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(formatFloat(value))
+	w.buf.WriteByte('\n')
+}
+
+// ============================================================================
+// Helper
+// ============================================================================
+
+// A collector for a single value read when scraped
+type funcCollector struct {
+	name string
+	help string
+	typ  string
+	fn   func() float64
+}
+
+func (c funcCollector) Collect(w *Writer) {
+	w.Family(c.name, c.help, c.typ)
+	w.Sample(c.name, nil, nil, c.fn())
+}
+
+// Escapes a HELP line
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// Escapes a label value
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// Formats a float as Prometheus expects, including infinities
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Joins label values into a map key
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// Returns the keys of a map in sorted order for stable output
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+)
+
+func TestCounter(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.Counter("requests_total", "Requests.", "route", "status")
+
+	counter.Inc("/b", "200")
+	counter.Inc("/a", "200")
+	counter.Add(2, "/a", "200")
+	counter.Inc(`/"quoted"`, "500")
+
+	want := `# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{route="/\"quoted\"",status="500"} 1
+requests_total{route="/a",status="200"} 3
+requests_total{route="/b",status="200"} 1
+`
+	assert.Equal(t, string(registry.Write()), want)
+	assert.Equal(t, counter.Value("/a", "200"), 3)
+
+	t.Run("Labels", func(t *testing.T) {
+		defer func() { assert.True(t, recover() != nil) }()
+		counter.Inc("/a")
+	})
+}
+
+func TestHistogram(t *testing.T) {
+	registry := NewRegistry()
+	histogram := registry.Histogram("latency_seconds", "Latency.", []float64{1, 0.1}, "route")
+
+	histogram.Observe(0.05, "/a")
+	histogram.Observe(0.1, "/a")
+	histogram.Observe(0.5, "/a")
+	histogram.Observe(2, "/a")
+
+	want := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 2
+latency_seconds_bucket{route="/a",le="1"} 3
+latency_seconds_bucket{route="/a",le="+Inf"} 4
+latency_seconds_sum{route="/a"} 2.65
+latency_seconds_count{route="/a"} 4
+`
+	assert.Equal(t, string(registry.Write()), want)
+}
+
+func TestFuncs(t *testing.T) {
+	registry := NewRegistry()
+	registry.GaugeFunc("running", "Running tasks.", func() float64 { return 3 })
+	registry.CounterFunc("done_total", "Done\ntasks.", func() float64 { return 7 })
+
+	want := `# HELP running Running tasks.
+# TYPE running gauge
+running 3
+# HELP done_total Done\ntasks.
+# TYPE done_total counter
+done_total 7
+`
+	assert.Equal(t, string(registry.Write()), want)
+}
+
+func TestHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Runtime())
+
+	rr := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, rr.Header().Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8")
+	assert.True(t, strings.Contains(rr.Body.String(), "# TYPE go_goroutines gauge"))
+	assert.True(t, strings.Contains(rr.Body.String(), "go_gc_cycles_total "))
+}
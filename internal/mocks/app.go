@@ -2,10 +2,15 @@ package mocks
 
 import (
 	"testing"
+	"time"
 
 	"go-rest-starter.jtbergman.me/internal/app"
+	"go-rest-starter.jtbergman.me/internal/metrics"
 	"go-rest-starter.jtbergman.me/internal/models"
+	"go-rest-starter.jtbergman.me/internal/pubsub"
+	"go-rest-starter.jtbergman.me/internal/ratelimit"
 	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/tracing"
 )
 
 // Creates a mock App
@@ -19,13 +24,24 @@ func App(t *testing.T) *app.App {
 	// Create a shared logger
 	logger := logger()
 
+	// Create a registry per test
+	registry := metrics.NewRegistry()
+
+	// Create rate limit buckets per test
+	limits := ratelimit.NewMemoryStore(time.Minute)
+	t.Cleanup(limits.Close)
+
 	mock := app.New(
-		app.NewBackground(logger),
+		app.NewBackground(logger, registry),
 		cfg,
+		pubsub.NewHub(cfg.Events.History),
+		limits,
 		logger,
 		mail(),
+		registry,
 		models.New(db),
 		rest.New(logger),
+		tracing.New(tracing.NewMemoryExporter()),
 	)
 
 	return mock
@@ -40,3 +56,8 @@ func Logger(app *app.App) *mockLogger {
 func Mailer(app *app.App) *Mail {
 	return app.Mailer.(*Mail)
 }
+
+// Provides access to the spans recorded by the mock Tracer
+func Spans(app *app.App) *tracing.MemoryExporter {
+	return app.Tracer.Exporter().(*tracing.MemoryExporter)
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"os"
+	"time"
 
 	"go-rest-starter.jtbergman.me/internal/config"
 )
@@ -11,6 +12,13 @@ func cfg() config.Config {
 	cfg := config.Config{}
 	cfg.Env = "local"
 	cfg.Port = 4000
+	cfg.Timeout.Request = 5 * time.Second
 	cfg.DB.DSN = os.Getenv("TEST_DSN")
+	cfg.Log.SampleRate = 1
+	cfg.Compression.MinSize = 1024
+	cfg.Idempotency.TTL = 24 * time.Hour
+	cfg.Security.CSP = config.DefaultCSP
+	cfg.Events.Heartbeat = 15 * time.Second
+	cfg.Events.History = 1000
 	return cfg
 }
@@ -19,8 +19,9 @@ import (
 func createTestDB(t *testing.T, dsn string) *sql.DB {
 	db := connectToDB(t, dsn)
 
-	// Cleanup the database
+	// Cleanup the database, newest migration first so constraints are dropped in order
 	migrations := getMigrations(t, db, "down.sql")
+	sort.Sort(sort.Reverse(sort.StringSlice(migrations)))
 	applyMigrations(t, db, migrations)
 
 	// Create the database
@@ -1,9 +1,12 @@
 package mocks
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"sync"
+
+	"go-rest-starter.jtbergman.me/internal/xlogger"
 )
 
 // ============================================================================
@@ -20,7 +23,8 @@ type mockLogger struct {
 
 // Represents a log entry
 type logEntry struct {
-	level   string
+	ctx     context.Context
+	level   slog.Level
 	message string
 	args    []any
 }
@@ -31,7 +35,7 @@ type logEntry struct {
 
 // Mock Logger
 func logger() *mockLogger {
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	logger := slog.New(xlogger.NewHandler(slog.NewTextHandler(os.Stdout, nil)))
 	return &mockLogger{logger: logger, logs: []logEntry{}, record: false}
 }
 
@@ -48,16 +52,7 @@ func (l *mockLogger) End() {
 	l.mu.Lock()
 	l.record = false
 	for _, log := range l.logs {
-		switch log.level {
-		case "DEBUG":
-			l.logger.Debug(log.message, log.args...)
-
-		case "INFO":
-			l.logger.Info(log.message, log.args...)
-
-		case "ERROR":
-			l.logger.Error(log.message, log.args...)
-		}
+		l.logger.Log(log.ctx, log.level, log.message, log.args...)
 	}
 	l.logs = nil
 	l.mu.Unlock()
@@ -72,28 +67,35 @@ func (l *mockLogger) Handler() slog.Handler {
 }
 
 func (l *mockLogger) Debug(msg string, args ...any) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.record {
-		entry := logEntry{level: "DEBUG", message: msg, args: args}
-		l.logs = append(l.logs, entry)
-	}
+	l.log(context.Background(), slog.LevelDebug, msg, args)
 }
 
 func (l *mockLogger) Info(msg string, args ...any) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	if l.record {
-		entry := logEntry{level: "INFO", message: msg, args: args}
-		l.logs = append(l.logs, entry)
-	}
+	l.log(context.Background(), slog.LevelInfo, msg, args)
 }
 
 func (l *mockLogger) Error(msg string, args ...any) {
+	l.log(context.Background(), slog.LevelError, msg, args)
+}
+
+func (l *mockLogger) DebugContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelDebug, msg, args)
+}
+
+func (l *mockLogger) InfoContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelInfo, msg, args)
+}
+
+func (l *mockLogger) ErrorContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelError, msg, args)
+}
+
+// Records an entry while capturing
+func (l *mockLogger) log(ctx context.Context, level slog.Level, msg string, args []any) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	if l.record {
-		entry := logEntry{level: "ERROR", message: msg, args: args}
+		entry := logEntry{ctx: ctx, level: level, message: msg, args: args}
 		l.logs = append(l.logs, entry)
 	}
 }
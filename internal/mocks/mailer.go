@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"sync"
 
 	"go-rest-starter.jtbergman.me/internal/xerrors"
@@ -13,6 +14,8 @@ type Mail struct {
 	WelcomeActivationToken string
 	PasswordResetCount     int
 	PasswordResetToken     string
+	InvitationCount        int
+	InvitationToken        string
 }
 
 // Create a mock mail
@@ -21,7 +24,7 @@ func mail() *Mail {
 }
 
 // Sends a welcome email
-func (m *Mail) SendWelcomeEmail(recipient string, data map[string]string) *xerrors.AppError {
+func (m *Mail) SendWelcomeEmail(ctx context.Context, recipient string, data map[string]string) *xerrors.AppError {
 	m.mu.Lock()
 	m.WelcomeCount += 1
 	m.WelcomeActivationToken = data["activateToken"]
@@ -30,10 +33,19 @@ func (m *Mail) SendWelcomeEmail(recipient string, data map[string]string) *xerro
 }
 
 // Sends a password reset email
-func (m *Mail) SendPasswordResetEmail(recipient string, data map[string]string) *xerrors.AppError {
+func (m *Mail) SendPasswordResetEmail(ctx context.Context, recipient string, data map[string]string) *xerrors.AppError {
 	m.mu.Lock()
 	m.PasswordResetCount += 1
 	m.PasswordResetToken = data["passwordResetToken"]
 	m.mu.Unlock()
 	return nil
 }
+
+// Sends an organization invitation email
+func (m *Mail) SendInvitationEmail(ctx context.Context, recipient string, data map[string]string) *xerrors.AppError {
+	m.mu.Lock()
+	m.InvitationCount += 1
+	m.InvitationToken = data["invitationToken"]
+	m.mu.Unlock()
+	return nil
+}
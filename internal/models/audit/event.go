@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/pagination"
+	"go-rest-starter.jtbergman.me/internal/validator"
+)
+
+// ============================================================================
+// Constants
+// ============================================================================
+
+const (
+	ActionActivate             = "user.activate"
+	ActionDelete               = "user.delete"
+	ActionExport               = "user.export"
+	ActionInvitationAccept     = "invitation.accept"
+	ActionInvitationCreate     = "invitation.create"
+	ActionInvitationRevoke     = "invitation.revoke"
+	ActionLogin                = "user.login"
+	ActionLoginFailed          = "user.login_failed"
+	ActionLogout               = "user.logout"
+	ActionMemberAdd            = "member.add"
+	ActionPasswordReset        = "user.password_reset"
+	ActionPasswordResetRequest = "user.password_reset_request"
+	ActionPermissionGrant      = "permission.grant"
+	ActionPermissionRevoke     = "permission.revoke"
+	ActionRegister             = "user.register"
+	ActionRevokeTokens         = "user.revoke_tokens"
+	ActionUpdate               = "user.update"
+)
+
+// ============================================================================
+// Event
+// ============================================================================
+
+// An append-only record of a security-relevant event
+//
+// The actor performed the action and the subject is the user it affected. A
+// nil actor means the system or an anonymous client performed the action.
+type Event struct {
+	ID        int64          `json:"id"`
+	ActorID   *int64         `json:"actor_id"`
+	SubjectID *int64         `json:"subject_id"`
+	Action    string         `json:"action"`
+	IP        string         `json:"ip"`
+	UserAgent string         `json:"user_agent"`
+	Metadata  map[string]any `json:"metadata"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Create a new Event, use 0 for an unknown actor or subject
+func NewEvent(action string, actorID, subjectID int64, metadata map[string]any) *Event {
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+
+	return &Event{
+		ActorID:   optional(actorID),
+		SubjectID: optional(subjectID),
+		Action:    action,
+		Metadata:  metadata,
+	}
+}
+
+// Records the client IP and user agent of the request that caused the event
+func (e *Event) WithRequest(r *http.Request) *Event {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	e.IP = ip
+	e.UserAgent = r.UserAgent()
+	return e
+}
+
+// ============================================================================
+// Filters
+// ============================================================================
+
+// Filters for listing audit events, zero values are ignored
+type Filters struct {
+	ActorID   int64
+	SubjectID int64
+	Action    string
+	Since     time.Time
+	Until     time.Time
+	Page      int
+	PageSize  int
+}
+
+// Adds validation errors for invalid filters
+func (f Filters) Validate(v *validator.Validator) {
+	v.Check(f.ActorID >= 0, "actor_id", "must be a positive integer")
+	v.Check(f.SubjectID >= 0, "subject_id", "must be a positive integer")
+	f.Params().Validate(v)
+	v.Check(f.Since.IsZero() || f.Until.IsZero() || f.Since.Before(f.Until), "since", "must be before until")
+}
+
+// The page requested by the filters
+func (f Filters) Params() pagination.Params {
+	return pagination.Params{Page: f.Page, PageSize: f.PageSize}
+}
+
+// ============================================================================
+// Helper
+// ============================================================================
+
+// Converts a zero ID to nil
+func optional(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/validator"
+)
+
+func TestNewEvent(t *testing.T) {
+	t.Run("Optional", func(t *testing.T) {
+		event := NewEvent(ActionLogin, 0, 7, nil)
+		assert.True(t, event.ActorID == nil)
+		assert.Equal(t, *event.SubjectID, 7)
+		assert.True(t, event.Metadata != nil)
+	})
+
+	t.Run("WithRequest", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/", nil)
+		r.RemoteAddr = "203.0.113.7:5555"
+		r.Header.Set("User-Agent", "tests")
+
+		event := NewEvent(ActionLogin, 1, 1, nil).WithRequest(r)
+		assert.Equal(t, event.IP, "203.0.113.7")
+		assert.Equal(t, event.UserAgent, "tests")
+	})
+}
+
+func TestFilters(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		Name    string
+		Filters Filters
+		Valid   bool
+	}{
+		{Name: "Valid", Filters: Filters{Page: 1, PageSize: 20}, Valid: true},
+		{Name: "Page", Filters: Filters{Page: 0, PageSize: 20}, Valid: false},
+		{Name: "PageSize", Filters: Filters{Page: 1, PageSize: 101}, Valid: false},
+		{Name: "ActorID", Filters: Filters{ActorID: -1, Page: 1, PageSize: 20}, Valid: false},
+		{Name: "Range", Filters: Filters{Since: now, Until: now.Add(-time.Hour), Page: 1, PageSize: 20}, Valid: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			v := validator.New()
+			tc.Filters.Validate(v)
+			assert.Equal(t, v.Valid(tc.Name) == nil, tc.Valid)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/models/core"
+	"go-rest-starter.jtbergman.me/internal/pagination"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Interface
+// ============================================================================
+
+// Defines a mockable interface for audit operations
+type AuditRepository interface {
+	GetAll(ctx context.Context, filters Filters) ([]*Event, pagination.Metadata, *xerrors.AppError)
+	Insert(ctx context.Context, event *Event) *xerrors.AppError
+}
+
+func Repository(db core.Queryable) AuditRepository {
+	return &Audit{DB: db}
+}
+
+// ============================================================================
+// Implementation
+// ============================================================================
+
+// Provides access to the append-only audit log
+type Audit struct {
+	DB core.Queryable
+}
+
+// Appends an event to the audit log
+//
+// Sets the following properties on the provided event:
+//
+// Event.ID
+// Event.CreatedAt
+func (m Audit) Insert(ctx context.Context, event *Event) *xerrors.AppError {
+	metadata, err := json.Marshal(event.Metadata)
+	if err != nil {
+		return xerrors.ServerError(
+			"audit.Insert.Marshal",
+			fmt.Errorf("%w: %v", xerrors.ErrServerInternal, err),
+		)
+	}
+
+	query := `
+		INSERT INTO audit_events (actor_id, subject_id, action, ip, user_agent, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	args := []any{event.ActorID, event.SubjectID, event.Action, event.IP, event.UserAgent, metadata}
+	dest := []any{&event.ID, &event.CreatedAt}
+
+	ctx, done := core.Start(ctx, "audit.Insert")
+	defer done()
+
+	if err := m.DB.QueryRowContext(ctx, query, args...).Scan(dest...); err != nil {
+		return xerrors.DatabaseError(err, "audit.Insert")
+	}
+
+	return nil
+}
+
+// Gets a page of audit events matching the filters, newest first
+func (m Audit) GetAll(ctx context.Context, filters Filters) ([]*Event, pagination.Metadata, *xerrors.AppError) {
+	query := `
+		SELECT count(*) OVER(), id, actor_id, subject_id, action, ip, user_agent, metadata, created_at
+		FROM audit_events
+		WHERE ($1::bigint = 0 OR actor_id = $1)
+		AND ($2::bigint = 0 OR subject_id = $2)
+		AND ($3::text = '' OR action = $3)
+		AND ($4::timestamptz IS NULL OR created_at >= $4)
+		AND ($5::timestamptz IS NULL OR created_at < $5)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $6 OFFSET $7
+	`
+	args := []any{
+		filters.ActorID,
+		filters.SubjectID,
+		filters.Action,
+		nullTime(filters.Since),
+		nullTime(filters.Until),
+		filters.PageSize,
+		filters.Params().Offset(),
+	}
+
+	ctx, done := core.Start(ctx, "audit.GetAll")
+	defer done()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, pagination.Metadata{}, xerrors.DatabaseError(err, "audit.GetAll.QueryContext")
+	}
+	defer rows.Close()
+
+	total := 0
+	all := []*Event{}
+
+	for rows.Next() {
+		var event Event
+		var actorID, subjectID sql.NullInt64
+		var metadata []byte
+		dest := []any{
+			&total,
+			&event.ID,
+			&actorID,
+			&subjectID,
+			&event.Action,
+			&event.IP,
+			&event.UserAgent,
+			&metadata,
+			&event.CreatedAt,
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, pagination.Metadata{}, xerrors.DatabaseError(err, "audit.GetAll.Scan")
+		}
+		if err := json.Unmarshal(metadata, &event.Metadata); err != nil {
+			return nil, pagination.Metadata{}, xerrors.ServerError(
+				"audit.GetAll.Unmarshal",
+				fmt.Errorf("%w: %v", xerrors.ErrServerInternal, err),
+			)
+		}
+		event.ActorID = optional(actorID.Int64)
+		event.SubjectID = optional(subjectID.Int64)
+		all = append(all, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Metadata{}, xerrors.DatabaseError(err, "audit.GetAll.Err")
+	}
+
+	return all, pagination.NewMetadata(total, filters.Params()), nil
+}
+
+// ============================================================================
+// Helper
+// ============================================================================
+
+// Converts a zero time to a SQL NULL
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
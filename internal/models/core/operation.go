@@ -0,0 +1,30 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/tracing"
+)
+
+// The longest a repository operation may take when ctx has no deadline
+const DefaultOperationTimeout = 3 * time.Second
+
+// Starts a span for a repository operation (e.g. "users.GetByEmail"). Call
+// done when the operation completes.
+//
+// Queries are bounded by ctx, so a request's queries share its route timeout.
+// Contexts without a deadline, e.g. from background jobs, the admin CLI, or
+// event streams, are bounded by DefaultOperationTimeout instead.
+func Start(ctx context.Context, op string) (context.Context, func()) {
+	ctx, span := tracing.Start(ctx, op)
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, span.End
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultOperationTimeout)
+	return ctx, func() {
+		cancel()
+		span.End()
+	}
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+)
+
+func TestStart(t *testing.T) {
+	t.Run("NoDeadline", func(t *testing.T) {
+		ctx, done := Start(context.Background(), "test.NoDeadline")
+		defer done()
+
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.True(t, time.Until(deadline) <= DefaultOperationTimeout)
+		assert.True(t, time.Until(deadline) > DefaultOperationTimeout-time.Second)
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		parent, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		ctx, done := Start(parent, "test.Deadline")
+		defer done()
+
+		expected, _ := parent.Deadline()
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.Equal(t, deadline, expected)
+	})
+
+	t.Run("Done", func(t *testing.T) {
+		ctx, done := Start(context.Background(), "test.Done")
+		done()
+		assert.Equal(t, ctx.Err(), context.Canceled)
+	})
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ============================================================================
+// Tenant
+// ============================================================================
+
+// A Queryable scoped to one organization
+//
+// The organization ID is always bound as $1, so other arguments start at $2.
+// Repositories for tables owned by an organization should hold a Tenant
+// instead of a Queryable, so their queries cannot run without the ID. A query
+// that does not use $1 fails, since Postgres cannot infer its type.
+type Tenant struct {
+	db             Queryable
+	organizationID int64
+}
+
+// Creates a Tenant for the organization
+func NewTenant(db Queryable, organizationID int64) Tenant {
+	return Tenant{db: db, organizationID: organizationID}
+}
+
+// Returns the ID of the organization the tenant is scoped to
+func (t Tenant) OrganizationID() int64 {
+	return t.organizationID
+}
+
+func (t Tenant) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return t.db.QueryContext(ctx, query, t.bind(args)...)
+}
+
+func (t Tenant) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return t.db.QueryRowContext(ctx, query, t.bind(args)...)
+}
+
+func (t Tenant) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return t.db.ExecContext(ctx, query, t.bind(args)...)
+}
+
+// Prepends the organization ID to the arguments
+func (t Tenant) bind(args []any) []any {
+	return append([]any{t.organizationID}, args...)
+}
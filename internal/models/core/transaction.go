@@ -0,0 +1,28 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// Runs fn in a transaction, committing if it returns nil and rolling back
+// otherwise. Repositories created from tx inside fn share the transaction.
+func Transaction(ctx context.Context, db *sql.DB, op string, fn func(tx *sql.Tx) *xerrors.AppError) *xerrors.AppError {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return xerrors.DatabaseError(err, op+".BeginTx")
+	}
+
+	if appErr := fn(tx); appErr != nil {
+		tx.Rollback()
+		return appErr
+	}
+
+	if err := tx.Commit(); err != nil {
+		return xerrors.DatabaseError(err, op+".Commit")
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// ============================================================================
+// Key
+// ============================================================================
+
+// The first response to a request made with an Idempotency-Key
+//
+// Key identifies the client and the header value, and Fingerprint identifies
+// the request so a key reused for a different request can be rejected. A
+// zero Status means the first request is still in flight.
+type Key struct {
+	Key         string
+	Fingerprint string
+	Status      int
+	Header      http.Header
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Create a new in-flight Key leased until it expires after lease
+//
+// The lease should cover the request's timeout, so a key whose request died
+// without releasing it can be locked again soon after. Completing the key
+// extends it to the TTL its response is stored for.
+func New(key, fingerprint string, lease time.Duration) *Key {
+	return &Key{
+		Key:         key,
+		Fingerprint: fingerprint,
+		Header:      http.Header{},
+		Body:        []byte{},
+		ExpiresAt:   time.Now().Add(lease),
+	}
+}
+
+// Returns true while the first request has not completed
+func (k *Key) InFlight() bool {
+	return k.Status == 0
+}
+
+// Hashes the method, path, and body of a request
+func Fingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,178 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go-rest-starter.jtbergman.me/internal/models/core"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Interface
+// ============================================================================
+
+// Defines a mockable interface for idempotency key operations
+type IdempotencyRepository interface {
+	Lock(ctx context.Context, key *Key) (bool, *xerrors.AppError)
+	Get(ctx context.Context, key string) (*Key, *xerrors.AppError)
+	Complete(ctx context.Context, key *Key) *xerrors.AppError
+	Release(ctx context.Context, key *Key) (int64, *xerrors.AppError)
+	DeleteExpired(ctx context.Context) (int64, *xerrors.AppError)
+}
+
+func Repository(db core.Queryable) IdempotencyRepository {
+	return &Idempotency{DB: db}
+}
+
+// ============================================================================
+// Implementation
+// ============================================================================
+
+// Provides access to the stored responses for idempotency keys
+type Idempotency struct {
+	DB core.Queryable
+}
+
+// Inserts an in-flight key, replacing it if it has expired
+//
+// Returns true if the caller holds the key and should handle the request, or
+// false if the key is in use and the stored Key should be read with Get.
+func (m Idempotency) Lock(ctx context.Context, key *Key) (bool, *xerrors.AppError) {
+	query := `
+		INSERT INTO idempotency_keys (key, fingerprint, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+			status = NULL,
+			header = '{}',
+			body = '',
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+		RETURNING created_at
+	`
+	args := []any{key.Key, key.Fingerprint, key.ExpiresAt}
+
+	ctx, done := core.Start(ctx, "idempotency.Lock")
+	defer done()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&key.CreatedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+
+	case err != nil:
+		return false, xerrors.DatabaseError(err, "idempotency.Lock")
+	}
+
+	return true, nil
+}
+
+// Gets an unexpired key
+func (m Idempotency) Get(ctx context.Context, key string) (*Key, *xerrors.AppError) {
+	query := `
+		SELECT key, fingerprint, COALESCE(status, 0), header, body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE key = $1 AND expires_at > NOW()
+	`
+
+	var result Key
+	var header []byte
+	dest := []any{
+		&result.Key,
+		&result.Fingerprint,
+		&result.Status,
+		&header,
+		&result.Body,
+		&result.CreatedAt,
+		&result.ExpiresAt,
+	}
+
+	ctx, done := core.Start(ctx, "idempotency.Get")
+	defer done()
+
+	if err := m.DB.QueryRowContext(ctx, query, key).Scan(dest...); err != nil {
+		return nil, xerrors.DatabaseError(err, "idempotency.Get")
+	}
+
+	if err := json.Unmarshal(header, &result.Header); err != nil {
+		return nil, xerrors.ServerError(
+			"idempotency.Get.Unmarshal",
+			fmt.Errorf("%w: %v", xerrors.ErrServerInternal, err),
+		)
+	}
+
+	return &result, nil
+}
+
+// Stores the response for an in-flight key and extends it to key.ExpiresAt
+//
+// Only the lock taken by key is completed, so a request that outlived its
+// lease does not overwrite the retry that locked the key after it.
+func (m Idempotency) Complete(ctx context.Context, key *Key) *xerrors.AppError {
+	header, err := json.Marshal(key.Header)
+	if err != nil {
+		return xerrors.ServerError(
+			"idempotency.Complete.Marshal",
+			fmt.Errorf("%w: %v", xerrors.ErrServerInternal, err),
+		)
+	}
+
+	query := `
+		UPDATE idempotency_keys
+		SET status = $1, header = $2, body = $3, expires_at = $4
+		WHERE key = $5 AND created_at = $6 AND status IS NULL
+	`
+	args := []any{key.Status, string(header), key.Body, key.ExpiresAt, key.Key, key.CreatedAt}
+
+	ctx, done := core.Start(ctx, "idempotency.Complete")
+	defer done()
+
+	if _, err := m.DB.ExecContext(ctx, query, args...); err != nil {
+		return xerrors.DatabaseError(err, "idempotency.Complete")
+	}
+
+	return nil
+}
+
+// Deletes an in-flight key so the request can be retried
+//
+// Like Complete, only the lock taken by key is deleted.
+func (m Idempotency) Release(ctx context.Context, key *Key) (int64, *xerrors.AppError) {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE key = $1 AND created_at = $2 AND status IS NULL
+	`
+
+	ctx, done := core.Start(ctx, "idempotency.Release")
+	defer done()
+
+	result, err := m.DB.ExecContext(ctx, query, key.Key, key.CreatedAt)
+	if err != nil {
+		return 0, xerrors.DatabaseError(err, "idempotency.Release")
+	}
+
+	return core.RowsAffected(result, "idempotency.Release")
+}
+
+// Deletes every expired key
+func (m Idempotency) DeleteExpired(ctx context.Context) (int64, *xerrors.AppError) {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE expires_at <= NOW()
+	`
+
+	ctx, done := core.Start(ctx, "idempotency.DeleteExpired")
+	defer done()
+
+	result, err := m.DB.ExecContext(ctx, query)
+	if err != nil {
+		return 0, xerrors.DatabaseError(err, "idempotency.DeleteExpired")
+	}
+
+	return core.RowsAffected(result, "idempotency.DeleteExpired")
+}
@@ -0,0 +1,52 @@
+package invitations
+
+import (
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/models/organizations"
+	"go-rest-starter.jtbergman.me/internal/models/tokens"
+	"go-rest-starter.jtbergman.me/internal/validator"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Type
+// ============================================================================
+
+// Encapsulates the database properties of an organization invitation
+type Invitation struct {
+	ID             int64     `json:"id"`
+	Hash           []byte    `json:"-"`
+	OrganizationID int64     `json:"organization_id"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	InvitedBy      int64     `json:"invited_by"`
+	Expiry         time.Time `json:"expiry"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Create a new Invitation from an invitation token
+func new(organizationID int64, email, role string, token *tokens.Token) (*Invitation, *xerrors.AppError) {
+	v := validator.New()
+	v.IsEmail(email, "email", "is invalid")
+	v.Check(organizations.ValidRole(role), "role", "must be owner, admin, or member")
+
+	if err := v.Valid("invitations.new.valid"); err != nil {
+		return nil, err
+	}
+
+	return &Invitation{
+		Hash:           token.Hash,
+		OrganizationID: organizationID,
+		Email:          email,
+		Role:           role,
+		InvitedBy:      token.UserID,
+		Expiry:         token.Expiry,
+		CreatedAt:      token.CreatedAt,
+	}, nil
+}
+
+// Checks if the invitation can no longer be accepted
+func (i *Invitation) IsExpired() bool {
+	return !time.Now().Before(i.Expiry)
+}
@@ -0,0 +1,151 @@
+package invitations
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/models/core"
+	"go-rest-starter.jtbergman.me/internal/models/tokens"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Interface
+// ============================================================================
+
+// Defines a mockable interface for the invitations of one organization
+type PendingRepository interface {
+	GetAll(ctx context.Context) ([]*Invitation, *xerrors.AppError)
+	Insert(ctx context.Context, invitation *Invitation) *xerrors.AppError
+	New(email, role string, token *tokens.Token) (*Invitation, *xerrors.AppError)
+	Revoke(ctx context.Context, id int64) (int64, *xerrors.AppError)
+}
+
+// Creates the invitations repository for an organization, usually through
+// models.ForOrganization
+func ForOrganization(db core.Queryable, organizationID int64) PendingRepository {
+	return &Pending{tenant: core.NewTenant(db, organizationID)}
+}
+
+// ============================================================================
+// Implementation
+// ============================================================================
+
+// Provides access to the Invitation database methods of one organization
+//
+// Every query is run through a core.Tenant, which binds the organization ID
+// as $1, and filters by organization_id = $1.
+type Pending struct {
+	tenant core.Tenant
+}
+
+// Create Invitation to the organization
+//
+// The token should be created with tokens.ScopeInvitation for the inviting
+// user. Only its hash is stored, the plaintext should be emailed.
+func (m Pending) New(email, role string, token *tokens.Token) (*Invitation, *xerrors.AppError) {
+	invitation, err := new(m.tenant.OrganizationID(), email, role, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// Inserts an invitation to the organization, replacing any existing
+// invitation for the same email
+//
+// Sets the following properties on the provided invitation:
+//
+// Invitation.ID
+// Invitation.OrganizationID
+func (m Pending) Insert(ctx context.Context, invitation *Invitation) *xerrors.AppError {
+	query := `
+		INSERT INTO invitations (organization_id, hash, email, role, invited_by, expiry, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (organization_id, email) DO UPDATE
+		SET hash = EXCLUDED.hash, role = EXCLUDED.role, invited_by = EXCLUDED.invited_by,
+			expiry = EXCLUDED.expiry, created_at = EXCLUDED.created_at
+		RETURNING id, organization_id
+	`
+	args := []any{
+		invitation.Hash,
+		invitation.Email,
+		invitation.Role,
+		invitation.InvitedBy,
+		invitation.Expiry,
+		invitation.CreatedAt,
+	}
+
+	ctx, done := core.Start(ctx, "invitations.Pending.Insert")
+	defer done()
+
+	if err := m.tenant.QueryRowContext(ctx, query, args...).Scan(&invitation.ID, &invitation.OrganizationID); err != nil {
+		return xerrors.DatabaseError(err, "invitations.Pending.Insert")
+	}
+
+	return nil
+}
+
+// Gets the unexpired invitations to the organization
+func (m Pending) GetAll(ctx context.Context) ([]*Invitation, *xerrors.AppError) {
+	query := `
+		SELECT id, organization_id, email, role, invited_by, expiry, created_at
+		FROM invitations
+		WHERE organization_id = $1 AND expiry > $2
+		ORDER BY created_at, id
+	`
+
+	ctx, done := core.Start(ctx, "invitations.Pending.GetAll")
+	defer done()
+
+	rows, err := m.tenant.QueryContext(ctx, query, time.Now())
+	if err != nil {
+		return nil, xerrors.DatabaseError(err, "invitations.Pending.GetAll.QueryContext")
+	}
+	defer rows.Close()
+
+	all := []*Invitation{}
+
+	for rows.Next() {
+		var invitation Invitation
+		var invitedBy sql.NullInt64
+		dest := []any{
+			&invitation.ID,
+			&invitation.OrganizationID,
+			&invitation.Email,
+			&invitation.Role,
+			&invitedBy,
+			&invitation.Expiry,
+			&invitation.CreatedAt,
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, xerrors.DatabaseError(err, "invitations.Pending.GetAll.Scan")
+		}
+		invitation.InvitedBy = invitedBy.Int64
+		all = append(all, &invitation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, xerrors.DatabaseError(err, "invitations.Pending.GetAll.Err")
+	}
+
+	return all, nil
+}
+
+// Revokes an invitation to the organization
+func (m Pending) Revoke(ctx context.Context, id int64) (int64, *xerrors.AppError) {
+	query := `DELETE FROM invitations WHERE organization_id = $1 AND id = $2`
+
+	ctx, done := core.Start(ctx, "invitations.Pending.Revoke")
+	defer done()
+
+	result, err := m.tenant.ExecContext(ctx, query, id)
+	if err != nil {
+		return 0, xerrors.DatabaseError(err, "invitations.Pending.Revoke")
+	}
+
+	return core.RowsAffected(result, "invitations.Pending.Revoke")
+}
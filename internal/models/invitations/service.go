@@ -0,0 +1,70 @@
+package invitations
+
+import (
+	"context"
+	"database/sql"
+
+	"go-rest-starter.jtbergman.me/internal/models/core"
+	"go-rest-starter.jtbergman.me/internal/models/tokens"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Interface
+// ============================================================================
+
+// Defines a mockable interface for invitation operations
+//
+// Invitations are owned by an organization, so they are only reachable
+// through ForOrganization, except by the token emailed to the invitee.
+type InvitationsRepository interface {
+	Claim(ctx context.Context, plaintext string) (*Invitation, *xerrors.AppError)
+}
+
+func Repository(db core.Queryable) InvitationsRepository {
+	return &Invitations{DB: db}
+}
+
+// ============================================================================
+// Implementation
+// ============================================================================
+
+// Provides access to Invitation database methods
+type Invitations struct {
+	DB core.Queryable
+}
+
+// Deletes an invitation given its token and returns it, including expired
+// invitations
+//
+// Run it in a transaction with the membership it grants, so an invitation is
+// accepted at most once and is not used up if accepting fails.
+func (m Invitations) Claim(ctx context.Context, plaintext string) (*Invitation, *xerrors.AppError) {
+	query := `
+		DELETE FROM invitations
+		WHERE hash = $1
+		RETURNING id, hash, organization_id, email, role, invited_by, expiry, created_at
+	`
+	var invitation Invitation
+	var invitedBy sql.NullInt64
+	dest := []any{
+		&invitation.ID,
+		&invitation.Hash,
+		&invitation.OrganizationID,
+		&invitation.Email,
+		&invitation.Role,
+		&invitedBy,
+		&invitation.Expiry,
+		&invitation.CreatedAt,
+	}
+
+	ctx, done := core.Start(ctx, "invitations.Claim")
+	defer done()
+
+	if err := m.DB.QueryRowContext(ctx, query, tokens.Hash(plaintext)).Scan(dest...); err != nil {
+		return nil, xerrors.DatabaseError(err, "invitations.Claim")
+	}
+
+	invitation.InvitedBy = invitedBy.Int64
+	return &invitation, nil
+}
@@ -1,24 +1,77 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 
+	"go-rest-starter.jtbergman.me/internal/models/audit"
+	"go-rest-starter.jtbergman.me/internal/models/core"
+	"go-rest-starter.jtbergman.me/internal/models/idempotency"
+	"go-rest-starter.jtbergman.me/internal/models/invitations"
+	"go-rest-starter.jtbergman.me/internal/models/organizations"
 	"go-rest-starter.jtbergman.me/internal/models/permissions"
 	"go-rest-starter.jtbergman.me/internal/models/tokens"
 	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
 )
 
 // Encapsulates all the models
 type Models struct {
-	Permissions permissions.PermissionsRepository
-	Tokens      tokens.TokensRepository
-	Users       users.UsersRepository
+	Audit         audit.AuditRepository
+	Idempotency   idempotency.IdempotencyRepository
+	Invitations   invitations.InvitationsRepository
+	Organizations organizations.OrganizationsRepository
+	Permissions   permissions.PermissionsRepository
+	Tokens        tokens.TokensRepository
+	Users         users.UsersRepository
+
+	db        *sql.DB
+	queryable core.Queryable
+}
+
+// The repositories for data owned by one organization
+//
+// Each runs its queries through a core.Tenant, so memberships and
+// invitations cannot be queried without filtering by the organization.
+type Organization struct {
+	Invitations invitations.PendingRepository
+	Members     organizations.MembersRepository
 }
 
 func New(db *sql.DB) *Models {
+	models := repositories(db)
+	models.db = db
+	return models
+}
+
+// Runs fn with models that share a transaction, committed if fn returns nil
+// and rolled back otherwise
+func (m *Models) Transaction(ctx context.Context, fn func(models *Models) *xerrors.AppError) *xerrors.AppError {
+	return core.Transaction(ctx, m.db, "models.Transaction", func(tx *sql.Tx) *xerrors.AppError {
+		return fn(repositories(tx))
+	})
+}
+
+// Returns the repositories for the organization's data, sharing the
+// transaction if m was created by Transaction
+func (m *Models) ForOrganization(organizationID int64) *Organization {
+	return &Organization{
+		Invitations: invitations.ForOrganization(m.queryable, organizationID),
+		Members:     organizations.ForOrganization(m.queryable, organizationID),
+	}
+}
+
+// Creates every repository on the same Queryable
+func repositories(db core.Queryable) *Models {
 	return &Models{
-		Permissions: permissions.Repository(db),
-		Tokens:      tokens.Repository(db),
-		Users:       users.Repository(db),
+		Audit:         audit.Repository(db),
+		Idempotency:   idempotency.Repository(db),
+		Invitations:   invitations.Repository(db),
+		Organizations: organizations.Repository(db),
+		Permissions:   permissions.Repository(db),
+		Tokens:        tokens.Repository(db),
+		Users:         users.Repository(db),
+
+		queryable: db,
 	}
 }
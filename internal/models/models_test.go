@@ -0,0 +1,114 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/models/invitations"
+	"go-rest-starter.jtbergman.me/internal/models/organizations"
+	"go-rest-starter.jtbergman.me/internal/models/tokens"
+)
+
+// Every query run through ForOrganization filters by the organization
+func TestForOrganization(t *testing.T) {
+	db, statements := recordingDB()
+	scoped := (&Models{queryable: db}).ForOrganization(7)
+	ctx := context.Background()
+
+	token := &tokens.Token{UserID: 1, Expiry: time.Now().Add(time.Hour), CreatedAt: time.Now()}
+	invitation, err := scoped.Invitations.New("invitee@example.com", organizations.RoleMember, token)
+	assert.Check(t, err == nil)
+	assert.Equal(t, invitation.OrganizationID, 7)
+
+	scoped.Members.Add(ctx, 1, organizations.RoleMember)
+	scoped.Members.Get(ctx, 1)
+	scoped.Members.GetAll(ctx)
+	scoped.Members.Remove(ctx, 1)
+	scoped.Invitations.Insert(ctx, invitation)
+	scoped.Invitations.GetAll(ctx)
+	scoped.Invitations.Revoke(ctx, 1)
+
+	assert.Equal(t, len(*statements), 7)
+	for _, statement := range *statements {
+		assert.True(t, strings.Contains(statement.query, "organization_id = $1") || strings.Contains(statement.query, "VALUES ($1,"))
+		assert.Equal(t, statement.args[0], driver.Value(int64(7)))
+	}
+}
+
+// Memberships and invitations cannot be queried without an organization,
+// since the unscoped repositories have no methods for them
+func TestUnscopedRepositories(t *testing.T) {
+	methods := func(repository any) []string {
+		typ := reflect.TypeOf(repository).Elem()
+		names := []string{}
+		for i := 0; i < typ.NumMethod(); i++ {
+			names = append(names, typ.Method(i).Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	// Organizations are created with their owner and listed for a user
+	assert.Equal(t, strings.Join(methods((*organizations.OrganizationsRepository)(nil)), ","), "GetAllForUser,GetByID,Insert,New")
+
+	// Invitations are claimed by the token emailed to the invitee
+	assert.Equal(t, strings.Join(methods((*invitations.InvitationsRepository)(nil)), ","), "Claim")
+}
+
+// ============================================================================
+// Recording Driver
+// ============================================================================
+
+// A statement run against a recording database
+type statement struct {
+	query string
+	args  []driver.Value
+}
+
+// Returns a database that records statements and returns no rows
+func recordingDB() (*sql.DB, *[]statement) {
+	statements := &[]statement{}
+	return sql.OpenDB(recorder{statements}), statements
+}
+
+type recorder struct {
+	statements *[]statement
+}
+
+func (r recorder) Connect(context.Context) (driver.Conn, error) { return r, nil }
+func (r recorder) Driver() driver.Driver                        { return nil }
+func (r recorder) Prepare(query string) (driver.Stmt, error)    { return recorderStmt{r, query}, nil }
+func (r recorder) Close() error                                 { return nil }
+func (r recorder) Begin() (driver.Tx, error)                    { return nil, driver.ErrSkip }
+
+type recorderStmt struct {
+	recorder recorder
+	query    string
+}
+
+func (s recorderStmt) Close() error  { return nil }
+func (s recorderStmt) NumInput() int { return -1 }
+
+func (s recorderStmt) Exec(args []driver.Value) (driver.Result, error) {
+	*s.recorder.statements = append(*s.recorder.statements, statement{s.query, args})
+	return driver.RowsAffected(0), nil
+}
+
+func (s recorderStmt) Query(args []driver.Value) (driver.Rows, error) {
+	*s.recorder.statements = append(*s.recorder.statements, statement{s.query, args})
+	return recorderRows{}, nil
+}
+
+type recorderRows struct{}
+
+func (recorderRows) Columns() []string              { return nil }
+func (recorderRows) Close() error                   { return nil }
+func (recorderRows) Next(dest []driver.Value) error { return io.EOF }
@@ -0,0 +1,133 @@
+package organizations
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+	"go-rest-starter.jtbergman.me/internal/models/core"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Interface
+// ============================================================================
+
+// Defines a mockable interface for the memberships of one organization
+type MembersRepository interface {
+	Add(ctx context.Context, userID int64, role string) *xerrors.AppError
+	Get(ctx context.Context, userID int64) (*Membership, *xerrors.AppError)
+	GetAll(ctx context.Context) ([]*Membership, *xerrors.AppError)
+	Remove(ctx context.Context, userID int64) (int64, *xerrors.AppError)
+}
+
+// Creates the members repository for an organization, usually through
+// models.ForOrganization
+func ForOrganization(db core.Queryable, organizationID int64) MembersRepository {
+	return &Members{tenant: core.NewTenant(db, organizationID)}
+}
+
+// ============================================================================
+// Implementation
+// ============================================================================
+
+// Provides access to the Membership database methods of one organization
+//
+// Every query is run through a core.Tenant, which binds the organization ID
+// as $1, and filters by organization_id = $1.
+type Members struct {
+	tenant core.Tenant
+}
+
+// Gets a user's membership in the organization
+func (m Members) Get(ctx context.Context, userID int64) (*Membership, *xerrors.AppError) {
+	query := `
+		SELECT organization_id, user_id, role, created_at
+		FROM organization_members
+		WHERE organization_id = $1 AND user_id = $2
+	`
+	var membership Membership
+	dest := []any{&membership.OrganizationID, &membership.UserID, &membership.Role, &membership.CreatedAt}
+
+	ctx, done := core.Start(ctx, "organizations.Members.Get")
+	defer done()
+
+	if err := m.tenant.QueryRowContext(ctx, query, userID).Scan(dest...); err != nil {
+		return nil, xerrors.DatabaseError(err, "organizations.Members.Get")
+	}
+
+	return &membership, nil
+}
+
+// Gets all memberships of the organization
+func (m Members) GetAll(ctx context.Context) ([]*Membership, *xerrors.AppError) {
+	query := `
+		SELECT organization_id, user_id, role, created_at
+		FROM organization_members
+		WHERE organization_id = $1
+		ORDER BY created_at, user_id
+	`
+
+	ctx, done := core.Start(ctx, "organizations.Members.GetAll")
+	defer done()
+
+	rows, err := m.tenant.QueryContext(ctx, query)
+	if err != nil {
+		return nil, xerrors.DatabaseError(err, "organizations.Members.GetAll.QueryContext")
+	}
+	defer rows.Close()
+
+	all := []*Membership{}
+
+	for rows.Next() {
+		var membership Membership
+		dest := []any{&membership.OrganizationID, &membership.UserID, &membership.Role, &membership.CreatedAt}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, xerrors.DatabaseError(err, "organizations.Members.GetAll.Scan")
+		}
+		all = append(all, &membership)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, xerrors.DatabaseError(err, "organizations.Members.GetAll.Err")
+	}
+
+	return all, nil
+}
+
+// Adds a user to the organization, or raises the role of an existing member
+//
+// Existing members never lose a role, so an organization cannot lose its
+// owner this way. Check for xerrors.ErrForeignKeyViolation when the user does
+// not exist.
+func (m Members) Add(ctx context.Context, userID int64, role string) *xerrors.AppError {
+	query := `
+		INSERT INTO organization_members (organization_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		WHERE array_position($4::text[], EXCLUDED.role) > array_position($4::text[], organization_members.role)
+	`
+
+	ctx, done := core.Start(ctx, "organizations.Members.Add")
+	defer done()
+
+	if _, err := m.tenant.ExecContext(ctx, query, userID, role, pq.Array(roles)); err != nil {
+		return xerrors.DatabaseError(err, "organizations.Members.Add")
+	}
+
+	return nil
+}
+
+// Removes a user from the organization
+func (m Members) Remove(ctx context.Context, userID int64) (int64, *xerrors.AppError) {
+	query := `DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2`
+
+	ctx, done := core.Start(ctx, "organizations.Members.Remove")
+	defer done()
+
+	result, err := m.tenant.ExecContext(ctx, query, userID)
+	if err != nil {
+		return 0, xerrors.DatabaseError(err, "organizations.Members.Remove")
+	}
+
+	return core.RowsAffected(result, "organizations.Members.Remove")
+}
@@ -0,0 +1,91 @@
+package organizations
+
+import (
+	"strings"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/validator"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Constants
+// ============================================================================
+
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// Roles from lowest to highest
+var roles = []string{RoleMember, RoleAdmin, RoleOwner}
+
+// Ranks roles so that a higher role includes the lower ones
+var roleRanks = map[string]int{
+	RoleMember: 1,
+	RoleAdmin:  2,
+	RoleOwner:  3,
+}
+
+// Checks if a role is one of the known roles
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// ============================================================================
+// Organization
+// ============================================================================
+
+// Encapsulates the database properties of an organization
+type Organization struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"-"`
+}
+
+// Create a new Organization
+func new(name string) (*Organization, *xerrors.AppError) {
+	name = strings.TrimSpace(name)
+
+	v := validator.New()
+	v.Check(len(name) > 0, "name", "must be provided")
+	v.Check(len(name) <= 100, "name", "must not be more than 100 characters")
+
+	if err := v.Valid("organizations.new.valid"); err != nil {
+		return nil, err
+	}
+
+	return &Organization{Name: name}, nil
+}
+
+// ============================================================================
+// Membership
+// ============================================================================
+
+// Represents a user's role within an organization
+type Membership struct {
+	OrganizationID int64     `json:"organization_id"`
+	UserID         int64     `json:"user_id"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Checks if the membership has at least the given role
+func (m *Membership) HasRole(role string) bool {
+	return roleRanks[m.Role] >= roleRanks[role] && roleRanks[role] > 0
+}
+
+// ============================================================================
+// No Membership
+// ============================================================================
+
+// An empty Membership used when a request has no active organization
+var NoMembership = &Membership{}
+
+// Checks if a membership is the empty membership
+func (m *Membership) IsNone() bool {
+	return m == NoMembership
+}
@@ -0,0 +1,136 @@
+package organizations
+
+import (
+	"context"
+
+	"go-rest-starter.jtbergman.me/internal/models/core"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Interface
+// ============================================================================
+
+// Defines a mockable interface for organization operations
+type OrganizationsRepository interface {
+	GetAllForUser(ctx context.Context, userID int64) ([]*Organization, *xerrors.AppError)
+	GetByID(ctx context.Context, id int64) (*Organization, *xerrors.AppError)
+	Insert(ctx context.Context, organization *Organization, ownerID int64) *xerrors.AppError
+	New(name string) (*Organization, *xerrors.AppError)
+}
+
+func Repository(db core.Queryable) OrganizationsRepository {
+	return &Organizations{DB: db}
+}
+
+// ============================================================================
+// Implementation
+// ============================================================================
+
+// Provides access to Organization database methods
+//
+// Memberships are owned by an organization, so they are only reachable
+// through ForOrganization.
+type Organizations struct {
+	DB core.Queryable
+}
+
+// Create Organization
+func (Organizations) New(name string) (*Organization, *xerrors.AppError) {
+	organization, err := new(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+// Inserts an organization and makes the given user its owner
+//
+// Sets the following properties on the provided organization:
+//
+// Organization.ID
+// Organization.CreatedAt
+// Organization.Version
+func (m Organizations) Insert(ctx context.Context, organization *Organization, ownerID int64) *xerrors.AppError {
+	query := `
+		WITH organization AS (
+			INSERT INTO organizations (name)
+			VALUES ($1)
+			RETURNING id, created_at, version
+		), owner AS (
+			INSERT INTO organization_members (organization_id, user_id, role)
+			SELECT id, $2, $3 FROM organization
+		)
+		SELECT id, created_at, version FROM organization
+	`
+	args := []any{organization.Name, ownerID, RoleOwner}
+	dest := []any{&organization.ID, &organization.CreatedAt, &organization.Version}
+
+	ctx, done := core.Start(ctx, "organizations.Insert")
+	defer done()
+
+	if err := m.DB.QueryRowContext(ctx, query, args...).Scan(dest...); err != nil {
+		return xerrors.DatabaseError(err, "organizations.Insert")
+	}
+
+	return nil
+}
+
+// Gets an organization by its ID
+func (m Organizations) GetByID(ctx context.Context, id int64) (*Organization, *xerrors.AppError) {
+	query := `
+		SELECT id, name, created_at, version
+		FROM organizations
+		WHERE id = $1
+	`
+	var organization Organization
+	dest := []any{&organization.ID, &organization.Name, &organization.CreatedAt, &organization.Version}
+
+	ctx, done := core.Start(ctx, "organizations.GetByID")
+	defer done()
+
+	if err := m.DB.QueryRowContext(ctx, query, id).Scan(dest...); err != nil {
+		return nil, xerrors.DatabaseError(err, "organizations.GetByID")
+	}
+
+	return &organization, nil
+}
+
+// Gets all organizations the user is a member of
+func (m Organizations) GetAllForUser(ctx context.Context, userID int64) ([]*Organization, *xerrors.AppError) {
+	query := `
+		SELECT organizations.id, organizations.name, organizations.created_at, organizations.version
+		FROM organizations
+		INNER JOIN organization_members ON organization_members.organization_id = organizations.id
+		WHERE organization_members.user_id = $1
+		ORDER BY organizations.id
+	`
+
+	ctx, done := core.Start(ctx, "organizations.GetAllForUser")
+	defer done()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, xerrors.DatabaseError(err, "organizations.GetAllForUser.QueryContext")
+	}
+	defer rows.Close()
+
+	all := []*Organization{}
+
+	for rows.Next() {
+		var organization Organization
+		dest := []any{&organization.ID, &organization.Name, &organization.CreatedAt, &organization.Version}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, xerrors.DatabaseError(err, "organizations.GetAllForUser.Scan")
+		}
+		all = append(all, &organization)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, xerrors.DatabaseError(err, "organizations.GetAllForUser.Err")
+	}
+
+	return all, nil
+}
@@ -9,6 +9,14 @@ const (
 	PermissionSuperAdmin = "superadmin"
 )
 
+// Every permission code, matching the rows seeded in the permissions table
+var codes = Perms{PermissionAdmin, PermissionSuperAdmin}
+
+// Checks if a code is a known permission
+func Valid(code string) bool {
+	return codes.Include(code)
+}
+
 // ============================================================================
 // Permission Type
 // ============================================================================
@@ -2,7 +2,6 @@ package permissions
 
 import (
 	"context"
-	"time"
 
 	"github.com/lib/pq"
 	"go-rest-starter.jtbergman.me/internal/models/core"
@@ -14,8 +13,9 @@ import (
 // ===========================================================================
 
 type PermissionsRepository interface {
-	GetByID(userID int64) (Perms, *xerrors.AppError)
-	Insert(userID int64, codes ...string) (int64, *xerrors.AppError)
+	Delete(ctx context.Context, userID int64, codes ...string) (int64, *xerrors.AppError)
+	GetByID(ctx context.Context, userID int64) (Perms, *xerrors.AppError)
+	Insert(ctx context.Context, userID int64, codes ...string) (int64, *xerrors.AppError)
 }
 
 func Repository(db core.Queryable) PermissionsRepository {
@@ -32,7 +32,7 @@ type Permissions struct {
 }
 
 // Gets permissions for the given user
-func (m Permissions) GetByID(userID int64) (Perms, *xerrors.AppError) {
+func (m Permissions) GetByID(ctx context.Context, userID int64) (Perms, *xerrors.AppError) {
 	query := `
 		SELECT permissions.code
 		FROM permissions
@@ -40,8 +40,8 @@ func (m Permissions) GetByID(userID int64) (Perms, *xerrors.AppError) {
 		INNER JOIN users ON user_permissions.user_id = users.id
 		WHERE users.id = $1`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	ctx, done := core.Start(ctx, "permissions.GetByID")
+	defer done()
 
 	rows, err := m.DB.QueryContext(ctx, query, userID)
 	if err != nil {
@@ -68,13 +68,17 @@ func (m Permissions) GetByID(userID int64) (Perms, *xerrors.AppError) {
 }
 
 // Adds a variadic number of permissions for a user
-func (m Permissions) Insert(userID int64, codes ...string) (int64, *xerrors.AppError) {
+//
+// Permissions the user already has and unknown codes are skipped, so the
+// number of rows affected is the number of permissions newly granted.
+func (m Permissions) Insert(ctx context.Context, userID int64, codes ...string) (int64, *xerrors.AppError) {
 	query := `
 		INSERT INTO user_permissions
-		SELECT $1, permissions.id FROM permissions WHERE permissions.code = ANY($2)`
+		SELECT $1, permissions.id FROM permissions WHERE permissions.code = ANY($2)
+		ON CONFLICT DO NOTHING`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	ctx, done := core.Start(ctx, "permissions.Insert")
+	defer done()
 
 	result, err := m.DB.ExecContext(ctx, query, userID, pq.Array(codes))
 	if err != nil {
@@ -83,3 +87,23 @@ func (m Permissions) Insert(userID int64, codes ...string) (int64, *xerrors.AppE
 
 	return core.RowsAffected(result, "permissions.AddForUser")
 }
+
+// Removes a variadic number of permissions from a user
+func (m Permissions) Delete(ctx context.Context, userID int64, codes ...string) (int64, *xerrors.AppError) {
+	query := `
+		DELETE FROM user_permissions
+		USING permissions
+		WHERE user_permissions.permission_id = permissions.id
+		AND user_permissions.user_id = $1
+		AND permissions.code = ANY($2)`
+
+	ctx, done := core.Start(ctx, "permissions.Delete")
+	defer done()
+
+	result, err := m.DB.ExecContext(ctx, query, userID, pq.Array(codes))
+	if err != nil {
+		return 0, xerrors.DatabaseError(err, "permissions.Delete")
+	}
+
+	return core.RowsAffected(result, "permissions.Delete")
+}
@@ -14,9 +14,10 @@ import (
 
 type TokensRepository interface {
 	New(userID int64, expiryDuration time.Duration, scope string) (*Token, *xerrors.AppError)
-	Insert(token *Token) (int64, *xerrors.AppError)
-	Delete(plaintext string, scope string) (int64, *xerrors.AppError)
-	DeleteAllForScope(userID int64, scope string) (int64, *xerrors.AppError)
+	Insert(ctx context.Context, token *Token) (int64, *xerrors.AppError)
+	Delete(ctx context.Context, plaintext string, scope string) (int64, *xerrors.AppError)
+	DeleteAllForScope(ctx context.Context, userID int64, scope string) (int64, *xerrors.AppError)
+	DeleteAllForUser(ctx context.Context, userID int64) (int64, *xerrors.AppError)
 }
 
 func Repository(db core.Queryable) TokensRepository {
@@ -50,15 +51,15 @@ func (Tokens) New(userID int64, expiryDuration time.Duration, scope string) (*To
 }
 
 // Insert token
-func (m Tokens) Insert(token *Token) (int64, *xerrors.AppError) {
+func (m Tokens) Insert(ctx context.Context, token *Token) (int64, *xerrors.AppError) {
 	query := `
 		INSERT INTO tokens (hash, user_id, expiry, scope, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 	args := []any{token.Hash, token.UserID, token.Expiry, token.Scope, token.CreatedAt, token.UpdatedAt}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	ctx, done := core.Start(ctx, "tokens.Insert")
+	defer done()
 
 	result, err := m.DB.ExecContext(ctx, query, args...)
 	if err != nil {
@@ -75,11 +76,11 @@ func (m Tokens) Insert(token *Token) (int64, *xerrors.AppError) {
 //	ScopeActivation
 //	ScopeAuthentication
 //	ScopePasswordReset
-func (m Tokens) Delete(plaintext string, scope string) (int64, *xerrors.AppError) {
+func (m Tokens) Delete(ctx context.Context, plaintext string, scope string) (int64, *xerrors.AppError) {
 	hash := Hash(plaintext)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	ctx, done := core.Start(ctx, "tokens.Delete")
+	defer done()
 
 	result, err := m.DB.ExecContext(ctx, "DELETE FROM tokens WHERE hash = $1 AND scope = $2", hash, scope)
 	if err != nil {
@@ -96,9 +97,9 @@ func (m Tokens) Delete(plaintext string, scope string) (int64, *xerrors.AppError
 //	ScopeActivation
 //	ScopeAuthentication
 //	ScopePasswordReset
-func (m Tokens) DeleteAllForScope(userID int64, scope string) (int64, *xerrors.AppError) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+func (m Tokens) DeleteAllForScope(ctx context.Context, userID int64, scope string) (int64, *xerrors.AppError) {
+	ctx, done := core.Start(ctx, "tokens.DeleteAllForScope")
+	defer done()
 
 	result, err := m.DB.ExecContext(ctx, "DELETE FROM tokens WHERE user_id = $1 AND scope = $2", userID, scope)
 	if err != nil {
@@ -107,3 +108,16 @@ func (m Tokens) DeleteAllForScope(userID int64, scope string) (int64, *xerrors.A
 
 	return core.RowsAffected(result, "tokens.DeleteAllForScope")
 }
+
+// Delete all tokens for a user regardless of scope
+func (m Tokens) DeleteAllForUser(ctx context.Context, userID int64) (int64, *xerrors.AppError) {
+	ctx, done := core.Start(ctx, "tokens.DeleteAllForUser")
+	defer done()
+
+	result, err := m.DB.ExecContext(ctx, "DELETE FROM tokens WHERE user_id = $1", userID)
+	if err != nil {
+		return 0, xerrors.DatabaseError(err, "tokens.DeleteAllForUser")
+	}
+
+	return core.RowsAffected(result, "tokens.DeleteAllForUser")
+}
@@ -16,6 +16,7 @@ import (
 const (
 	ScopeActivation     = "activate"
 	ScopeAuthentication = "authneticate"
+	ScopeInvitation     = "invitation"
 	ScopePasswordReset  = "reset"
 )
 
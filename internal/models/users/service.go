@@ -2,10 +2,14 @@ package users
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
+	"go-rest-starter.jtbergman.me/internal/listing"
 	"go-rest-starter.jtbergman.me/internal/models/core"
 	"go-rest-starter.jtbergman.me/internal/models/tokens"
+	"go-rest-starter.jtbergman.me/internal/pagination"
 	"go-rest-starter.jtbergman.me/internal/xerrors"
 )
 
@@ -15,12 +19,16 @@ import (
 
 // Defines a mockable interface for user operations
 type UsersRepository interface {
-	Delete(user *User) (int64, *xerrors.AppError)
-	GetByEmail(email string) (*User, *xerrors.AppError)
-	GetByToken(plaintext string) (*User, *xerrors.AppError)
-	Insert(user *User) *xerrors.AppError
+	Delete(ctx context.Context, user *User) (int64, *xerrors.AppError)
+	Export(ctx context.Context, query listing.Query) (*sql.Rows, *xerrors.AppError)
+	GetAll(ctx context.Context, query listing.Query, params pagination.Params) ([]*User, pagination.Metadata, *xerrors.AppError)
+	GetAllForPermission(ctx context.Context, code string) ([]*User, *xerrors.AppError)
+	GetByEmail(ctx context.Context, email string) (*User, *xerrors.AppError)
+	GetByID(ctx context.Context, id int64) (*User, *xerrors.AppError)
+	GetByToken(ctx context.Context, plaintext string) (*User, *xerrors.AppError)
+	Insert(ctx context.Context, user *User) *xerrors.AppError
 	New(email, plaintext string) (*User, *xerrors.AppError)
-	Update(user *User) *xerrors.AppError
+	Update(ctx context.Context, user *User) *xerrors.AppError
 }
 
 func Repository(db core.Queryable) UsersRepository {
@@ -49,7 +57,8 @@ func (Users) New(email, plaintext string) (*User, *xerrors.AppError) {
 
 // Insert a given user
 //
-// Check for xerrors.ErrUniqueViolation for email conflicts.
+// Check for xerrors.ErrUniqueViolation for email conflicts. Users are only
+// inserted activated if User.Activated was set beforehand.
 //
 // Sets the following properties on the provided users:
 //
@@ -57,17 +66,17 @@ func (Users) New(email, plaintext string) (*User, *xerrors.AppError) {
 // User.Activated
 // User.CreatedAt
 // User.Version
-func (m Users) Insert(user *User) *xerrors.AppError {
+func (m Users) Insert(ctx context.Context, user *User) *xerrors.AppError {
 	query := `
-		INSERT INTO users (email, password)
-		VALUES ($1, $2)
+		INSERT INTO users (email, password, activated)
+		VALUES ($1, $2, $3)
 		RETURNING id, activated, created_at, version
 	`
-	args := []any{user.Email, user.Password}
+	args := []any{user.Email, user.Password, user.Activated}
 	dest := []any{&user.ID, &user.Activated, &user.CreatedAt, &user.Version}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	ctx, done := core.Start(ctx, "users.Insert")
+	defer done()
 
 	if err := m.DB.QueryRowContext(ctx, query, args...).Scan(dest...); err != nil {
 		return xerrors.DatabaseError(err, "users.Insert")
@@ -77,7 +86,7 @@ func (m Users) Insert(user *User) *xerrors.AppError {
 }
 
 // Gets the user by their email
-func (m Users) GetByEmail(email string) (*User, *xerrors.AppError) {
+func (m Users) GetByEmail(ctx context.Context, email string) (*User, *xerrors.AppError) {
 	query := `
 		SELECT id, email, password, activated, created_at, version
 		FROM users
@@ -86,8 +95,8 @@ func (m Users) GetByEmail(email string) (*User, *xerrors.AppError) {
 	var user User
 	dest := []any{&user.ID, &user.Email, &user.Password, &user.Activated, &user.CreatedAt, &user.Version}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	ctx, done := core.Start(ctx, "users.GetByEmail")
+	defer done()
 
 	if err := m.DB.QueryRowContext(ctx, query, email).Scan(dest...); err != nil {
 		return nil, xerrors.DatabaseError(err, "users.GetByEmail")
@@ -96,8 +105,141 @@ func (m Users) GetByEmail(email string) (*User, *xerrors.AppError) {
 	return &user, nil
 }
 
+// Gets the user by their ID
+func (m Users) GetByID(ctx context.Context, id int64) (*User, *xerrors.AppError) {
+	query := `
+		SELECT id, email, password, activated, created_at, version
+		FROM users
+		WHERE id = $1
+	`
+	var user User
+	dest := []any{&user.ID, &user.Email, &user.Password, &user.Activated, &user.CreatedAt, &user.Version}
+
+	ctx, done := core.Start(ctx, "users.GetByID")
+	defer done()
+
+	if err := m.DB.QueryRowContext(ctx, query, id).Scan(dest...); err != nil {
+		return nil, xerrors.DatabaseError(err, "users.GetByID")
+	}
+
+	return &user, nil
+}
+
+// Gets a page of users matching the filters of a query built with ListSpec,
+// in the query's sort order
+func (m Users) GetAll(ctx context.Context, q listing.Query, params pagination.Params) ([]*User, pagination.Metadata, *xerrors.AppError) {
+	where, filterArgs := q.Where(3)
+	query := `
+		SELECT count(*) OVER(), id, email, password, activated, created_at, version
+		FROM users
+		WHERE ` + where + `
+		ORDER BY ` + q.OrderBy() + `
+		LIMIT $1 OFFSET $2
+	`
+	args := append([]any{params.PageSize, params.Offset()}, filterArgs...)
+
+	ctx, done := core.Start(ctx, "users.GetAll")
+	defer done()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, pagination.Metadata{}, xerrors.DatabaseError(err, "users.GetAll.QueryContext")
+	}
+	defer rows.Close()
+
+	total := 0
+	all := []*User{}
+
+	for rows.Next() {
+		var user User
+		dest := []any{&total, &user.ID, &user.Email, &user.Password, &user.Activated, &user.CreatedAt, &user.Version}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, pagination.Metadata{}, xerrors.DatabaseError(err, "users.GetAll.Scan")
+		}
+		all = append(all, &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Metadata{}, xerrors.DatabaseError(err, "users.GetAll.Err")
+	}
+
+	return all, pagination.NewMetadata(total, params), nil
+}
+
+// Gets the rows of every user matching the filters of a query built with
+// ListSpec, in the query's sort order, for streaming with Scan
+//
+// The caller must close the rows. They are read with ctx, so the request's
+// deadline bounds the export.
+func (m Users) Export(ctx context.Context, q listing.Query) (*sql.Rows, *xerrors.AppError) {
+	where, args := q.Where(1)
+	query := `
+		SELECT id, email, activated, created_at, version
+		FROM users
+		WHERE ` + where + `
+		ORDER BY ` + q.OrderBy() + `
+	`
+
+	ctx, done := core.Start(ctx, "users.Export")
+	defer done()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, xerrors.DatabaseError(err, "users.Export.QueryContext")
+	}
+
+	return rows, nil
+}
+
+// Scans a row from Export into a User
+func Scan(rows *sql.Rows) (*User, error) {
+	var user User
+	if err := rows.Scan(&user.ID, &user.Email, &user.Activated, &user.CreatedAt, &user.Version); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Gets all users with the given permission code
+func (m Users) GetAllForPermission(ctx context.Context, code string) ([]*User, *xerrors.AppError) {
+	query := `
+		SELECT users.id, users.email, users.password, users.activated, users.created_at, users.version
+		FROM users
+		INNER JOIN user_permissions ON user_permissions.user_id = users.id
+		INNER JOIN permissions ON user_permissions.permission_id = permissions.id
+		WHERE permissions.code = $1
+		ORDER BY users.id
+	`
+
+	ctx, done := core.Start(ctx, "users.GetAllForPermission")
+	defer done()
+
+	rows, err := m.DB.QueryContext(ctx, query, code)
+	if err != nil {
+		return nil, xerrors.DatabaseError(err, "users.GetAllForPermission.QueryContext")
+	}
+	defer rows.Close()
+
+	all := []*User{}
+
+	for rows.Next() {
+		var user User
+		dest := []any{&user.ID, &user.Email, &user.Password, &user.Activated, &user.CreatedAt, &user.Version}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, xerrors.DatabaseError(err, "users.GetAllForPermission.Scan")
+		}
+		all = append(all, &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, xerrors.DatabaseError(err, "users.GetAllForPermission.Err")
+	}
+
+	return all, nil
+}
+
 // Gets the user from one of their tokens
-func (m Users) GetByToken(plaintext string) (*User, *xerrors.AppError) {
+func (m Users) GetByToken(ctx context.Context, plaintext string) (*User, *xerrors.AppError) {
 	query := `
 		SELECT users.id, users.email, users.password, users.activated, users.created_at, users.version
 		FROM users
@@ -110,8 +252,8 @@ func (m Users) GetByToken(plaintext string) (*User, *xerrors.AppError) {
 	args := []any{tokens.Hash(plaintext), time.Now()}
 	dest := []any{&user.ID, &user.Email, &user.Password, &user.Activated, &user.CreatedAt, &user.Version}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	ctx, done := core.Start(ctx, "users.GetByToken")
+	defer done()
 
 	if err := m.DB.QueryRowContext(ctx, query, args...).Scan(dest...); err != nil {
 		return nil, xerrors.DatabaseError(err, "users.GetByToken")
@@ -122,14 +264,16 @@ func (m Users) GetByToken(plaintext string) (*User, *xerrors.AppError) {
 
 // Updates a user using optimistic locking.
 //
-// Be careful not to provide a user with default values for the set fields.
+// Be careful not to provide a user with default values for the set fields,
+// and prefer rest.ReadPatch for partial updates from clients. Returns
+// xerrors.ErrEditConflict if the user's version changed since it was read.
 //
 // Sets:
 //
 // email
 // password
 // activated
-func (m Users) Update(user *User) *xerrors.AppError {
+func (m Users) Update(ctx context.Context, user *User) *xerrors.AppError {
 	query := `
 		UPDATE users
 		SET email = $1, password = $2, activated = $3, version = version + 1
@@ -139,10 +283,13 @@ func (m Users) Update(user *User) *xerrors.AppError {
 	args := []any{user.Email, user.Password, user.Activated, user.ID, user.Version}
 	dest := []any{&user.Version}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	ctx, done := core.Start(ctx, "users.Update")
+	defer done()
 
 	err := m.DB.QueryRowContext(ctx, query, args...).Scan(dest...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return xerrors.ClientEditConflict("users.Update")
+	}
 	if err != nil {
 		return xerrors.DatabaseError(err, "users.Update")
 	}
@@ -151,9 +298,9 @@ func (m Users) Update(user *User) *xerrors.AppError {
 }
 
 // Deletes a user
-func (m Users) Delete(user *User) (int64, *xerrors.AppError) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+func (m Users) Delete(ctx context.Context, user *User) (int64, *xerrors.AppError) {
+	ctx, done := core.Start(ctx, "users.Delete")
+	defer done()
 
 	result, err := m.DB.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, user.ID)
 	if err != nil {
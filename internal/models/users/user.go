@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"go-rest-starter.jtbergman.me/internal/listing"
 	"go-rest-starter.jtbergman.me/internal/validator"
 	"go-rest-starter.jtbergman.me/internal/xerrors"
 	"golang.org/x/crypto/bcrypt"
@@ -45,6 +46,11 @@ func new(email, plaintext string) (*User, *xerrors.AppError) {
 	return user, nil
 }
 
+// Adds validation errors for an invalid user, e.g. after a patch
+func (u *User) Validate(v *validator.Validator) {
+	v.IsEmail(u.Email, "email", "is invalid")
+}
+
 // Set a user's password
 func (u *User) SetPassword(plaintext string) *xerrors.AppError {
 	hash, err := hash(plaintext, "models.SetPassword")
@@ -104,3 +110,38 @@ func hash(plaintext string, op string) (string, *xerrors.AppError) {
 
 	return string(hash), nil
 }
+
+// ============================================================================
+// Listing
+// ============================================================================
+
+// The fields admins may filter and sort users by
+var ListSpec = listing.Spec{
+	Fields: map[string]listing.Field{
+		"id": {
+			Column:    "id",
+			Type:      listing.Int,
+			Operators: []listing.Operator{listing.Eq, listing.Gt, listing.Gte, listing.Lt, listing.Lte},
+			Sortable:  true,
+		},
+		"email": {
+			Column:    "email",
+			Type:      listing.String,
+			Operators: []listing.Operator{listing.Eq, listing.Contains},
+			Sortable:  true,
+		},
+		"activated": {
+			Column:    "activated",
+			Type:      listing.Bool,
+			Operators: []listing.Operator{listing.Eq},
+		},
+		"created_at": {
+			Column:    "created_at",
+			Type:      listing.Time,
+			Operators: []listing.Operator{listing.Gt, listing.Gte, listing.Lt, listing.Lte},
+			Sortable:  true,
+		},
+	},
+	DefaultSort: "-created_at",
+	Tiebreaker:  "id",
+}
@@ -0,0 +1,102 @@
+package pagination
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/validator"
+)
+
+func TestCursors(t *testing.T) {
+	cursors := NewCursors(strings.Repeat("s", 32))
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)
+
+	token, err := cursors.Encode(DirectionPrev, createdAt, int64(42))
+	assert.Check(t, err == nil)
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		cursor, err := cursors.Decode(token)
+		assert.Check(t, err == nil)
+		assert.Equal(t, cursor.Direction, DirectionPrev)
+
+		var gotCreatedAt time.Time
+		var gotID int64
+		assert.Check(t, cursor.Scan(&gotCreatedAt, &gotID) == nil)
+		assert.True(t, gotCreatedAt.Equal(createdAt))
+		assert.Equal(t, gotID, 42)
+	})
+
+	t.Run("WrongValues", func(t *testing.T) {
+		cursor, _ := cursors.Decode(token)
+		var id int64
+		assert.Check(t, errors.Is(cursor.Scan(&id), ErrInvalidCursor))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		encoded, signature, _ := strings.Cut(token, ".")
+		forged := strings.Replace(encoded, encoded[:4], "AAAA", 1)
+
+		for _, invalid := range []string{"", "garbage", encoded, forged + "." + signature, token + "x"} {
+			_, err := cursors.Decode(invalid)
+			assert.Check(t, errors.Is(err, ErrInvalidCursor))
+		}
+
+		// Cursors signed with another secret are rejected
+		_, err := NewCursors("").Decode(token)
+		assert.Check(t, errors.Is(err, ErrInvalidCursor))
+	})
+}
+
+func TestParse(t *testing.T) {
+	cursors := NewCursors("")
+	token, _ := cursors.Encode(DirectionNext, int64(1))
+
+	t.Run("Defaults", func(t *testing.T) {
+		v := validator.New()
+		params := Parse(url.Values{}, cursors, v)
+		assert.Check(t, len(v.Errors) == 0)
+		assert.Equal(t, params.Page, 1)
+		assert.Equal(t, params.PageSize, DefaultPageSize)
+		assert.Check(t, params.Cursor == nil)
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		v := validator.New()
+		params := Parse(url.Values{"cursor": {token}, "page_size": {"5"}}, cursors, v)
+		assert.Check(t, len(v.Errors) == 0)
+		assert.Equal(t, params.PageSize, 5)
+		assert.Equal(t, params.Limit(), 6)
+		assert.Equal(t, params.Cursor.Direction, DirectionNext)
+	})
+
+	tests := []struct {
+		name  string
+		qs    url.Values
+		key   string
+		error string
+	}{
+		{name: "Page", qs: url.Values{"page": {"0"}}, key: "page", error: "must be greater than zero"},
+		{name: "PageSize", qs: url.Values{"page_size": {"101"}}, key: "page_size", error: "must be a maximum of 100"},
+		{name: "NotInteger", qs: url.Values{"page_size": {"ten"}}, key: "page_size", error: "must be an integer value"},
+		{name: "InvalidCursor", qs: url.Values{"cursor": {"garbage"}}, key: "cursor", error: "is invalid"},
+		{name: "CursorAndPage", qs: url.Values{"cursor": {token}, "page": {"2"}}, key: "page", error: "cannot be used with cursor"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := validator.New()
+			Parse(test.qs, cursors, v)
+			assert.Equal(t, v.Errors[test.key], test.error)
+		})
+	}
+
+	t.Run("Unsupported", func(t *testing.T) {
+		v := validator.New()
+		Parse(url.Values{"cursor": {token}}, nil, v)
+		assert.Equal(t, v.Errors["cursor"], "is not supported")
+	})
+}
@@ -0,0 +1,76 @@
+package pagination
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// Keyset
+// ============================================================================
+
+// The sort key for keyset pagination, e.g. created_at DESC, id DESC
+//
+// Every column is sorted in the same direction so positions can be compared
+// as a row, and the last column must be unique (usually id) so the order is
+// total. Column names are trusted and must never come from the client.
+type Keyset struct {
+	Columns    []string
+	Descending bool
+}
+
+// Returns the condition selecting rows past the cursor, e.g.
+// "(created_at, id) < ($2, $3)", with placeholders numbered from start
+//
+// The cursor's values are scanned into dest, which are returned as the
+// arguments for the placeholders. Without a cursor the condition is "TRUE"
+// and there are no arguments.
+func (k Keyset) Where(cursor *Cursor, start int, dest ...any) (string, []any, error) {
+	if cursor == nil {
+		return "TRUE", nil, nil
+	}
+
+	if len(dest) != len(k.Columns) {
+		return "", nil, fmt.Errorf("pagination: keyset has %d columns, got %d values", len(k.Columns), len(dest))
+	}
+
+	if err := cursor.Scan(dest...); err != nil {
+		return "", nil, err
+	}
+
+	placeholders := make([]string, len(dest))
+	for i := range dest {
+		placeholders[i] = fmt.Sprintf("$%d", start+i)
+	}
+
+	// Pages after the cursor continue in sort order, and pages before it
+	// move against it
+	operator := ">"
+	if k.Descending != (cursor.Direction == DirectionPrev) {
+		operator = "<"
+	}
+
+	where := fmt.Sprintf("(%s) %s (%s)", strings.Join(k.Columns, ", "), operator, strings.Join(placeholders, ", "))
+	return where, dest, nil
+}
+
+// Returns the ORDER BY list for the page, which is reversed for pages before
+// the cursor so the LIMIT keeps the rows closest to it
+func (k Keyset) OrderBy(cursor *Cursor) string {
+	descending := k.Descending
+	if cursor != nil && cursor.Direction == DirectionPrev {
+		descending = !descending
+	}
+
+	direction := "ASC"
+	if descending {
+		direction = "DESC"
+	}
+
+	columns := make([]string, len(k.Columns))
+	for i, column := range k.Columns {
+		columns[i] = column + " " + direction
+	}
+
+	return strings.Join(columns, ", ")
+}
@@ -0,0 +1,66 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+)
+
+func TestKeyset(t *testing.T) {
+	cursors := NewCursors("")
+	keyset := Keyset{Columns: []string{"created_at", "id"}, Descending: true}
+
+	t.Run("FirstPage", func(t *testing.T) {
+		where, args, err := keyset.Where(nil, 2)
+		assert.Check(t, err == nil)
+		assert.Equal(t, where, "TRUE")
+		assert.Check(t, len(args) == 0)
+		assert.Equal(t, keyset.OrderBy(nil), "created_at DESC, id DESC")
+	})
+
+	tests := []struct {
+		name       string
+		descending bool
+		direction  Direction
+		where      string
+		orderBy    string
+	}{
+		{"Descending/Next", true, DirectionNext, "(created_at, id) < ($2, $3)", "created_at DESC, id DESC"},
+		{"Descending/Prev", true, DirectionPrev, "(created_at, id) > ($2, $3)", "created_at ASC, id ASC"},
+		{"Ascending/Next", false, DirectionNext, "(created_at, id) > ($2, $3)", "created_at ASC, id ASC"},
+		{"Ascending/Prev", false, DirectionPrev, "(created_at, id) < ($2, $3)", "created_at DESC, id DESC"},
+	}
+
+	createdAt := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, _ := cursors.Encode(test.direction, createdAt, int64(7))
+			cursor, _ := cursors.Decode(token)
+
+			keyset := Keyset{Columns: keyset.Columns, Descending: test.descending}
+
+			var gotCreatedAt time.Time
+			var gotID int64
+			where, args, err := keyset.Where(cursor, 2, &gotCreatedAt, &gotID)
+			assert.Check(t, err == nil)
+			assert.Equal(t, where, test.where)
+			assert.Equal(t, keyset.OrderBy(cursor), test.orderBy)
+
+			// The arguments point at the scanned values
+			assert.Check(t, len(args) == 2)
+			assert.True(t, gotCreatedAt.Equal(createdAt))
+			assert.Equal(t, *args[1].(*int64), 7)
+		})
+	}
+
+	t.Run("ColumnMismatch", func(t *testing.T) {
+		token, _ := cursors.Encode(DirectionNext, int64(7))
+		cursor, _ := cursors.Decode(token)
+
+		var id int64
+		_, _, err := keyset.Where(cursor, 1, &id)
+		assert.Check(t, err != nil)
+	})
+}
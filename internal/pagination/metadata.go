@@ -0,0 +1,137 @@
+package pagination
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// Metadata
+// ============================================================================
+
+// Pagination details sent with a page under the "metadata" key
+//
+// Offset pages include the current and last page, and cursor pages include
+// the cursors for the neighbouring pages if they exist.
+type Metadata struct {
+	CurrentPage  int    `json:"current_page,omitempty"`
+	PageSize     int    `json:"page_size"`
+	LastPage     int    `json:"last_page,omitempty"`
+	TotalRecords int    `json:"total_records"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+	PrevCursor   string `json:"prev_cursor,omitempty"`
+}
+
+// Calculates the metadata for an offset page
+func NewMetadata(total int, params Params) Metadata {
+	if total == 0 {
+		return Metadata{CurrentPage: params.Page, PageSize: params.PageSize}
+	}
+
+	return Metadata{
+		CurrentPage:  params.Page,
+		PageSize:     params.PageSize,
+		LastPage:     (total + params.PageSize - 1) / params.PageSize,
+		TotalRecords: total,
+	}
+}
+
+// Trims the rows of a keyset page fetched with Params.Limit and creates the
+// cursors for the neighbouring pages from the key of the first and last row
+//
+// Rows must be in the order given by Keyset.OrderBy, and key must return the
+// values of the Keyset columns. Total is the number of rows across all pages.
+func Paginate[T any](cursors *Cursors, params Params, rows []T, total int, key func(T) []any) ([]T, Metadata, error) {
+	more := len(rows) > params.PageSize
+	if more {
+		rows = rows[:params.PageSize]
+	}
+
+	// Pages before the cursor are fetched in reverse
+	backward := params.Cursor != nil && params.Cursor.Direction == DirectionPrev
+	if backward {
+		slices.Reverse(rows)
+	}
+
+	metadata := Metadata{PageSize: params.PageSize, TotalRecords: total}
+	if len(rows) == 0 {
+		return rows, metadata, nil
+	}
+
+	// There is a next page if more rows were found going forward or if the
+	// client paged back, and a previous page if more rows were found going
+	// back or if the client paged forward with a cursor
+	hasNext := more || backward
+	hasPrev := (more && backward) || (params.Cursor != nil && !backward)
+
+	var err error
+	if hasNext {
+		if metadata.NextCursor, err = cursors.Encode(DirectionNext, key(rows[len(rows)-1])...); err != nil {
+			return nil, Metadata{}, err
+		}
+	}
+	if hasPrev {
+		if metadata.PrevCursor, err = cursors.Encode(DirectionPrev, key(rows[0])...); err != nil {
+			return nil, Metadata{}, err
+		}
+	}
+
+	return rows, metadata, nil
+}
+
+// ============================================================================
+// Link
+// ============================================================================
+
+// Sets the RFC 8288 Link header with the first, prev, next, and last pages
+// that exist, keeping the request's other query parameters
+func SetLinks(w http.ResponseWriter, r *http.Request, metadata Metadata) {
+	links := []string{}
+	link := func(rel, key, value string) {
+		qs := r.URL.Query()
+		qs.Del(PageParam)
+		qs.Del(CursorParam)
+		if key != "" {
+			qs.Set(key, value)
+		}
+
+		target := r.URL.Path
+		if encoded := qs.Encode(); encoded != "" {
+			target += "?" + encoded
+		}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, target, rel))
+	}
+
+	switch {
+	// Cursor pages
+	case metadata.CurrentPage == 0:
+		if metadata.PrevCursor != "" {
+			link("first", "", "")
+			link("prev", CursorParam, metadata.PrevCursor)
+		}
+		if metadata.NextCursor != "" {
+			link("next", CursorParam, metadata.NextCursor)
+		}
+
+	// Offset pages
+	default:
+		page := metadata.CurrentPage
+		link("first", PageParam, "1")
+		if page > 1 && page <= metadata.LastPage+1 {
+			link("prev", PageParam, strconv.Itoa(page-1))
+		}
+		if page < metadata.LastPage {
+			link("next", PageParam, strconv.Itoa(page+1))
+		}
+		if metadata.LastPage > 0 {
+			link("last", PageParam, strconv.Itoa(metadata.LastPage))
+		}
+	}
+
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
@@ -0,0 +1,121 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+)
+
+func TestPaginate(t *testing.T) {
+	cursors := NewCursors("")
+	key := func(id int64) []any { return []any{id} }
+
+	// Decodes the single id held by a cursor
+	id := func(t *testing.T, token string) int64 {
+		cursor, err := cursors.Decode(token)
+		assert.Check(t, err == nil)
+		var id int64
+		assert.Check(t, cursor.Scan(&id) == nil)
+		return id
+	}
+
+	params := func(direction Direction, id int64) Params {
+		if direction == "" {
+			return Params{Page: 1, PageSize: 2}
+		}
+		token, _ := cursors.Encode(direction, id)
+		cursor, _ := cursors.Decode(token)
+		return Params{Page: 1, PageSize: 2, Cursor: cursor}
+	}
+
+	t.Run("FirstPage", func(t *testing.T) {
+		rows, metadata, err := Paginate(cursors, params("", 0), []int64{1, 2, 3}, 5, key)
+		assert.Check(t, err == nil)
+		assert.Check(t, len(rows) == 2)
+		assert.Equal(t, metadata.TotalRecords, 5)
+		assert.Equal(t, metadata.PrevCursor, "")
+		assert.Equal(t, id(t, metadata.NextCursor), 2)
+	})
+
+	t.Run("LastPage", func(t *testing.T) {
+		rows, metadata, _ := Paginate(cursors, params(DirectionNext, 4), []int64{5}, 5, key)
+		assert.Check(t, len(rows) == 1)
+		assert.Equal(t, metadata.NextCursor, "")
+		assert.Equal(t, id(t, metadata.PrevCursor), 5)
+	})
+
+	t.Run("Backward", func(t *testing.T) {
+		// Rows before 5 are fetched in reverse order
+		rows, metadata, _ := Paginate(cursors, params(DirectionPrev, 5), []int64{4, 3, 2}, 5, key)
+		assert.Equal(t, rows[0], 3)
+		assert.Equal(t, rows[1], 4)
+		assert.Equal(t, id(t, metadata.PrevCursor), 3)
+		assert.Equal(t, id(t, metadata.NextCursor), 4)
+	})
+
+	t.Run("BackwardToStart", func(t *testing.T) {
+		rows, metadata, _ := Paginate(cursors, params(DirectionPrev, 3), []int64{2, 1}, 5, key)
+		assert.Equal(t, rows[0], 1)
+		assert.Equal(t, metadata.PrevCursor, "")
+		assert.Equal(t, id(t, metadata.NextCursor), 2)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		rows, metadata, _ := Paginate(cursors, params("", 0), []int64{}, 0, key)
+		assert.Check(t, len(rows) == 0)
+		assert.Equal(t, metadata.NextCursor, "")
+		assert.Equal(t, metadata.PrevCursor, "")
+	})
+}
+
+func TestSetLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		metadata Metadata
+		link     string
+	}{
+		{
+			name:     "Offset/Middle",
+			target:   "/v1/items?page=2&page_size=10&q=a",
+			metadata: NewMetadata(30, Params{Page: 2, PageSize: 10}),
+			link: `</v1/items?page=1&page_size=10&q=a>; rel="first", ` +
+				`</v1/items?page=1&page_size=10&q=a>; rel="prev", ` +
+				`</v1/items?page=3&page_size=10&q=a>; rel="next", ` +
+				`</v1/items?page=3&page_size=10&q=a>; rel="last"`,
+		},
+		{
+			name:     "Offset/Empty",
+			target:   "/v1/items",
+			metadata: NewMetadata(0, Params{Page: 1, PageSize: 10}),
+			link:     `</v1/items?page=1>; rel="first"`,
+		},
+		{
+			name:     "Cursor/First",
+			target:   "/v1/items?page_size=10",
+			metadata: Metadata{PageSize: 10, NextCursor: "n"},
+			link:     `</v1/items?cursor=n&page_size=10>; rel="next"`,
+		},
+		{
+			name:     "Cursor/Middle",
+			target:   "/v1/items?cursor=c",
+			metadata: Metadata{PageSize: 10, NextCursor: "n", PrevCursor: "p"},
+			link:     `</v1/items>; rel="first", </v1/items?cursor=p>; rel="prev", </v1/items?cursor=n>; rel="next"`,
+		},
+		{
+			name:     "Cursor/Only",
+			target:   "/v1/items",
+			metadata: Metadata{PageSize: 10},
+			link:     "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			SetLinks(rr, httptest.NewRequest("GET", test.target, nil), test.metadata)
+			assert.Equal(t, rr.Header().Get("Link"), test.link)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package pagination
+
+import (
+	"net/url"
+
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/validator"
+)
+
+// ============================================================================
+// Constants
+// ============================================================================
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+	MaxPage         = 10_000_000
+)
+
+// The query string parameters read by Parse
+const (
+	PageParam     = "page"
+	PageSizeParam = "page_size"
+	CursorParam   = "cursor"
+)
+
+// ============================================================================
+// Params
+// ============================================================================
+
+// The page requested by the client, either a page number or a cursor
+type Params struct {
+	Page     int
+	PageSize int
+	Cursor   *Cursor
+}
+
+// Reads page, page_size, and cursor from the query string, adding errors to
+// the validator. Endpoints without cursors pass nil Cursors.
+func Parse(qs url.Values, cursors *Cursors, v *validator.Validator) Params {
+	params := Params{
+		Page:     rest.ReadInt(qs, PageParam, 1, v),
+		PageSize: rest.ReadInt(qs, PageSizeParam, DefaultPageSize, v),
+	}
+
+	if token := qs.Get(CursorParam); token != "" {
+		switch {
+		case cursors == nil:
+			v.AddError(CursorParam, "is not supported")
+
+		case qs.Has(PageParam):
+			v.AddError(PageParam, "cannot be used with cursor")
+
+		default:
+			cursor, err := cursors.Decode(token)
+			if err != nil {
+				v.AddError(CursorParam, "is invalid")
+			}
+			params.Cursor = cursor
+		}
+	}
+
+	params.Validate(v)
+	return params
+}
+
+// Adds validation errors for an invalid page or page size
+func (p Params) Validate(v *validator.Validator) {
+	v.Check(p.Page > 0, PageParam, "must be greater than zero")
+	v.Check(p.Page <= MaxPage, PageParam, "must be a maximum of 10 million")
+	v.Check(p.PageSize > 0, PageSizeParam, "must be greater than zero")
+	v.Check(p.PageSize <= MaxPageSize, PageSizeParam, "must be a maximum of 100")
+}
+
+// The number of rows to skip for offset pagination
+func (p Params) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// The number of rows to fetch for keyset pagination, which is one more than
+// the page size so Paginate can tell if there is another page
+func (p Params) Limit() int {
+	return p.PageSize + 1
+}
@@ -0,0 +1,203 @@
+// pubsub delivers events published by handlers to each user's open event
+// streams
+//
+// Events are held in memory, so a Hub only reaches streams served by the same
+// process. Recent events are kept so clients that reconnect with
+// Last-Event-ID receive what they missed.
+package pubsub
+
+import (
+	"strconv"
+	"sync"
+
+	"go-rest-starter.jtbergman.me/internal/rest"
+)
+
+// ============================================================================
+// Constants
+// ============================================================================
+
+// The events a subscription may have waiting before it is dropped
+const subscriptionBuffer = 32
+
+// ============================================================================
+// Hub
+// ============================================================================
+
+// Fans events out to the subscriptions of each user
+type Hub struct {
+	mu       sync.Mutex
+	closed   bool
+	sequence uint64
+	history  []published
+	limit    int
+	users    map[int64]map[*Subscription]struct{}
+}
+
+// An event kept for replay
+type published struct {
+	sequence uint64
+	userID   int64
+	event    rest.Event
+}
+
+// Creates a Hub that keeps the last history events, across all users, for
+// clients resuming with Last-Event-ID
+func NewHub(history int) *Hub {
+	return &Hub{
+		limit: history,
+		users: map[int64]map[*Subscription]struct{}{},
+	}
+}
+
+// Publishes an event to every subscription of the user and returns its ID
+//
+// IDs increase across the Hub. Subscriptions too slow to keep up are closed,
+// so their clients reconnect and resume from the history. Events published
+// after Close are dropped.
+func (hub *Hub) Publish(userID int64, name string, data any) string {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.closed {
+		return ""
+	}
+
+	hub.sequence++
+	event := rest.Event{ID: strconv.FormatUint(hub.sequence, 10), Name: name, Data: data}
+
+	if hub.limit > 0 {
+		if len(hub.history) == hub.limit {
+			hub.history = append(hub.history[:0], hub.history[1:]...)
+		}
+		hub.history = append(hub.history, published{sequence: hub.sequence, userID: userID, event: event})
+	}
+
+	for subscription := range hub.users[userID] {
+		select {
+		case subscription.events <- event:
+		default:
+			hub.remove(subscription)
+		}
+	}
+
+	return event.ID
+}
+
+// Subscribes to the user's events
+//
+// If lastEventID is the ID of an event still in the history, the user's
+// later events are delivered first. Subscriptions to a closed Hub are closed
+// immediately.
+func (hub *Hub) Subscribe(userID int64, lastEventID string) *Subscription {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	// Replay missed events
+	var missed []rest.Event
+	if last, err := strconv.ParseUint(lastEventID, 10, 64); err == nil {
+		for _, p := range hub.history {
+			if p.userID == userID && p.sequence > last {
+				missed = append(missed, p.event)
+			}
+		}
+	}
+
+	subscription := &Subscription{
+		hub:    hub,
+		userID: userID,
+		events: make(chan rest.Event, subscriptionBuffer+len(missed)),
+	}
+	for _, event := range missed {
+		subscription.events <- event
+	}
+
+	if hub.closed {
+		close(subscription.events)
+		return subscription
+	}
+
+	if hub.users[userID] == nil {
+		hub.users[userID] = map[*Subscription]struct{}{}
+	}
+	hub.users[userID][subscription] = struct{}{}
+
+	return subscription
+}
+
+// Returns the number of open subscriptions
+func (hub *Hub) Subscribers() int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	count := 0
+	for _, subscriptions := range hub.users {
+		count += len(subscriptions)
+	}
+	return count
+}
+
+// Closes every subscription, ending their streams, and stops accepting new
+// ones. Call it when the server shuts down so open streams do not hold
+// shutdown up.
+func (hub *Hub) Close() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.closed = true
+	for _, subscriptions := range hub.users {
+		for subscription := range subscriptions {
+			hub.remove(subscription)
+		}
+	}
+}
+
+// Closes every subscription of the user, ending their streams. Call it when
+// the user's tokens stop being valid, e.g. on logout or account deletion.
+// Clients with another valid token reconnect.
+func (hub *Hub) CloseUser(userID int64) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for subscription := range hub.users[userID] {
+		hub.remove(subscription)
+	}
+}
+
+// Removes a subscription and closes its channel. The caller must hold mu.
+func (hub *Hub) remove(subscription *Subscription) {
+	subscriptions, ok := hub.users[subscription.userID]
+	if _, subscribed := subscriptions[subscription]; !ok || !subscribed {
+		return
+	}
+
+	delete(subscriptions, subscription)
+	if len(subscriptions) == 0 {
+		delete(hub.users, subscription.userID)
+	}
+	close(subscription.events)
+}
+
+// ============================================================================
+// Subscription
+// ============================================================================
+
+// A user's subscription to a Hub
+type Subscription struct {
+	hub    *Hub
+	userID int64
+	events chan rest.Event
+}
+
+// Returns the channel of events, which is closed when the subscription ends
+func (subscription *Subscription) Events() <-chan rest.Event {
+	return subscription.events
+}
+
+// Ends the subscription. It is safe to call more than once.
+func (subscription *Subscription) Close() {
+	subscription.hub.mu.Lock()
+	defer subscription.hub.mu.Unlock()
+
+	subscription.hub.remove(subscription)
+}
@@ -0,0 +1,128 @@
+package pubsub
+
+import (
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/rest"
+)
+
+// Returns the events waiting on a subscription without blocking
+func pending(subscription *Subscription) []rest.Event {
+	var events []rest.Event
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+// Returns true if the subscription's channel is closed
+func closed(subscription *Subscription) bool {
+	pending(subscription)
+	select {
+	case _, ok := <-subscription.Events():
+		return !ok
+	default:
+		return false
+	}
+}
+
+func TestHub(t *testing.T) {
+	t.Run("Publish", func(t *testing.T) {
+		hub := NewHub(10)
+		a := hub.Subscribe(1, "")
+		b := hub.Subscribe(1, "")
+		other := hub.Subscribe(2, "")
+
+		id := hub.Publish(1, "user.updated", "data")
+		assert.Equal(t, id, "1")
+
+		for _, subscription := range []*Subscription{a, b} {
+			events := pending(subscription)
+			assert.Equal(t, len(events), 1)
+			assert.Equal(t, events[0].ID, "1")
+			assert.Equal(t, events[0].Name, "user.updated")
+			assert.Equal(t, events[0].Data, any("data"))
+		}
+		assert.Equal(t, len(pending(other)), 0)
+
+		// IDs increase across users
+		assert.Equal(t, hub.Publish(2, "user.updated", nil), "2")
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		hub := NewHub(3)
+		hub.Publish(1, "a", nil)
+		hub.Publish(2, "b", nil)
+		hub.Publish(1, "c", nil)
+		hub.Publish(1, "d", nil)
+
+		// Only the user's events after the last ID
+		events := pending(hub.Subscribe(1, "1"))
+		assert.Equal(t, len(events), 2)
+		assert.Equal(t, events[0].ID, "3")
+		assert.Equal(t, events[1].ID, "4")
+
+		// The first event fell out of the history
+		assert.Equal(t, len(pending(hub.Subscribe(1, "0"))), 2)
+
+		// New streams and unknown IDs replay nothing
+		assert.Equal(t, len(pending(hub.Subscribe(1, ""))), 0)
+		assert.Equal(t, len(pending(hub.Subscribe(1, "abc"))), 0)
+	})
+
+	t.Run("SlowSubscriber", func(t *testing.T) {
+		hub := NewHub(0)
+		slow := hub.Subscribe(1, "")
+
+		for range subscriptionBuffer + 1 {
+			hub.Publish(1, "event", nil)
+		}
+
+		assert.Equal(t, len(pending(slow)), subscriptionBuffer)
+		assert.True(t, closed(slow))
+		assert.Equal(t, hub.Subscribers(), 0)
+	})
+
+	t.Run("CloseUser", func(t *testing.T) {
+		hub := NewHub(10)
+		a := hub.Subscribe(1, "")
+		b := hub.Subscribe(1, "")
+		other := hub.Subscribe(2, "")
+
+		hub.CloseUser(1)
+		assert.True(t, closed(a))
+		assert.True(t, closed(b))
+		assert.False(t, closed(other))
+		assert.Equal(t, hub.Subscribers(), 1)
+
+		// The user can subscribe again
+		assert.False(t, closed(hub.Subscribe(1, "")))
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		hub := NewHub(10)
+		subscription := hub.Subscribe(1, "")
+		assert.Equal(t, hub.Subscribers(), 1)
+
+		subscription.Close()
+		subscription.Close()
+		assert.True(t, closed(subscription))
+		assert.Equal(t, hub.Subscribers(), 0)
+
+		// Closing the hub ends every subscription and refuses new ones
+		open := hub.Subscribe(1, "")
+		hub.Close()
+		assert.True(t, closed(open))
+		assert.True(t, closed(hub.Subscribe(1, "")))
+		assert.Equal(t, hub.Publish(1, "event", nil), "")
+		assert.Equal(t, hub.Subscribers(), 0)
+	})
+}
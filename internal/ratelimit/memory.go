@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Type
+// ============================================================================
+
+// A token bucket that remembers when it was last refilled
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time
+}
+
+// Stores token buckets in memory for a single instance
+//
+// Buckets are evicted in the background once they have refilled, since a
+// full bucket behaves exactly like a missing one.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+	done    chan struct{}
+	once    sync.Once
+}
+
+// Creates a MemoryStore that evicts full buckets every interval
+func NewMemoryStore(interval time.Duration) *MemoryStore {
+	store := &MemoryStore{
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+		done:    make(chan struct{}),
+	}
+
+	go store.run(interval)
+	return store
+}
+
+// ============================================================================
+// Methods
+// ============================================================================
+
+// Takes a token from the bucket for the key
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	if limit.IsZero() {
+		return Result{Allowed: true}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	burst := float64(limit.Burst)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		s.buckets[key] = b
+	}
+
+	// Refill for the time elapsed since the last request
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(burst, b.tokens+elapsed*limit.rate())
+	b.last = now
+
+	result := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = limit.refill(1 - b.tokens)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = limit.refill(burst - b.tokens)
+	b.full = now.Add(result.Reset)
+
+	return result, nil
+}
+
+// Stops background eviction
+func (s *MemoryStore) Close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// Evicts full buckets every interval until closed
+func (s *MemoryStore) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.evict()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Removes buckets that have refilled completely
+func (s *MemoryStore) evict() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
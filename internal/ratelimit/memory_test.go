@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+)
+
+// Creates a store with a controllable clock
+func testStore(t *testing.T) (*MemoryStore, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore(time.Hour)
+	store.now = func() time.Time { return now }
+	t.Cleanup(store.Close)
+
+	return store, &now
+}
+
+func TestMemoryStore(t *testing.T) {
+	limit := Limit{Burst: 2, Period: 10 * time.Second}
+	ctx := context.Background()
+
+	t.Run("Burst", func(t *testing.T) {
+		store, _ := testStore(t)
+
+		first, _ := store.Take(ctx, "key", limit)
+		assert.True(t, first.Allowed)
+		assert.Equal(t, first.Limit, 2)
+		assert.Equal(t, first.Remaining, 1)
+		assert.Equal(t, first.Reset, 5*time.Second)
+
+		second, _ := store.Take(ctx, "key", limit)
+		assert.True(t, second.Allowed)
+		assert.Equal(t, second.Remaining, 0)
+		assert.Equal(t, second.Reset, 10*time.Second)
+
+		third, _ := store.Take(ctx, "key", limit)
+		assert.False(t, third.Allowed)
+		assert.Equal(t, third.RetryAfter, 5*time.Second)
+	})
+
+	t.Run("Refill", func(t *testing.T) {
+		store, now := testStore(t)
+		store.Take(ctx, "key", limit)
+		store.Take(ctx, "key", limit)
+
+		*now = now.Add(5 * time.Second)
+		result, _ := store.Take(ctx, "key", limit)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, result.Remaining, 0)
+	})
+
+	t.Run("Keys", func(t *testing.T) {
+		store, _ := testStore(t)
+		store.Take(ctx, "a", limit)
+		store.Take(ctx, "a", limit)
+
+		result, _ := store.Take(ctx, "b", limit)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		store, _ := testStore(t)
+		result, _ := store.Take(ctx, "key", Limit{})
+		assert.True(t, result.Allowed)
+		assert.Equal(t, len(store.buckets), 0)
+	})
+
+	t.Run("Evict", func(t *testing.T) {
+		store, now := testStore(t)
+		store.Take(ctx, "a", limit)
+		store.Take(ctx, "b", limit)
+		store.Take(ctx, "b", limit)
+
+		// a is full after 5s, b after 10s
+		*now = now.Add(5 * time.Second)
+		store.evict()
+		_, a := store.buckets["a"]
+		_, b := store.buckets["b"]
+		assert.False(t, a)
+		assert.True(t, b)
+	})
+}
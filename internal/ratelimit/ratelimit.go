@@ -0,0 +1,74 @@
+// ratelimit provides token bucket rate limiting behind a pluggable Store
+//
+// A Limit allows Burst requests at once and refills continuously so that
+// Burst requests are available again after Period. The in-memory store is
+// suitable for a single instance. Implement Store to share buckets between
+// instances, e.g. with Redis.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// ============================================================================
+// Limit
+// ============================================================================
+
+// Defines a token bucket that holds Burst tokens and refills over Period
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Returns the number of tokens added per second
+func (l Limit) rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+// Returns the time it takes to refill the given number of tokens
+func (l Limit) refill(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / l.rate() * float64(time.Second))
+}
+
+// Returns true if the limit never rejects requests
+func (l Limit) IsZero() bool {
+	return l.Burst <= 0 || l.Period <= 0
+}
+
+// ============================================================================
+// Result
+// ============================================================================
+
+// Describes the state of a bucket after taking a token
+type Result struct {
+	// Allowed is true if a token was available
+	Allowed bool
+
+	// Limit is the bucket capacity
+	Limit int
+
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+
+	// RetryAfter is the time until a token is available when not allowed
+	RetryAfter time.Duration
+}
+
+// ============================================================================
+// Store
+// ============================================================================
+
+// Defines a backend that holds token buckets by key
+//
+// Implementations must be safe for concurrent use. An error means the store
+// could not be reached, and callers decide whether to fail open or closed.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
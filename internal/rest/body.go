@@ -0,0 +1,296 @@
+package rest
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Write
+// ============================================================================
+
+// Writes data to the client, usually an Envelope or a typed response, in the
+// registered media type the Accept header prefers (JSON by default)
+//
+// Clients that accept none of the registered media types receive a 406. 200
+// responses get a weak ETag hashing the body unless the handler already set
+// one, e.g. with VersionETag, which is made specific to the media type.
+func (rest *Rest) Write(
+	w http.ResponseWriter,
+	r *http.Request,
+	op string,
+	status int,
+	data any,
+) {
+	mediaType, codec, err := rest.responseCodec(r, op)
+	if err != nil {
+		rest.Error(w, r, err)
+		return
+	}
+
+	rest.write(w, r, op, status, mediaType, codec, data)
+}
+
+// Encodes any value with the codec and writes it with the given content type
+func (rest *Rest) write(w http.ResponseWriter, r *http.Request, op string, status int, contentType string, codec Codec, data any) {
+	response, err := codec.Marshal(data)
+
+	// If an error occurs here, Write could cause infinite recursion
+	if err != nil {
+		wrappedError := fmt.Errorf("%w: %v", xerrors.ErrServerInternal, err)
+		serverError := xerrors.ServerError(op, wrappedError)
+		rest.Logger.ErrorContext(r.Context(), serverError.Error())
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "Internal server error"}`))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	if etag := w.Header().Get("ETag"); etag != "" {
+		w.Header().Set("ETag", representationETag(etag, contentType))
+	} else if status == http.StatusOK {
+		w.Header().Set("ETag", contentETag(response))
+	}
+	w.WriteHeader(status)
+	w.Write(response)
+}
+
+// ============================================================================
+// Read
+// ============================================================================
+
+// The largest request body read by default, after decompression
+const DefaultMaxBodySize int64 = 1_048_576
+
+// Configures how Read reads a single request body
+type ReadOption func(*readOptions)
+
+type readOptions struct {
+	maxBodySize        int64
+	allowUnknownFields bool
+}
+
+// Limits the request body to n bytes instead of DefaultMaxBodySize
+func MaxBodySize(n int64) ReadOption {
+	return func(opts *readOptions) {
+		opts.maxBodySize = n
+	}
+}
+
+// Ignores fields in the request body that dst does not declare, rather than
+// rejecting the request
+func AllowUnknownFields() ReadOption {
+	return func(opts *readOptions) {
+		opts.allowUnknownFields = true
+	}
+}
+
+// Reads the request body into the given destination or returns an error
+//
+// The body is decoded by the codec registered for its Content-Type and must be
+// no larger than DefaultMaxBodySize and contain only fields declared by dst
+// unless options say otherwise. Requests whose response the client could not
+// accept are rejected with a 406 before the handler does any work.
+//
+// Unsupported Content-Types are rejected with a 415. Bodies without a
+// Content-Type are the exception: they are read as JSON, so clients like curl
+// that omit the header keep working, and bodies that are not JSON get a 400
+// from the decoder instead.
+func (rest *Rest) Read(w http.ResponseWriter, r *http.Request, op string, dst any, opts ...ReadOption) *xerrors.AppError {
+	options := readOptions{maxBodySize: DefaultMaxBodySize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	codec, err := rest.requestCodec(r, op)
+	if err != nil {
+		return err
+	}
+	if _, _, err := rest.responseCodec(r, op); err != nil {
+		return err
+	}
+
+	body, err := readBody(w, r, op, options.maxBodySize)
+	if err != nil {
+		return err
+	}
+
+	return decode(codec.NewDecoder(body, options.allowUnknownFields), op, dst)
+}
+
+// Decodes a single value from the decoder into dst, converting decoding
+// errors to client errors
+func decode(dec Decoder, op string, dst any) *xerrors.AppError {
+	if err := dec.Decode(dst); err != nil {
+		var syntaxError *json.SyntaxError
+		var unmarshalTypeError *json.UnmarshalTypeError
+		var maxBytesError *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &maxBytesError):
+			return BodyTooLarge(maxBytesError.Limit, op, err)
+
+		case errors.As(err, &syntaxError):
+			return xerrors.ClientError(
+				http.StatusBadRequest,
+				fmt.Sprintf("Request body is malformed (at position %d)", syntaxError.Offset),
+				op,
+				fmt.Errorf("%w: %v", xerrors.ErrBadRequest, err),
+			)
+
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return xerrors.ClientError(
+				http.StatusBadRequest,
+				"Request body contains badly-formed JSON (at EOF)",
+				op,
+				fmt.Errorf("%w: %v", xerrors.ErrBadRequest, err),
+			)
+
+		case errors.As(err, &unmarshalTypeError):
+			return xerrors.ClientError(
+				http.StatusBadRequest,
+				fmt.Sprintf("Request body contains an invalid value for the %q field (at position %d)", unmarshalTypeError.Field, unmarshalTypeError.Offset),
+				op,
+				fmt.Errorf("%w: %v", xerrors.ErrBadRequest, err),
+			)
+
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+			return xerrors.ClientError(
+				http.StatusBadRequest,
+				fmt.Sprintf("Request body contains unknown field %s", fieldName),
+				op,
+				fmt.Errorf("%w: %v", xerrors.ErrBadRequest, err),
+			)
+
+		case errors.Is(err, ErrMalformedBody):
+			return xerrors.ClientError(
+				http.StatusBadRequest,
+				"Request body is malformed",
+				op,
+				fmt.Errorf("%w: %v", xerrors.ErrBadRequest, err),
+			)
+
+		case errors.Is(err, gzip.ErrChecksum), errors.Is(err, gzip.ErrHeader), errors.As(err, new(flate.CorruptInputError)):
+			return xerrors.ClientError(
+				http.StatusBadRequest,
+				"Request body is not valid gzip",
+				op,
+				fmt.Errorf("%w: %v", xerrors.ErrBadRequest, err),
+			)
+
+		case errors.Is(err, io.EOF):
+			return xerrors.ClientError(
+				http.StatusBadRequest,
+				"Request body cannot be empty",
+				op,
+				fmt.Errorf("%w: %v", xerrors.ErrBadRequest, err),
+			)
+
+		default:
+			return xerrors.ServerError(
+				op,
+				fmt.Errorf("%w: %v", xerrors.ErrServerInternal, err),
+			)
+		}
+	}
+
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return BodyTooLarge(maxBytesError.Limit, op, err)
+		}
+
+		return xerrors.ClientError(
+			http.StatusBadRequest,
+			"Request body must only contain a single value",
+			op,
+			fmt.Errorf("%w: %v", xerrors.ErrBadRequest, err),
+		)
+	}
+
+	return nil
+}
+
+// Creates the 413 error for a body larger than limit bytes
+func BodyTooLarge(limit int64, op string, err error) *xerrors.AppError {
+	return xerrors.ClientError(
+		http.StatusRequestEntityTooLarge,
+		fmt.Sprintf("Request body must not be larger than %s", formatBytes(limit)),
+		op,
+		fmt.Errorf("%w: %v", xerrors.ErrEntityTooLarge, err),
+	)
+}
+
+// Formats a byte count using the largest whole unit, e.g. 1MB or 1536B
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
+
+// Returns true for application/json and structured +json media types
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" ||
+		(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+// Returns the request body limited to limit bytes, decompressing it first if
+// it was sent with "Content-Encoding: gzip" so the limit applies to the body
+// itself
+func readBody(w http.ResponseWriter, r *http.Request, op string, limit int64) (io.Reader, *xerrors.AppError) {
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		return r.Body, nil
+
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(r.Body)
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil, xerrors.ClientError(
+				http.StatusBadRequest,
+				"Request body cannot be empty",
+				op,
+				fmt.Errorf("%w: %v", xerrors.ErrBadRequest, err),
+			)
+
+		case err != nil:
+			return nil, xerrors.ClientError(
+				http.StatusBadRequest,
+				"Request body is not valid gzip",
+				op,
+				fmt.Errorf("%w: %v", xerrors.ErrBadRequest, err),
+			)
+		}
+
+		r.Body = http.MaxBytesReader(w, gz, limit)
+		return r.Body, nil
+
+	default:
+		err := xerrors.ClientError(
+			http.StatusUnsupportedMediaType,
+			"Request body must be sent uncompressed or with gzip",
+			op,
+			xerrors.ErrUnsupportedMediaType,
+		)
+		err.Header = http.Header{"Accept-Encoding": {"gzip"}}
+		return nil, err
+	}
+}
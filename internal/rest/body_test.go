@@ -0,0 +1,166 @@
+package rest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+func TestReadEncoding(t *testing.T) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(`{"name": "gzip"}`))
+	gz.Close()
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		status   int
+	}{
+		{name: "Identity", body: []byte(`{"name": "gzip"}`)},
+		{name: "Gzip", encoding: "gzip", body: compressed.Bytes()},
+		{name: "InvalidGzip", encoding: "gzip", body: []byte(`{"name": "gzip"}`), status: http.StatusBadRequest},
+		{name: "Unsupported", encoding: "br", body: []byte(`{}`), status: http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", bytes.NewReader(test.body))
+			req.Header.Set("Content-Encoding", test.encoding)
+
+			var dst struct {
+				Name string `json:"name"`
+			}
+			err := rest.Read(httptest.NewRecorder(), req, "test", &dst)
+
+			if test.status == 0 {
+				assert.Check(t, err == nil)
+				assert.Equal(t, dst.Name, "gzip")
+				return
+			}
+			assert.Equal(t, err.StatusCode, test.status)
+		})
+	}
+
+	t.Run("DecompressedLimit", func(t *testing.T) {
+		var bomb bytes.Buffer
+		gz := gzip.NewWriter(&bomb)
+		gz.Write([]byte(`{"name": "` + strings.Repeat("a", 2_000_000) + `"}`))
+		gz.Close()
+
+		req := httptest.NewRequest("POST", "/", &bomb)
+		req.Header.Set("Content-Encoding", "gzip")
+
+		var dst struct {
+			Name string `json:"name"`
+		}
+		err := rest.Read(httptest.NewRecorder(), req, "test", &dst)
+		assert.Equal(t, err.StatusCode, http.StatusRequestEntityTooLarge)
+		assert.Equal(t, err.Data.(string), "Request body must not be larger than 1MB")
+	})
+}
+
+func TestReadContentType(t *testing.T) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		contentType string
+		status      int
+	}{
+		{contentType: ""},
+		{contentType: "application/json"},
+		{contentType: "Application/JSON; charset=UTF-8"},
+		{contentType: "application/merge-patch+json"},
+		{contentType: "text/plain", status: http.StatusUnsupportedMediaType},
+		{contentType: "application/x-www-form-urlencoded", status: http.StatusUnsupportedMediaType},
+		{contentType: "application/json; charset=latin1", status: http.StatusUnsupportedMediaType},
+		{contentType: "application/json; charset", status: http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.contentType, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(`{}`))
+			req.Header.Set("Content-Type", test.contentType)
+
+			var dst struct{}
+			err := rest.Read(httptest.NewRecorder(), req, "test", &dst)
+
+			if test.status == 0 {
+				assert.Check(t, err == nil)
+				return
+			}
+			assert.Equal(t, err.StatusCode, test.status)
+			assert.Equal(t, err.Code(), "unsupported_media_type")
+		})
+	}
+}
+
+func TestReadWithoutContentType(t *testing.T) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	t.Run("JSON", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"name": "test"}`))
+
+		var dst struct {
+			Name string `json:"name"`
+		}
+		err := rest.Read(httptest.NewRecorder(), req, "test", &dst)
+		assert.Check(t, err == nil)
+		assert.Equal(t, dst.Name, "test")
+	})
+
+	t.Run("NotJSON", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`name=test`))
+
+		var dst struct{}
+		err := rest.Read(httptest.NewRecorder(), req, "test", &dst)
+		assert.Check(t, err != nil)
+		assert.Equal(t, err.StatusCode, http.StatusBadRequest)
+		assert.Equal(t, err.Code(), "bad_request")
+	})
+}
+
+func TestReadOptions(t *testing.T) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	read := func(body string, opts ...ReadOption) *xerrors.AppError {
+		var dst struct {
+			Name string `json:"name"`
+		}
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		return rest.Read(httptest.NewRecorder(), req, "test", &dst, opts...)
+	}
+
+	t.Run("MaxBodySize", func(t *testing.T) {
+		err := read(`{"name": "`+strings.Repeat("a", 2048)+`"}`, MaxBodySize(1024))
+		assert.Equal(t, err.StatusCode, http.StatusRequestEntityTooLarge)
+		assert.Equal(t, err.Data.(string), "Request body must not be larger than 1KB")
+
+		assert.Check(t, read(`{"name": "a"}`, MaxBodySize(1024)) == nil)
+	})
+
+	t.Run("UnknownFields", func(t *testing.T) {
+		err := read(`{"name": "a", "extra": true}`)
+		assert.Equal(t, err.StatusCode, http.StatusBadRequest)
+		assert.Equal(t, err.Data.(string), `Request body contains unknown field "extra"`)
+
+		assert.Check(t, read(`{"name": "a", "extra": true}`, AllowUnknownFields()) == nil)
+	})
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, formatBytes(1_048_576), "1MB")
+	assert.Equal(t, formatBytes(16<<10), "16KB")
+	assert.Equal(t, formatBytes(1536), "1536B")
+}
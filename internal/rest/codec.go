@@ -0,0 +1,334 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Constants
+// ============================================================================
+
+// The media types registered by New, in order of preference
+const (
+	JSONContentType        = "application/json"
+	MessagePackContentType = "application/msgpack"
+	CBORContentType        = "application/cbor"
+)
+
+// ============================================================================
+// Codecs
+// ============================================================================
+
+// Encodes response bodies and decodes request bodies in one media type
+//
+// Codecs use the json struct tags of the values they encode, so a type only
+// declares its wire names once.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	NewDecoder(r io.Reader, allowUnknownFields bool) Decoder
+}
+
+// Reads values from a request body
+//
+// Decode returns io.EOF for an empty body, and errors wrapping
+// ErrMalformedBody for bodies that are not valid in the codec's format.
+type Decoder interface {
+	Decode(v any) error
+}
+
+// Wrapped by decoding errors that are the client's fault
+var ErrMalformedBody = errors.New("body is malformed")
+
+// Registers a codec for a media type, replacing any codec registered for it
+//
+// Codecs are preferred in the order they were first registered when a client
+// accepts several equally, so JSON stays the default.
+func (rest *Rest) Register(mediaType string, codec Codec) {
+	if rest.codecs == nil {
+		rest.codecs = map[string]Codec{}
+	}
+	if _, ok := rest.codecs[mediaType]; !ok {
+		rest.mediaTypes = append(rest.mediaTypes, mediaType)
+	}
+	rest.codecs[mediaType] = codec
+}
+
+// Returns the codec for a request body's Content-Type, or a 415
+//
+// Requests without a Content-Type are read as JSON rather than rejected, see
+// Read. Any +json media type such as application/merge-patch+json is also
+// read as JSON, and textual bodies must be UTF-8.
+func (rest *Rest) requestCodec(r *http.Request, op string) (Codec, *xerrors.AppError) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return rest.codecs[JSONContentType], nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		if isJSONMediaType(mediaType) {
+			mediaType = JSONContentType
+		}
+
+		charset, hasCharset := params["charset"]
+		if codec, ok := rest.codecs[mediaType]; ok && (!hasCharset || strings.EqualFold(charset, "utf-8")) {
+			return codec, nil
+		}
+	}
+
+	return nil, xerrors.ClientError(
+		http.StatusUnsupportedMediaType,
+		"Request body must be sent as "+rest.listMediaTypes(),
+		op,
+		fmt.Errorf("%w: %q", xerrors.ErrUnsupportedMediaType, contentType),
+	)
+}
+
+// Returns the media type and codec for the response, or a 406 if the client
+// does not accept any registered media type
+func (rest *Rest) responseCodec(r *http.Request, op string) (string, Codec, *xerrors.AppError) {
+	mediaType, ok := negotiate(strings.Join(r.Header.Values("Accept"), ","), rest.mediaTypes)
+	if !ok {
+		return "", nil, xerrors.ClientError(
+			http.StatusNotAcceptable,
+			"Responses can only be sent as "+rest.listMediaTypes(),
+			op,
+			fmt.Errorf("%w: %q", xerrors.ErrNotAcceptable, r.Header.Get("Accept")),
+		)
+	}
+
+	return mediaType, rest.codecs[mediaType], nil
+}
+
+// Lists the registered media types for error messages, e.g. "a, b, or c"
+func (rest *Rest) listMediaTypes() string {
+	switch n := len(rest.mediaTypes); n {
+	case 1:
+		return rest.mediaTypes[0]
+	case 2:
+		return rest.mediaTypes[0] + " or " + rest.mediaTypes[1]
+	default:
+		return strings.Join(rest.mediaTypes[:n-1], ", ") + ", or " + rest.mediaTypes[n-1]
+	}
+}
+
+// ============================================================================
+// Negotiation
+// ============================================================================
+
+// Returns the offered media type the Accept header prefers
+//
+// Each offer takes the q-value of the most specific range matching it, so
+// "application/cbor;q=0, */*" excludes only CBOR. Any +json type, such as
+// application/problem+json, also accepts application/json. The highest
+// q-value wins, ties go to the earliest offer, and a q-value of 0 means not
+// acceptable. An empty header accepts anything.
+func negotiate(accept string, offers []string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+
+	type mediaRange struct {
+		mediaType   string
+		q           float64
+		specificity int
+	}
+
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+
+		specificity := 3
+		switch {
+		case mediaType == "*/*":
+			specificity = 0
+		case strings.HasSuffix(mediaType, "/*"):
+			specificity = 1
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q, specificity: specificity})
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		offerType, _, _ := strings.Cut(offer, "/")
+
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			rangeSpecificity := r.specificity
+			matches := r.mediaType == offer || r.mediaType == "*/*" || r.mediaType == offerType+"/*"
+			if !matches && offer == JSONContentType && isJSONMediaType(r.mediaType) {
+				matches, rangeSpecificity = true, 2
+			}
+
+			if matches && rangeSpecificity > specificity {
+				q, specificity = r.q, rangeSpecificity
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+
+	return best, bestQ > 0
+}
+
+// ============================================================================
+// JSON
+// ============================================================================
+
+// Encodes bodies as JSON
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) NewDecoder(r io.Reader, allowUnknownFields bool) Decoder {
+	dec := json.NewDecoder(r)
+	if !allowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	return dec
+}
+
+// ============================================================================
+// MessagePack
+// ============================================================================
+
+// Encodes bodies as MessagePack, with times as timestamp extensions
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.SetSortMapKeys(true)
+	enc.UseCompactInts(true)
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) NewDecoder(r io.Reader, allowUnknownFields bool) Decoder {
+	body := &bodyReader{r: r}
+	dec := msgpack.NewDecoder(body)
+	dec.SetCustomStructTag("json")
+	dec.DisallowUnknownFields(!allowUnknownFields)
+	return &binaryDecoder{dec: dec, body: body}
+}
+
+// ============================================================================
+// CBOR
+// ============================================================================
+
+// Encodes bodies as CBOR (RFC 8949), with times as RFC 3339 strings tagged as
+// date/times
+type cborCodec struct {
+	enc     cbor.EncMode
+	strict  cbor.DecMode
+	lenient cbor.DecMode
+}
+
+// Creates the CBOR codec. Map keys are sorted so equal values encode to equal
+// bytes, and untyped maps decode with string keys like JSON.
+func newCBORCodec() cborCodec {
+	enc, err := cbor.EncOptions{Sort: cbor.SortCoreDeterministic, Time: cbor.TimeRFC3339Nano, TimeTag: cbor.EncTagRequired}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+
+	options := cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]any(nil))}
+	lenient, err := options.DecMode()
+	if err != nil {
+		panic(err)
+	}
+
+	options.ExtraReturnErrors = cbor.ExtraDecErrorUnknownField
+	strict, err := options.DecMode()
+	if err != nil {
+		panic(err)
+	}
+
+	return cborCodec{enc: enc, strict: strict, lenient: lenient}
+}
+
+func (c cborCodec) Marshal(v any) ([]byte, error) {
+	return c.enc.Marshal(v)
+}
+
+func (c cborCodec) NewDecoder(r io.Reader, allowUnknownFields bool) Decoder {
+	mode := c.strict
+	if allowUnknownFields {
+		mode = c.lenient
+	}
+
+	body := &bodyReader{r: r}
+	return &binaryDecoder{dec: mode.NewDecoder(body), body: body}
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// Records the first error reading a request body, so errors from the body
+// such as http.MaxBytesError are not mistaken for malformed data
+type bodyReader struct {
+	r   io.Reader
+	err error
+}
+
+func (b *bodyReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
+	}
+	return n, err
+}
+
+// Adapts a binary decoder's errors to the Decoder contract
+type binaryDecoder struct {
+	dec  Decoder
+	body *bodyReader
+}
+
+func (d *binaryDecoder) Decode(v any) error {
+	err := d.dec.Decode(v)
+	switch {
+	case err == nil:
+		return nil
+	case d.body.err != nil:
+		return d.body.err
+	case err == io.EOF:
+		return io.EOF
+	default:
+		return fmt.Errorf("%w: %v", ErrMalformedBody, err)
+	}
+}
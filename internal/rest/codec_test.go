@@ -0,0 +1,180 @@
+package rest
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+type codecResource struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	Tags      []string          `json:"tags"`
+	Settings  map[string]string `json:"settings,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	Secret    string            `json:"-"`
+}
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{JSONContentType, MessagePackContentType, CBORContentType}
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", JSONContentType},
+		{"*/*", JSONContentType},
+		{"application/*", JSONContentType},
+		{"application/cbor", CBORContentType},
+		{"application/msgpack, application/json;q=0.5", MessagePackContentType},
+		{"application/json;q=0.1, application/cbor;q=0.9", CBORContentType},
+		{"application/json;q=0, */*", MessagePackContentType},
+		{"application/json;q=0, application/msgpack;q=0, application/*;q=0.2", CBORContentType},
+		{"text/html, application/xhtml+xml, */*;q=0.8", JSONContentType},
+		{"application/problem+json", JSONContentType},
+		{"application/cbor;q=0.5, application/problem+json", JSONContentType},
+		{"application/problem+json;q=0.5, application/cbor", CBORContentType},
+		{"application/problem+json, application/json;q=0", ""},
+		{"text/html", ""},
+		{"application/json;q=0", ""},
+		{"application/json;q=2", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.accept, func(t *testing.T) {
+			got, ok := negotiate(test.accept, offers)
+			assert.Equal(t, ok, test.want != "")
+			assert.Equal(t, got, test.want)
+		})
+	}
+}
+
+func TestCodecs(t *testing.T) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	resource := codecResource{
+		ID:        1,
+		Name:      "resource",
+		Tags:      []string{"a", "b"},
+		Settings:  map[string]string{"theme": "dark"},
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		Secret:    "hidden",
+	}
+
+	for _, mediaType := range []string{JSONContentType, MessagePackContentType, CBORContentType} {
+		codec := rest.codecs[mediaType]
+
+		// Reads a body sent in mediaType into a resource
+		read := func(body []byte, opts ...ReadOption) (codecResource, int) {
+			req := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+			req.Header.Set("Content-Type", mediaType)
+
+			var dst codecResource
+			if err := rest.Read(httptest.NewRecorder(), req, "test", &dst, opts...); err != nil {
+				return dst, err.StatusCode
+			}
+			return dst, http.StatusOK
+		}
+
+		t.Run(mediaType+"/RoundTrip", func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept", mediaType)
+			rr := httptest.NewRecorder()
+			rest.Write(rr, req, "test", http.StatusOK, resource)
+
+			assert.Equal(t, rr.Header().Get("Content-Type"), mediaType)
+			assert.Equal(t, rr.Header().Get("Vary"), "Accept")
+
+			got, status := read(rr.Body.Bytes())
+			assert.Equal(t, status, http.StatusOK)
+			assert.Equal(t, got.ID, resource.ID)
+			assert.Equal(t, got.Name, resource.Name)
+			assert.Equal(t, len(got.Tags), 2)
+			assert.Equal(t, got.Settings["theme"], "dark")
+			assert.True(t, got.CreatedAt.Equal(resource.CreatedAt))
+			assert.Equal(t, got.Secret, "")
+		})
+
+		t.Run(mediaType+"/UnknownFields", func(t *testing.T) {
+			body, _ := codec.Marshal(map[string]any{"name": "a", "extra": true})
+
+			_, status := read(body)
+			assert.Equal(t, status, http.StatusBadRequest)
+
+			got, status := read(body, AllowUnknownFields())
+			assert.Equal(t, status, http.StatusOK)
+			assert.Equal(t, got.Name, "a")
+		})
+
+		t.Run(mediaType+"/Malformed", func(t *testing.T) {
+			body, _ := codec.Marshal(map[string]any{"name": "a"})
+
+			_, status := read(body[:len(body)-1])
+			assert.Equal(t, status, http.StatusBadRequest)
+
+			_, status = read(append(body, body...))
+			assert.Equal(t, status, http.StatusBadRequest)
+
+			_, status = read(nil)
+			assert.Equal(t, status, http.StatusBadRequest)
+		})
+
+		t.Run(mediaType+"/MaxBodySize", func(t *testing.T) {
+			body, _ := codec.Marshal(map[string]any{"name": string(bytes.Repeat([]byte("a"), 2048))})
+
+			_, status := read(body, MaxBodySize(1024))
+			assert.Equal(t, status, http.StatusRequestEntityTooLarge)
+		})
+	}
+}
+
+func TestNotAcceptable(t *testing.T) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	t.Run("Write", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", "text/html")
+		rr := httptest.NewRecorder()
+		rest.Write(rr, req, "test", http.StatusOK, Envelope{"name": "a"})
+
+		// The error itself falls back to JSON
+		assert.Equal(t, rr.Code, http.StatusNotAcceptable)
+		assert.Equal(t, rr.Header().Get("Content-Type"), JSONContentType)
+		assert.Check(t, bytes.Contains(rr.Body.Bytes(), []byte("application/json, application/msgpack, or application/cbor")))
+	})
+
+	t.Run("Read", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set("Accept", "text/csv")
+
+		var dst struct{}
+		err := rest.Read(httptest.NewRecorder(), req, "test", &dst)
+		assert.Equal(t, err.StatusCode, http.StatusNotAcceptable)
+		assert.Equal(t, err.Code(), "not_acceptable")
+	})
+}
+
+func TestAcceptProblemJSON(t *testing.T) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", ProblemContentType)
+	rr := httptest.NewRecorder()
+	rest.Write(rr, req, "test", http.StatusOK, Envelope{"name": "a"})
+
+	// Successful responses are JSON, and errors are problem details
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assert.Equal(t, rr.Header().Get("Content-Type"), JSONContentType)
+
+	rr = httptest.NewRecorder()
+	rest.Error(rr, req, xerrors.ClientError(http.StatusNotFound, "not found", "test", xerrors.ErrNotFound))
+	assert.Equal(t, rr.Code, http.StatusNotFound)
+	assert.Equal(t, rr.Header().Get("Content-Type"), ProblemContentType)
+}
@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	"go-rest-starter.jtbergman.me/internal/validator"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Decode
+// ============================================================================
+
+// Implemented by request inputs that check their own fields
+type Validatable interface {
+	Validate(v *validator.Validator)
+}
+
+// Reads the request body into a T and validates it if T (or *T) implements
+// Validatable, returning either a decoding or a validation error
+func Decode[T any](rest *Rest, w http.ResponseWriter, r *http.Request, op string, opts ...ReadOption) (T, *xerrors.AppError) {
+	var input T
+	if err := rest.Read(w, r, op, &input, opts...); err != nil {
+		return input, err
+	}
+
+	if validatable, ok := any(&input).(Validatable); ok {
+		v := validator.New()
+		validatable.Validate(v)
+		if err := v.Valid(op); err != nil {
+			return input, err
+		}
+	}
+
+	return input, nil
+}
+
+// ============================================================================
+// Handle
+// ============================================================================
+
+// Adapts a typed handler to an http.HandlerFunc
+//
+// The request body is decoded with Decode, and the result is written with
+// Write using the given status. Errors from either step are written with
+// Error. Handlers that need the request itself should use Decode instead.
+func Handle[T, R any](
+	rest *Rest,
+	op string,
+	status int,
+	fn func(ctx context.Context, input T) (R, *xerrors.AppError),
+	opts ...ReadOption,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		input, err := Decode[T](rest, w, r, op, opts...)
+		if err != nil {
+			rest.Error(w, r, err)
+			return
+		}
+
+		result, err := fn(r.Context(), input)
+		if err != nil {
+			rest.Error(w, r, err)
+			return
+		}
+
+		rest.Write(w, r, op, status, result)
+	}
+}
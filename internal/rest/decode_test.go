@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/validator"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+type decodeInput struct {
+	Name string `json:"name"`
+}
+
+func (input *decodeInput) Validate(v *validator.Validator) {
+	v.Check(len(input.Name) > 0, "name", "must be provided")
+}
+
+type decodeOutput struct {
+	Greeting string `json:"greeting"`
+}
+
+func TestHandle(t *testing.T) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	handler := Handle(rest, "test.greet", http.StatusCreated, func(ctx context.Context, input decodeInput) (decodeOutput, *xerrors.AppError) {
+		if input.Name == "forbidden" {
+			return decodeOutput{}, xerrors.ClientForbidden(true, "test.greet")
+		}
+		return decodeOutput{Greeting: "Hello, " + input.Name}, nil
+	})
+
+	assert.RunHandlerTestCase(t, handler, "POST", "/", assert.HandlerTestCase[decodeOutput]{
+		Name:   "Valid",
+		Body:   `{"name": "Go"}`,
+		Status: http.StatusCreated,
+		FN: func(t *testing.T, result decodeOutput) {
+			assert.Equal(t, result.Greeting, "Hello, Go")
+		},
+	})
+
+	assert.RunHandlerTestCase(t, handler, "POST", "/", assert.HandlerTestCase[struct{ Error map[string]string }]{
+		Name:   "Invalid",
+		Body:   `{"name": ""}`,
+		Status: http.StatusUnprocessableEntity,
+		FN: func(t *testing.T, result struct{ Error map[string]string }) {
+			assert.Equal(t, result.Error["name"], "must be provided")
+		},
+	})
+
+	assert.RunHandlerTestCase(t, handler, "POST", "/", assert.HandlerTestCase[struct{ Error string }]{
+		Name:   "Malformed",
+		Body:   `{"name": `,
+		Status: http.StatusBadRequest,
+	})
+
+	assert.RunHandlerTestCase(t, handler, "POST", "/", assert.HandlerTestCase[struct{ Error string }]{
+		Name:   "HandlerError",
+		Body:   `{"name": "forbidden"}`,
+		Status: http.StatusForbidden,
+	})
+}
+
+func TestDecodeWithoutValidate(t *testing.T) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// Types without a Validate method are only decoded
+	handler := Handle(rest, "test.echo", http.StatusOK, func(ctx context.Context, input decodeOutput) (decodeOutput, *xerrors.AppError) {
+		return input, nil
+	})
+
+	assert.RunHandlerTestCase(t, handler, "POST", "/", assert.HandlerTestCase[decodeOutput]{
+		Name:   "Empty",
+		Body:   `{"greeting": ""}`,
+		Status: http.StatusOK,
+	})
+}
@@ -0,0 +1,120 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// ETags
+// ============================================================================
+
+// Returns a strong ETag for a resource version, e.g. "3"
+//
+// Handlers that serve or update a versioned resource should set it as the
+// ETag header, and Write then keeps it instead of hashing the body. Strong
+// ETags identify one representation, so Write adds the subtype of other
+// media types, e.g. "3+cbor".
+func VersionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// Returns the strong ETag for the representation of a resource in the media
+// type, which is unchanged for JSON and weak ETags
+func representationETag(etag string, mediaType string) string {
+	if isJSONMediaType(mediaType) || strings.HasPrefix(etag, "W/") || !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+
+	_, subtype, _ := strings.Cut(mediaType, "/")
+	return strings.TrimSuffix(etag, `"`) + "+" + subtype + `"`
+}
+
+// Removes the representation from the ETags in an If-Match or If-None-Match
+// header, e.g. "3+cbor" becomes "3"
+func resourceETags(header string) string {
+	candidates := strings.Split(header, ",")
+	for i, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		if base, _, ok := strings.Cut(candidate, "+"); ok {
+			candidate = base + `"`
+		}
+		candidates[i] = candidate
+	}
+
+	return strings.Join(candidates, ",")
+}
+
+// Returns a weak ETag for a response body. Weak because the same data may be
+// encoded differently, e.g. when compressed, without changing its meaning.
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Returns true if an If-Match or If-None-Match header lists the ETag
+//
+// "*" matches any ETag. Strong comparison (weak == false) requires both tags
+// to be strong and equal, as If-Match does, and weak comparison ignores the
+// W/ prefix, as If-None-Match does (RFC 9110 Section 8.8.3.2).
+func MatchETag(header string, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if candidate == etag && !strings.HasPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ============================================================================
+// Preconditions
+// ============================================================================
+
+// Evaluates If-Match and If-None-Match for a request that changes the
+// resource with the given current ETag
+//
+// Returns a 412 if If-Match does not list the ETag, so clients only
+// overwrite the version they read, or if If-None-Match does. The ETags of
+// every representation of the version match, since they share one resource.
+// Missing headers always pass. Conditional GETs are answered with 304 by
+// middleware instead.
+func CheckPreconditions(r *http.Request, op string, etag string) *xerrors.AppError {
+	if ifMatch := resourceETags(strings.Join(r.Header.Values("If-Match"), ",")); ifMatch != "" && !MatchETag(ifMatch, etag, false) {
+		return xerrors.ClientError(
+			http.StatusPreconditionFailed,
+			"The resource has changed, fetch it again before updating",
+			op,
+			xerrors.ErrPreconditionFailed,
+		)
+	}
+
+	if ifNoneMatch := resourceETags(strings.Join(r.Header.Values("If-None-Match"), ",")); ifNoneMatch != "" && MatchETag(ifNoneMatch, etag, true) {
+		return xerrors.ClientError(
+			http.StatusPreconditionFailed,
+			"The resource already matches the precondition",
+			op,
+			xerrors.ErrPreconditionFailed,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+)
+
+func TestMatchETag(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		etag   string
+		weak   bool
+		want   bool
+	}{
+		{"Strong/Equal", `"3"`, `"3"`, false, true},
+		{"Strong/List", `"1", "2" ,"3"`, `"3"`, false, true},
+		{"Strong/Different", `"2"`, `"3"`, false, false},
+		{"Strong/WeakHeader", `W/"3"`, `"3"`, false, false},
+		{"Strong/WeakETag", `W/"3"`, `W/"3"`, false, false},
+		{"Strong/Wildcard", `*`, `"3"`, false, true},
+		{"Weak/Equal", `W/"3"`, `"3"`, true, true},
+		{"Weak/Different", `W/"2"`, `W/"3"`, true, false},
+		{"Weak/Wildcard", `*`, `W/"3"`, true, true},
+		{"NoETag", `*`, ``, true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, MatchETag(test.header, test.etag, test.weak), test.want)
+		})
+	}
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	etag := VersionETag(3)
+	assert.Equal(t, etag, `"3"`)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{name: "None"},
+		{name: "IfMatch", headers: map[string]string{"If-Match": `"3"`}},
+		{name: "IfMatch/Stale", headers: map[string]string{"If-Match": `"2"`}, status: http.StatusPreconditionFailed},
+		{name: "IfMatch/Weak", headers: map[string]string{"If-Match": `W/"3"`}, status: http.StatusPreconditionFailed},
+		{name: "IfMatch/Representation", headers: map[string]string{"If-Match": `"3+cbor"`}},
+		{name: "IfMatch/Representation/Stale", headers: map[string]string{"If-Match": `"2+cbor"`}, status: http.StatusPreconditionFailed},
+		{name: "IfMatch/Wildcard", headers: map[string]string{"If-Match": `*`}},
+		{name: "IfNoneMatch", headers: map[string]string{"If-None-Match": `"2"`}},
+		{name: "IfNoneMatch/Matches", headers: map[string]string{"If-None-Match": `*`}, status: http.StatusPreconditionFailed},
+		{name: "IfNoneMatch/Representation", headers: map[string]string{"If-None-Match": `W/"3+msgpack"`}, status: http.StatusPreconditionFailed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("PATCH", "/", nil)
+			for key, value := range test.headers {
+				req.Header.Set(key, value)
+			}
+
+			err := CheckPreconditions(req, "test", etag)
+			if test.status == 0 {
+				assert.Check(t, err == nil)
+				return
+			}
+			assert.Equal(t, err.StatusCode, test.status)
+			assert.Equal(t, err.Code(), "precondition_failed")
+		})
+	}
+}
+
+func TestWriteETag(t *testing.T) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	write := func(status int, data any, etag string, accept ...string) string {
+		rr := httptest.NewRecorder()
+		if etag != "" {
+			rr.Header().Set("ETag", etag)
+		}
+		req := httptest.NewRequest("GET", "/", nil)
+		for _, value := range accept {
+			req.Header.Add("Accept", value)
+		}
+		rest.Write(rr, req, "test", status, data)
+		return rr.Header().Get("ETag")
+	}
+
+	// The same content has the same weak ETag
+	first := write(http.StatusOK, Envelope{"name": "a"}, "")
+	assert.Check(t, strings.HasPrefix(first, `W/"`))
+	assert.Equal(t, write(http.StatusOK, Envelope{"name": "a"}, ""), first)
+	assert.Check(t, write(http.StatusOK, Envelope{"name": "b"}, "") != first)
+
+	// Handlers may set a strong ETag, and other statuses have none
+	assert.Equal(t, write(http.StatusOK, Envelope{"name": "a"}, VersionETag(1)), `"1"`)
+	assert.Equal(t, write(http.StatusCreated, Envelope{"name": "a"}, ""), "")
+
+	// Strong ETags are specific to the representation
+	assert.Equal(t, write(http.StatusOK, Envelope{"name": "a"}, VersionETag(1), JSONContentType), `"1"`)
+	assert.Equal(t, write(http.StatusOK, Envelope{"name": "a"}, VersionETag(1), "application/problem+json"), `"1"`)
+	assert.Equal(t, write(http.StatusOK, Envelope{"name": "a"}, VersionETag(1), MessagePackContentType), `"1+msgpack"`)
+	assert.Equal(t, write(http.StatusOK, Envelope{"name": "a"}, VersionETag(1), CBORContentType), `"1+cbor"`)
+	assert.Check(t, strings.HasPrefix(write(http.StatusOK, Envelope{"name": "a"}, "", CBORContentType), `W/"`))
+}
@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Write HTML
+// ============================================================================
+
+// The data available to HTML templates
+type page struct {
+	Nonce string
+}
+
+// Renders an HTML template file to the client
+//
+// The Content-Security-Policy nonce is available to the page as {{ .Nonce }}
+// so inline scripts and styles can be allowed without 'unsafe-inline'. Like
+// http.ServeFile, the file is read on every request.
+func (rest *Rest) WriteHTML(w http.ResponseWriter, r *http.Request, op string, filename string, nonce string) {
+	tmpl, err := template.ParseFiles(filename)
+	if err != nil {
+		rest.Error(w, r, xerrors.ServerError(op, fmt.Errorf("%w: %v", xerrors.ErrServerInternal, err)))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, page{Nonce: nonce}); err != nil {
+		rest.Error(w, r, xerrors.ServerError(op, fmt.Errorf("%w: %v", xerrors.ErrServerInternal, err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
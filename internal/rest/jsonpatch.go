@@ -0,0 +1,330 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// JSON Merge Patch (RFC 7396)
+// ============================================================================
+
+// Applies a merge patch to a decoded JSON document. Objects are merged
+// recursively, null removes a member, and any other value replaces the target.
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatch(targetObject[key], value)
+		}
+	}
+
+	return targetObject
+}
+
+// ============================================================================
+// JSON Patch (RFC 6902)
+// ============================================================================
+
+// Errors applying a JSON Patch
+var (
+	errPatchInvalid    = errors.New("patch is invalid")
+	errPatchPath       = errors.New("path does not exist")
+	errPatchTestFailed = errors.New("test failed")
+)
+
+// A single JSON Patch operation
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Applies the operations in order to a decoded JSON document, returning the
+// patched document. The document may be modified in place.
+func jsonPatch(doc any, operations []patchOperation) (any, error) {
+	for i, operation := range operations {
+		var err error
+		if doc, err = operation.apply(doc); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+	}
+
+	return doc, nil
+}
+
+// Applies the operation to the document
+func (o patchOperation) apply(doc any) (any, error) {
+	path, err := parsePointer(o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case "add", "replace", "test":
+		if o.Value == nil {
+			return nil, fmt.Errorf("%w: value is required", errPatchInvalid)
+		}
+		value, err := decodeValue(o.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch o.Op {
+		case "add":
+			return pointerAdd(doc, path, value)
+
+		case "replace":
+			if _, err := pointerGet(doc, path); err != nil {
+				return nil, err
+			}
+			if doc, err = pointerRemove(doc, path); err != nil {
+				return nil, err
+			}
+			return pointerAdd(doc, path, value)
+
+		default:
+			current, err := pointerGet(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, errPatchTestFailed
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		return pointerRemove(doc, path)
+
+	case "move", "copy":
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if o.Op == "copy" {
+			return pointerAdd(doc, path, deepCopy(value))
+		}
+
+		// A value cannot be moved into one of its own children
+		if strings.HasPrefix(o.Path, o.From+"/") {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", errPatchInvalid)
+		}
+		if doc, err = pointerRemove(doc, from); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, value)
+
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", errPatchInvalid, o.Op)
+	}
+}
+
+// Decodes a JSON value, keeping numbers as json.Number so they compare and
+// round trip exactly
+func decodeValue(raw []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("%w: %v", errPatchInvalid, err)
+	}
+
+	return value, nil
+}
+
+// Copies maps and slices so a copied value can be changed independently
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, child := range v {
+			copied[key] = deepCopy(child)
+		}
+		return copied
+
+	case []any:
+		copied := make([]any, len(v))
+		for i, child := range v {
+			copied[i] = deepCopy(child)
+		}
+		return copied
+
+	default:
+		return v
+	}
+}
+
+// ============================================================================
+// JSON Pointer (RFC 6901)
+// ============================================================================
+
+// Splits a JSON Pointer into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", errPatchInvalid, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+// Returns the value at the path
+func pointerGet(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			child, ok := node[token]
+			if !ok {
+				return nil, errPatchPath
+			}
+			doc = child
+
+		case []any:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+
+		default:
+			return nil, errPatchPath
+		}
+	}
+
+	return doc, nil
+}
+
+// Adds a value at the path, inserting into arrays and replacing object
+// members, and returns the new document
+func pointerAdd(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return pointerUpdate(doc, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+
+		case []any:
+			if token == "-" {
+				return append(node, value), nil
+			}
+			i, err := arrayIndex(token, len(node))
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:i], append([]any{value}, node[i:]...)...), nil
+
+		default:
+			return nil, errPatchPath
+		}
+	})
+}
+
+// Removes the value at the path and returns the new document
+func pointerRemove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", errPatchInvalid)
+	}
+
+	return pointerUpdate(doc, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			if _, ok := node[token]; !ok {
+				return nil, errPatchPath
+			}
+			delete(node, token)
+			return node, nil
+
+		case []any:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:i:i], node[i+1:]...), nil
+
+		default:
+			return nil, errPatchPath
+		}
+	})
+}
+
+// Walks to the parent of the last token and replaces it with the result of
+// fn, setting each new child back into its parent on the way out since
+// arrays may be reallocated
+func pointerUpdate(doc any, path []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	child, err := pointerGet(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	child, err = pointerUpdate(child, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := doc.(type) {
+	case map[string]any:
+		node[path[0]] = child
+
+	case []any:
+		i, _ := arrayIndex(path[0], len(node)-1)
+		node[i] = child
+	}
+
+	return doc, nil
+}
+
+// Parses an array index that must be between 0 and last inclusive
+func arrayIndex(token string, last int) (int, error) {
+	// Indexes are plain digits without leading zeros
+	if token == "" || strings.Trim(token, "0123456789") != "" || (len(token) > 1 && token[0] == '0') {
+		return 0, errPatchPath
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i > last {
+		return 0, errPatchPath
+	}
+
+	return i, nil
+}
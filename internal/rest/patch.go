@@ -0,0 +1,268 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go-rest-starter.jtbergman.me/internal/validator"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Constants
+// ============================================================================
+
+// The patch formats accepted by ReadPatch
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// The Accept-Patch header value advertising both formats (RFC 5789)
+const AcceptPatch = MergePatchContentType + ", " + JSONPatchContentType
+
+// ============================================================================
+// Read Patch
+// ============================================================================
+
+// Applies the JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902) in the
+// request body to dst, which must be a pointer to the current resource
+//
+// The patch is applied to the JSON representation of dst. Only the top level
+// fields named in allowed (by their JSON names) may change, and only those
+// fields are copied back into dst, so fields hidden from JSON such as
+// passwords and versions are never touched. If dst implements Validatable it
+// is validated after patching.
+//
+// Errors are 415 for other media types, 406 for clients that accept none of
+// the registered response media types, 400 for malformed patches, 409 for a
+// failed JSON Patch test, and 422 for patches that cannot be applied, change
+// fields outside allowed, or produce an invalid resource.
+func (rest *Rest) ReadPatch(w http.ResponseWriter, r *http.Request, op string, dst any, allowed []string, opts ...ReadOption) *xerrors.AppError {
+	options := readOptions{maxBodySize: DefaultMaxBodySize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// Check the patch format
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	charset, hasCharset := params["charset"]
+	if err != nil || (mediaType != MergePatchContentType && mediaType != JSONPatchContentType) || (hasCharset && !strings.EqualFold(charset, "utf-8")) {
+		appErr := xerrors.ClientError(
+			http.StatusUnsupportedMediaType,
+			"Request body must be sent as "+MergePatchContentType+" or "+JSONPatchContentType,
+			op,
+			fmt.Errorf("%w: %q", xerrors.ErrUnsupportedMediaType, r.Header.Get("Content-Type")),
+		)
+		appErr.Header = http.Header{"Accept-Patch": {AcceptPatch}}
+		return appErr
+	}
+
+	// The patched resource must be writable in a media type the client accepts
+	if _, _, appErr := rest.responseCodec(r, op); appErr != nil {
+		return appErr
+	}
+
+	// Read the patch
+	body, appErr := readBody(w, r, op, options.maxBodySize)
+	if appErr != nil {
+		return appErr
+	}
+	dec := json.NewDecoder(body)
+	dec.UseNumber()
+
+	// Get the current representation
+	original, err := toDocument(dst)
+	if err != nil {
+		return xerrors.ServerError(op, fmt.Errorf("%w: %v", xerrors.ErrServerInternal, err))
+	}
+	doc, _ := toDocument(dst)
+
+	// Apply the patch
+	var patched any
+	switch mediaType {
+	case MergePatchContentType:
+		var patch any
+		if appErr := decode(dec, op, &patch); appErr != nil {
+			return appErr
+		}
+		patched = mergePatch(doc, patch)
+
+	case JSONPatchContentType:
+		var operations []patchOperation
+		if appErr := decode(dec, op, &operations); appErr != nil {
+			return appErr
+		}
+		if patched, err = jsonPatch(doc, operations); err != nil {
+			return patchError(err, op)
+		}
+	}
+
+	object, ok := patched.(map[string]any)
+	if !ok {
+		return patchError(fmt.Errorf("%w: the result must be an object", errPatchInvalid), op)
+	}
+
+	// Only allowed fields may change
+	v := validator.New()
+	for _, key := range changedKeys(original, object) {
+		v.Check(slices.Contains(allowed, key), key, "cannot be changed")
+	}
+	if err := v.Valid(op); err != nil {
+		return err
+	}
+
+	// Copy the allowed fields into dst
+	if err := copyFields(original, object, dst, allowed, v); err != nil {
+		return xerrors.ServerError(op, fmt.Errorf("%w: %v", xerrors.ErrServerInternal, err))
+	}
+
+	// Validate the patched resource
+	if validatable, ok := dst.(Validatable); ok {
+		validatable.Validate(v)
+	}
+
+	return v.Valid(op)
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// Converts a value to a decoded JSON object
+func toDocument(value any) (map[string]any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Returns the top level keys that were added, removed, or changed, sorted
+func changedKeys(before, after map[string]any) []string {
+	keys := []string{}
+	for key, value := range after {
+		if previous, ok := before[key]; !ok || !reflect.DeepEqual(previous, value) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+	return keys
+}
+
+// Decodes the patched object into a new value of dst's type and copies the
+// allowed fields into dst. Values of the wrong type are added to the
+// validator. Fields that can hold null (pointers, slices, maps, and
+// interfaces) become nil when set to null or removed, and others are
+// rejected rather than silently set to their zero value.
+func copyFields(original, object map[string]any, dst any, allowed []string, v *validator.Validator) error {
+	target := reflect.ValueOf(dst)
+	if target.Kind() != reflect.Pointer || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("dst must be a pointer to a struct, got %T", dst)
+	}
+	target = target.Elem()
+	fields := jsonFields(target.Type())
+
+	for _, key := range allowed {
+		index, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("%s is not a JSON field of %s", key, target.Type())
+		}
+
+		field := reflect.New(target.Field(index).Type())
+		value, ok := object[key]
+		_, existed := original[key]
+		if ((ok && value == nil) || (!ok && existed)) && !nullable(field.Elem().Kind()) {
+			v.AddError(key, "cannot be null")
+			continue
+		}
+
+		if ok {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(data, field.Interface()); err != nil {
+				v.AddError(key, "has an invalid value")
+				continue
+			}
+		}
+
+		target.Field(index).Set(field.Elem())
+	}
+
+	return nil
+}
+
+// Returns true if values of the kind decode JSON null as nil
+func nullable(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	default:
+		return false
+	}
+}
+
+// Maps the JSON names of a struct's exported fields to their index
+func jsonFields(t reflect.Type) map[string]int {
+	fields := map[string]int{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		fields[name] = i
+	}
+
+	return fields
+}
+
+// Converts an error applying a JSON Patch to a client error
+func patchError(err error, op string) *xerrors.AppError {
+	if errors.Is(err, errPatchTestFailed) {
+		return xerrors.ClientError(
+			http.StatusConflict,
+			"The resource does not match the patch's test",
+			op,
+			fmt.Errorf("%w: %v", xerrors.ErrConflict, err),
+		)
+	}
+
+	return xerrors.ClientError(
+		http.StatusUnprocessableEntity,
+		fmt.Sprintf("The patch could not be applied: %v", err),
+		op,
+		fmt.Errorf("%w: %v", xerrors.ErrInvalidPatch, err),
+	)
+}
@@ -0,0 +1,205 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/validator"
+)
+
+type patchResource struct {
+	ID       int64             `json:"id"`
+	Name     string            `json:"name"`
+	Tags     []string          `json:"tags"`
+	Settings map[string]string `json:"settings,omitempty"`
+	Secret   string            `json:"-"`
+}
+
+func (p *patchResource) Validate(v *validator.Validator) {
+	v.Check(p.Name != "", "name", "must be provided")
+}
+
+func TestReadPatch(t *testing.T) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	allowed := []string{"name", "tags", "settings"}
+
+	patch := func(contentType, body string) (*patchResource, int, string) {
+		resource := &patchResource{ID: 1, Name: "before", Tags: []string{"a", "b"}, Secret: "hidden"}
+
+		req := httptest.NewRequest("PATCH", "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+
+		err := rest.ReadPatch(httptest.NewRecorder(), req, "test", resource, allowed)
+		if err != nil {
+			return resource, err.StatusCode, err.Code()
+		}
+		return resource, http.StatusOK, ""
+	}
+
+	t.Run("MergePatch", func(t *testing.T) {
+		resource, status, _ := patch(MergePatchContentType, `{"name": "after", "tags": null, "settings": {"theme": "dark"}}`)
+		assert.Equal(t, status, http.StatusOK)
+		assert.Equal(t, resource.Name, "after")
+		assert.Check(t, resource.Tags == nil)
+		assert.Equal(t, resource.Settings["theme"], "dark")
+		assert.Equal(t, resource.Secret, "hidden")
+	})
+
+	t.Run("JSONPatch", func(t *testing.T) {
+		body := `[
+			{"op": "test", "path": "/name", "value": "before"},
+			{"op": "replace", "path": "/name", "value": "after"},
+			{"op": "add", "path": "/tags/1", "value": "x"},
+			{"op": "remove", "path": "/tags/0"},
+			{"op": "copy", "from": "/tags/0", "path": "/tags/-"}
+		]`
+		resource, status, _ := patch(JSONPatchContentType, body)
+		assert.Equal(t, status, http.StatusOK)
+		assert.Equal(t, resource.Name, "after")
+		assert.Equal(t, strings.Join(resource.Tags, ","), "x,b,x")
+	})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		code        string
+	}{
+		{"ContentType/JSON", "application/json", `{"name": "after"}`, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"ContentType/Missing", "", `{"name": "after"}`, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{"Malformed", MergePatchContentType, `{"name": `, http.StatusBadRequest, "bad_request"},
+		{"NotAllowed", MergePatchContentType, `{"id": 2}`, http.StatusUnprocessableEntity, "failed_validation"},
+		{"HiddenField", MergePatchContentType, `{"Secret": "exposed"}`, http.StatusUnprocessableEntity, "failed_validation"},
+		{"InvalidType", MergePatchContentType, `{"name": 5}`, http.StatusUnprocessableEntity, "failed_validation"},
+		{"Validation", MergePatchContentType, `{"name": ""}`, http.StatusUnprocessableEntity, "failed_validation"},
+		{"NotObject", MergePatchContentType, `[]`, http.StatusUnprocessableEntity, "invalid_patch"},
+		{"TestFailed", JSONPatchContentType, `[{"op": "test", "path": "/name", "value": "other"}]`, http.StatusConflict, "conflict"},
+		{"MissingPath", JSONPatchContentType, `[{"op": "replace", "path": "/missing", "value": 1}]`, http.StatusUnprocessableEntity, "invalid_patch"},
+		{"UnknownOp", JSONPatchContentType, `[{"op": "merge", "path": "/name", "value": 1}]`, http.StatusUnprocessableEntity, "invalid_patch"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, status, code := patch(test.contentType, test.body)
+			assert.Equal(t, status, test.status)
+			assert.Equal(t, code, test.code)
+		})
+	}
+
+	t.Run("Null", func(t *testing.T) {
+		for _, test := range []struct{ contentType, body string }{
+			{MergePatchContentType, `{"name": null}`},
+			{JSONPatchContentType, `[{"op": "replace", "path": "/name", "value": null}]`},
+			{JSONPatchContentType, `[{"op": "remove", "path": "/name"}]`},
+		} {
+			req := httptest.NewRequest("PATCH", "/", strings.NewReader(test.body))
+			req.Header.Set("Content-Type", test.contentType)
+
+			resource := &patchResource{ID: 1, Name: "before"}
+			err := rest.ReadPatch(httptest.NewRecorder(), req, "test", resource, allowed)
+			assert.Equal(t, err.StatusCode, http.StatusUnprocessableEntity)
+			assert.Equal(t, err.Data.(map[string]string)["name"], "cannot be null")
+			assert.Equal(t, resource.Name, "before")
+		}
+	})
+
+	t.Run("AcceptPatch", func(t *testing.T) {
+		req := httptest.NewRequest("PATCH", "/", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "text/plain")
+
+		err := rest.ReadPatch(httptest.NewRecorder(), req, "test", &patchResource{}, allowed)
+		assert.Equal(t, err.Header.Get("Accept-Patch"), AcceptPatch)
+	})
+}
+
+// Examples from RFC 6902 Appendix A
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		err   error
+	}{
+		{"AddMember", `{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux"}]`, `{"baz": "qux", "foo": "bar"}`, nil},
+		{"AddElement", `{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo": ["bar", "qux", "baz"]}`, nil},
+		{"RemoveMember", `{"baz": "qux", "foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`, `{"foo": "bar"}`, nil},
+		{"RemoveElement", `{"foo": ["bar", "qux", "baz"]}`, `[{"op": "remove", "path": "/foo/1"}]`, `{"foo": ["bar", "baz"]}`, nil},
+		{"Replace", `{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`, `{"baz": "boo", "foo": "bar"}`, nil},
+		{"Move", `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`, `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`, `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`, nil},
+		{"MoveElement", `{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`, `{"foo": ["all", "cows", "eat", "grass"]}`, nil},
+		{"Test", `{"baz": "qux", "foo": ["a", 2, "c"]}`, `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`, `{"baz": "qux", "foo": ["a", 2, "c"]}`, nil},
+		{"TestFailed", `{"baz": "qux"}`, `[{"op": "test", "path": "/baz", "value": "bar"}]`, ``, errPatchTestFailed},
+		{"AddNested", `{"foo": "bar"}`, `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`, `{"foo": "bar", "child": {"grandchild": {}}}`, nil},
+		{"AddMissingParent", `{"foo": "bar"}`, `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`, ``, errPatchPath},
+		{"Escaped", `{"/": 9, "~1": 10}`, `[{"op": "test", "path": "/~01", "value": 10}]`, `{"/": 9, "~1": 10}`, nil},
+		{"AddArray", `{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`, `{"foo": ["bar", ["abc", "def"]]}`, nil},
+		{"IndexOutOfRange", `{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/2", "value": "x"}]`, ``, errPatchPath},
+		{"LeadingZero", `{"foo": ["bar", "baz"]}`, `[{"op": "remove", "path": "/foo/01"}]`, ``, errPatchPath},
+		{"MoveIntoChild", `{"foo": {"bar": 1}}`, `[{"op": "move", "from": "/foo", "path": "/foo/bar/baz"}]`, ``, errPatchInvalid},
+		{"MissingValue", `{"foo": 1}`, `[{"op": "add", "path": "/bar"}]`, ``, errPatchInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, _ := decodeValue([]byte(test.doc))
+			var operations []patchOperation
+			assert.Check(t, json.Unmarshal([]byte(test.patch), &operations) == nil)
+
+			got, err := jsonPatch(doc, operations)
+			if test.err != nil {
+				assert.Check(t, errors.Is(err, test.err))
+				return
+			}
+			assert.Check(t, err == nil)
+
+			want, _ := decodeValue([]byte(test.want))
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			assert.Equal(t, string(gotJSON), string(wantJSON))
+		})
+	}
+}
+
+// Examples from RFC 7396 Appendix A
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		target string
+		patch  string
+		want   string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.patch, func(t *testing.T) {
+			target, _ := decodeValue([]byte(test.target))
+			patch, _ := decodeValue([]byte(test.patch))
+
+			got, _ := json.Marshal(mergePatch(target, patch))
+			want, _ := decodeValue([]byte(test.want))
+			wantJSON, _ := json.Marshal(want)
+			assert.Equal(t, string(got), string(wantJSON))
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Problem Details
+// ============================================================================
+
+// The media type for problem details (RFC 9457)
+const ProblemContentType = "application/problem+json"
+
+// The base URI for problem types, followed by the error code
+const ProblemTypeBase = "https://go-rest-starter.jtbergman.me/problems/"
+
+// An RFC 9457 problem details object
+//
+// Code is the stable error code from xerrors (e.g. "not_found"), and Errors
+// holds field-level validation failures keyed by field name.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	Errors    map[string]string `json:"errors,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+// Creates the problem details for an error
+func newProblem(r *http.Request, err *xerrors.AppError, requestID string) Problem {
+	code := err.Code()
+	problem := Problem{
+		Type:      ProblemTypeBase + code,
+		Title:     http.StatusText(err.StatusCode),
+		Status:    err.StatusCode,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: requestID,
+	}
+
+	switch data := err.Data.(type) {
+	case string:
+		problem.Detail = data
+
+	case map[string]string:
+		problem.Detail = "The request contains invalid fields"
+		problem.Errors = data
+	}
+
+	return problem
+}
+
+// Returns true if the client accepts problem details, i.e. the Accept header
+// lists application/problem+json with a non-zero quality
+func acceptsProblem(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || mediaType != ProblemContentType {
+				continue
+			}
+
+			if q, ok := params["q"]; ok {
+				if value, err := strconv.ParseFloat(q, 64); err != nil || value == 0 {
+					continue
+				}
+			}
+			return true
+		}
+	}
+
+	return false
+}
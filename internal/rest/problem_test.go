@@ -0,0 +1,76 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+func TestErrorProblem(t *testing.T) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	validation := xerrors.ClientError(
+		http.StatusUnprocessableEntity,
+		map[string]string{"email": "is invalid"},
+		"test",
+		xerrors.ErrFailedValidation,
+	)
+
+	t.Run("Problem", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/v1/auth/register?x=1", nil)
+		req.Header.Set("Accept", "application/json;q=0.9, application/problem+json")
+		rr := httptest.NewRecorder()
+		rr.Header().Set(RequestIDHeader, "abc")
+
+		rest.Error(rr, req, validation)
+
+		var problem Problem
+		json.NewDecoder(rr.Body).Decode(&problem)
+		assert.Equal(t, rr.Code, http.StatusUnprocessableEntity)
+		assert.Equal(t, rr.Header().Get("Content-Type"), ProblemContentType)
+		assert.Equal(t, problem.Type, ProblemTypeBase+"failed_validation")
+		assert.Equal(t, problem.Title, "Unprocessable Entity")
+		assert.Equal(t, problem.Status, http.StatusUnprocessableEntity)
+		assert.Equal(t, problem.Instance, "/v1/auth/register")
+		assert.Equal(t, problem.Code, "failed_validation")
+		assert.Equal(t, problem.Errors["email"], "is invalid")
+		assert.Equal(t, problem.RequestID, "abc")
+	})
+
+	t.Run("Detail", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/v1/organizations", nil)
+		req.Header.Set("Accept", ProblemContentType)
+		rr := httptest.NewRecorder()
+
+		rest.Error(rr, req, xerrors.ClientUnauthorized(true, "test"))
+
+		var problem Problem
+		json.NewDecoder(rr.Body).Decode(&problem)
+		assert.Equal(t, problem.Code, "unauthorized")
+		assert.NotEqual(t, problem.Detail, "")
+		assert.Equal(t, len(problem.Errors), 0)
+	})
+
+	t.Run("Envelope", func(t *testing.T) {
+		for _, accept := range []string{"", "application/json", "application/problem+json;q=0"} {
+			req := httptest.NewRequest("POST", "/v1/auth/register", nil)
+			req.Header.Set("Accept", accept)
+			rr := httptest.NewRecorder()
+
+			rest.Error(rr, req, validation)
+
+			var env struct {
+				Error map[string]string `json:"error"`
+			}
+			json.NewDecoder(rr.Body).Decode(&env)
+			assert.Equal(t, rr.Header().Get("Content-Type"), "application/json")
+			assert.Equal(t, env.Error["email"], "is invalid")
+		}
+	})
+}
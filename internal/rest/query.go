@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/validator"
+)
+
+// ============================================================================
+// Query Strings
+// ============================================================================
+
+// Reads a string from the query string or returns the default value
+func ReadString(qs url.Values, key string, defaultValue string) string {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	return s
+}
+
+// Reads an integer from the query string or returns the default value. An
+// error is added to the validator if the value is not an integer.
+func ReadInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		v.AddError(key, "must be an integer value")
+		return defaultValue
+	}
+
+	return i
+}
+
+// Reads an RFC 3339 timestamp from the query string or returns the zero time.
+// An error is added to the validator if the value is not a timestamp.
+func ReadTime(qs url.Values, key string, v *validator.Validator) time.Time {
+	s := qs.Get(key)
+
+	if s == "" {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		v.AddError(key, "must be an RFC 3339 timestamp")
+		return time.Time{}
+	}
+
+	return t
+}
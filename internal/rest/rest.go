@@ -12,24 +12,65 @@ import (
 // ============================================================================
 
 type Rest struct {
-	Logger xlogger.Logger
+	Logger     xlogger.Logger
+	codecs     map[string]Codec
+	mediaTypes []string
 }
 
+// Creates a Rest that reads and writes JSON, MessagePack, and CBOR
 func New(logger xlogger.Logger) *Rest {
-	return &Rest{Logger: logger}
+	rest := &Rest{Logger: logger}
+	rest.Register(JSONContentType, jsonCodec{})
+	rest.Register(MessagePackContentType, msgpackCodec{})
+	rest.Register(CBORContentType, newCBORCodec())
+	return rest
 }
 
+// ============================================================================
+// Constants
+// ============================================================================
+
+// The header used to accept and echo request IDs
+const RequestIDHeader = "X-Request-ID"
+
 // ============================================================================
 // Methods
 // ============================================================================
 
-func (rest *Rest) Error(w http.ResponseWriter, err *xerrors.AppError) {
-	rest.Logger.Error(err.Error())
-	rest.WriteJSON(w, err.Op, err.StatusCode, Envelope{"error": err.Data})
+// Logs the error and writes it to the client
+//
+// Clients that accept application/problem+json receive RFC 9457 problem
+// details, and others receive {"error": ...} in the media type they accept,
+// or JSON if they accept none. Both include the request ID from the response
+// header set by middleware, so errors can be correlated with logs.
+func (rest *Rest) Error(w http.ResponseWriter, r *http.Request, err *xerrors.AppError) {
+	rest.Logger.ErrorContext(r.Context(), err.Error())
+
+	id := w.Header().Get(RequestIDHeader)
+
+	for key, values := range err.Header {
+		w.Header()[key] = values
+	}
+
+	if acceptsProblem(r) {
+		rest.write(w, r, err.Op, err.StatusCode, ProblemContentType, rest.codecs[JSONContentType], newProblem(r, err, id))
+		return
+	}
+
+	env := Envelope{"error": err.Data}
+	if id != "" {
+		env["request_id"] = id
+	}
+
+	mediaType, codec, notAcceptable := rest.responseCodec(r, err.Op)
+	if notAcceptable != nil {
+		mediaType, codec = JSONContentType, rest.codecs[JSONContentType]
+	}
+	rest.write(w, r, err.Op, err.StatusCode, mediaType, codec, env)
 }
 
 func (rest *Rest) MethodNotAllowed(w http.ResponseWriter, r *http.Request, allowed string) {
 	w.Header().Set("Allow", allowed)
 	w.WriteHeader(http.StatusMethodNotAllowed)
-	rest.Logger.Error("Method Not Allowed", "method", r.Method, "uri", r.URL.RequestURI())
+	rest.Logger.ErrorContext(r.Context(), "Method Not Allowed", "method", r.Method, "uri", r.URL.RequestURI())
 }
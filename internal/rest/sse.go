@@ -0,0 +1,185 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Constants
+// ============================================================================
+
+// The media type of Server-Sent Events
+const EventStreamContentType = "text/event-stream"
+
+// ============================================================================
+// Event
+// ============================================================================
+
+// A Server-Sent Event
+//
+// Data is sent as is if it is a string and as JSON otherwise, and events
+// without Data only update the client's last event ID or retry delay. Name
+// defaults to "message" in the browser, and Retry tells the browser how long
+// to wait before reconnecting.
+type Event struct {
+	ID    string
+	Name  string
+	Data  any
+	Retry time.Duration
+}
+
+// Returned by Send for IDs and names that would break the stream
+var errInvalidEvent = errors.New("event ID and name must not contain newlines or NUL")
+
+// ============================================================================
+// Event Stream
+// ============================================================================
+
+// Writes Server-Sent Events to a client
+//
+// Every write is flushed immediately, through any middleware wrapping the
+// response writer.
+type EventStream struct {
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	lastEventID string
+}
+
+// Starts an event stream, writing the response header
+//
+// The server's write timeout is lifted for the response, but the route must
+// also be configured without a request timeout. Clients that do not accept
+// text/event-stream receive a 406 and no stream is started.
+func (rest *Rest) EventStream(w http.ResponseWriter, r *http.Request, op string) (*EventStream, *xerrors.AppError) {
+	if _, ok := negotiate(strings.Join(r.Header.Values("Accept"), ","), []string{EventStreamContentType}); !ok {
+		return nil, xerrors.ClientError(
+			http.StatusNotAcceptable,
+			"Events can only be sent as "+EventStreamContentType,
+			op,
+			fmt.Errorf("%w: %q", xerrors.ErrNotAcceptable, r.Header.Get("Accept")),
+		)
+	}
+
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	header := w.Header()
+	header.Set("Content-Type", EventStreamContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := rc.Flush(); err != nil {
+		return nil, xerrors.ServerError(op, fmt.Errorf("%w: flushing event stream: %v", xerrors.ErrServerInternal, err))
+	}
+
+	return &EventStream{w: w, rc: rc, lastEventID: r.Header.Get("Last-Event-ID")}, nil
+}
+
+// Returns the ID of the last event the client received before reconnecting,
+// or an empty string for a new stream
+func (stream *EventStream) LastEventID() string {
+	return stream.lastEventID
+}
+
+// Writes and flushes an event
+func (stream *EventStream) Send(event Event) error {
+	if strings.ContainsAny(event.ID+event.Name, "\r\n\x00") {
+		return errInvalidEvent
+	}
+
+	var b strings.Builder
+	if event.ID != "" {
+		b.WriteString("id: " + event.ID + "\n")
+	}
+	if event.Name != "" {
+		b.WriteString("event: " + event.Name + "\n")
+	}
+	if event.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	if event.Data != nil {
+		data, ok := event.Data.(string)
+		if !ok {
+			encoded, err := json.Marshal(event.Data)
+			if err != nil {
+				return err
+			}
+			data = string(encoded)
+		}
+
+		// Each line is its own data field, joined with newlines by the browser
+		for _, line := range splitLines(data) {
+			b.WriteString("data: " + line + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	return stream.write(b.String())
+}
+
+// Writes and flushes a comment, which browsers ignore. Use it as a heartbeat
+// so proxies do not close idle streams.
+func (stream *EventStream) Comment(text string) error {
+	return stream.write(": " + strings.Join(splitLines(text), " ") + "\n\n")
+}
+
+// Sends events until the channel is closed or ctx ends, with a comment every
+// heartbeat while idle
+//
+// Pass r.Context(), which ends when the client disconnects. Returns nil
+// unless a write fails.
+func (stream *EventStream) Serve(ctx context.Context, events <-chan Event, heartbeat time.Duration) error {
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+			ticker.Reset(heartbeat)
+
+		case <-ticker.C:
+			if err := stream.Comment("heartbeat"); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Writes and flushes raw event stream text
+func (stream *EventStream) write(text string) error {
+	if _, err := stream.w.Write([]byte(text)); err != nil {
+		return err
+	}
+	return stream.rc.Flush()
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// Splits text on every line ending the event stream format recognizes: CRLF,
+// CR, and LF
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.ReplaceAll(text, "\r", "\n"), "\n")
+}
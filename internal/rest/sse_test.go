@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+)
+
+// Starts an event stream on a recorder
+func newEventStream(t *testing.T, headers map[string]string) (*EventStream, *httptest.ResponseRecorder) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rr := httptest.NewRecorder()
+
+	stream, err := rest.EventStream(rr, req, "test")
+	assert.Check(t, err == nil)
+	return stream, rr
+}
+
+func TestEventStream(t *testing.T) {
+	t.Run("Headers", func(t *testing.T) {
+		stream, rr := newEventStream(t, map[string]string{
+			"Accept":        "text/event-stream",
+			"Last-Event-ID": "42",
+		})
+
+		assert.Equal(t, rr.Code, http.StatusOK)
+		assert.Equal(t, rr.Header().Get("Content-Type"), EventStreamContentType)
+		assert.Equal(t, rr.Header().Get("Cache-Control"), "no-cache")
+		assert.True(t, rr.Flushed)
+		assert.Equal(t, stream.LastEventID(), "42")
+	})
+
+	t.Run("NotAcceptable", func(t *testing.T) {
+		rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+
+		stream, err := rest.EventStream(rr, req, "test")
+		assert.Check(t, stream == nil)
+		assert.Equal(t, err.StatusCode, http.StatusNotAcceptable)
+		assert.Equal(t, rr.Body.Len(), 0)
+	})
+
+	t.Run("Send", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			event Event
+			want  string
+		}{
+			{"Data", Event{Data: "hello"}, "data: hello\n\n"},
+			{"Fields", Event{ID: "1", Name: "user.updated", Data: "hello"}, "id: 1\nevent: user.updated\ndata: hello\n\n"},
+			{"Retry", Event{Retry: 5 * time.Second}, "retry: 5000\n\n"},
+			{"JSON", Event{Data: Envelope{"id": 1}}, "data: {\"id\":1}\n\n"},
+			{"Multiline", Event{Data: "a\nb\r\nc"}, "data: a\ndata: b\ndata: c\n\n"},
+			{"CarriageReturn", Event{Data: "a\rb\r\rc"}, "data: a\ndata: b\ndata: \ndata: c\n\n"},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				stream, rr := newEventStream(t, nil)
+				assert.Check(t, stream.Send(test.event) == nil)
+				assert.Equal(t, rr.Body.String(), test.want)
+			})
+		}
+	})
+
+	t.Run("InvalidEvent", func(t *testing.T) {
+		stream, rr := newEventStream(t, nil)
+
+		assert.Equal(t, stream.Send(Event{ID: "1\n2"}), errInvalidEvent)
+		assert.Equal(t, stream.Send(Event{Name: "a\rb"}), errInvalidEvent)
+		assert.Equal(t, rr.Body.Len(), 0)
+	})
+
+	t.Run("Comment", func(t *testing.T) {
+		stream, rr := newEventStream(t, nil)
+		assert.Check(t, stream.Comment("a\nb\rc\r\nd") == nil)
+		assert.Equal(t, rr.Body.String(), ": a b c d\n\n")
+	})
+
+	t.Run("Serve", func(t *testing.T) {
+		stream, rr := newEventStream(t, nil)
+
+		events := make(chan Event, 2)
+		events <- Event{ID: "1", Data: "a"}
+		events <- Event{ID: "2", Data: "b"}
+		close(events)
+
+		assert.Check(t, stream.Serve(context.Background(), events, time.Hour) == nil)
+		assert.Equal(t, rr.Body.String(), "id: 1\ndata: a\n\nid: 2\ndata: b\n\n")
+	})
+
+	t.Run("Heartbeat", func(t *testing.T) {
+		stream, rr := newEventStream(t, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		// Returns once the client disconnects
+		assert.Check(t, stream.Serve(ctx, make(chan Event), 10*time.Millisecond) == nil)
+		assert.True(t, strings.HasPrefix(rr.Body.String(), ": heartbeat\n\n"))
+	})
+}
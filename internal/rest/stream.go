@@ -0,0 +1,151 @@
+package rest
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Constants
+// ============================================================================
+
+// The media type of newline-delimited JSON, one value per line
+const NDJSONContentType = "application/x-ndjson"
+
+// The media types a JSONStream can write, NDJSON unless the client prefers a
+// JSON array
+var streamMediaTypes = []string{NDJSONContentType, JSONContentType}
+
+// ============================================================================
+// JSON Stream
+// ============================================================================
+
+// Scans the current row into the value written for it
+type RowScanner func(rows *sql.Rows) (any, error)
+
+// Writes a large result set row by row instead of buffering it in memory
+//
+// Rows are written as newline-delimited JSON, or as the elements of a JSON
+// array if the client only accepts application/json. The status is 200 once
+// the first byte is written, so an error partway through is written as a
+// trailing {"error": ...} record, the last line or array element, instead.
+type JSONStream struct {
+	rest      *Rest
+	w         http.ResponseWriter
+	r         *http.Request
+	op        string
+	mediaType string
+}
+
+// Negotiates the format of a JSONStream without writing anything
+//
+// Call it before running the query, so clients that accept neither format
+// receive a 406 without the work being done.
+func (rest *Rest) JSONStream(w http.ResponseWriter, r *http.Request, op string) (*JSONStream, *xerrors.AppError) {
+	mediaType, ok := negotiate(strings.Join(r.Header.Values("Accept"), ","), streamMediaTypes)
+	if !ok {
+		return nil, xerrors.ClientError(
+			http.StatusNotAcceptable,
+			"This resource can only be sent as "+strings.Join(streamMediaTypes, " or "),
+			op,
+			fmt.Errorf("%w: %q", xerrors.ErrNotAcceptable, r.Header.Get("Accept")),
+		)
+	}
+
+	return &JSONStream{rest: rest, w: w, r: r, op: op, mediaType: mediaType}, nil
+}
+
+// Writes every row, closing rows when done
+//
+// The server's write timeout is extended to the request's deadline, so routes
+// bound how long a stream may run with their timeout. Errors from scanning,
+// encoding, or the query are logged and written as the trailing error
+// record. Writes that fail because the client disconnected end the stream.
+func (stream *JSONStream) WriteRows(rows *sql.Rows, scan RowScanner) {
+	defer rows.Close()
+
+	rc := http.NewResponseController(stream.w)
+	deadline, _ := stream.r.Context().Deadline()
+	rc.SetWriteDeadline(deadline)
+
+	stream.w.Header().Set("Content-Type", stream.mediaType)
+	stream.w.Header().Add("Vary", "Accept")
+	stream.w.WriteHeader(http.StatusOK)
+
+	array := stream.mediaType == JSONContentType
+	if array {
+		if _, err := stream.w.Write([]byte("[")); err != nil {
+			return
+		}
+	}
+
+	count := 0
+	for rows.Next() {
+		value, err := scan(rows)
+		if err != nil {
+			stream.fail(xerrors.DatabaseError(err, stream.op+".Scan"), count, array)
+			return
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			stream.fail(xerrors.ServerError(stream.op+".Marshal", err), count, array)
+			return
+		}
+
+		if err := stream.writeRecord(data, count, array); err != nil {
+			return
+		}
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		stream.fail(xerrors.DatabaseError(err, stream.op+".Err"), count, array)
+		return
+	}
+
+	if array {
+		stream.w.Write([]byte("]"))
+	}
+	rc.Flush()
+}
+
+// Logs the error and ends the stream with the trailing error record
+func (stream *JSONStream) fail(err *xerrors.AppError, count int, array bool) {
+	stream.rest.Logger.ErrorContext(stream.r.Context(), err.Error())
+
+	id := stream.w.Header().Get(RequestIDHeader)
+
+	env := Envelope{"error": err.Data, "status": err.StatusCode}
+	if id != "" {
+		env["request_id"] = id
+	}
+
+	data, marshalErr := json.Marshal(env)
+	if marshalErr != nil {
+		data = []byte(`{"error":"A server error occurred"}`)
+	}
+
+	if stream.writeRecord(data, count, array) == nil && array {
+		stream.w.Write([]byte("]"))
+	}
+	http.NewResponseController(stream.w).Flush()
+}
+
+// Writes one record, after count others, as a line or array element
+func (stream *JSONStream) writeRecord(data []byte, count int, array bool) error {
+	switch {
+	case !array:
+		data = append(data, '\n')
+	case count > 0:
+		data = append([]byte(","), data...)
+	}
+
+	_, err := stream.w.Write(data)
+	return err
+}
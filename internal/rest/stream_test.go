@@ -0,0 +1,150 @@
+package rest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+)
+
+// ============================================================================
+// Rows Driver
+// ============================================================================
+
+// A database/sql driver whose queries return the rows of the query string,
+// e.g. "3" for rows 1 through 3, or "3!" to fail after them
+type rowsDriver struct{}
+
+func (rowsDriver) Open(string) (driver.Conn, error) { return rowsConn{}, nil }
+
+type rowsConn struct{}
+
+func (rowsConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (rowsConn) Close() error                        { return nil }
+func (rowsConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (rowsConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	fail := query[len(query)-1] == '!'
+	if fail {
+		query = query[:len(query)-1]
+	}
+
+	n, err := strconv.Atoi(query)
+	return &fakeRows{n: n, fail: fail}, err
+}
+
+type fakeRows struct {
+	i, n int
+	fail bool
+}
+
+func (rows *fakeRows) Columns() []string { return []string{"id"} }
+func (rows *fakeRows) Close() error      { return nil }
+
+func (rows *fakeRows) Next(dest []driver.Value) error {
+	if rows.i == rows.n {
+		if rows.fail {
+			return errors.New("connection reset")
+		}
+		return io.EOF
+	}
+
+	rows.i++
+	dest[0] = int64(rows.i)
+	return nil
+}
+
+var registerRowsDriver sync.Once
+
+// Queries the rows driver
+func queryRows(t *testing.T, query string) *sql.Rows {
+	registerRowsDriver.Do(func() { sql.Register("rows", rowsDriver{}) })
+
+	db, err := sql.Open("rows", "")
+	assert.Check(t, err == nil)
+	t.Cleanup(func() { db.Close() })
+
+	rows, err := db.Query(query)
+	assert.Check(t, err == nil)
+	return rows
+}
+
+// Scans a row into {"id": n}
+func scanID(rows *sql.Rows) (any, error) {
+	var id int64
+	if err := rows.Scan(&id); err != nil {
+		return nil, err
+	}
+	return Envelope{"id": id}, nil
+}
+
+// ============================================================================
+// Tests
+// ============================================================================
+
+func TestJSONStream(t *testing.T) {
+	rest := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name        string
+		accept      string
+		query       string
+		contentType string
+		want        string
+	}{
+		{"NDJSON", "", "2", NDJSONContentType, "{\"id\":1}\n{\"id\":2}\n"},
+		{"NDJSON/Empty", NDJSONContentType, "0", NDJSONContentType, ""},
+		{"NDJSON/Error", "*/*", "2!", NDJSONContentType, "{\"id\":1}\n{\"id\":2}\n{\"error\":\"A server error occurred\",\"status\":500}\n"},
+		{"Array", JSONContentType, "2", JSONContentType, `[{"id":1},{"id":2}]`},
+		{"Array/Empty", JSONContentType, "0", JSONContentType, `[]`},
+		{"Array/Error", JSONContentType, "1!", JSONContentType, `[{"id":1},{"error":"A server error occurred","status":500}]`},
+		{"Array/ErrorFirst", JSONContentType, "0!", JSONContentType, `[{"error":"A server error occurred","status":500}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept", test.accept)
+			rr := httptest.NewRecorder()
+
+			stream, err := rest.JSONStream(rr, req, "test")
+			assert.Check(t, err == nil)
+			stream.WriteRows(queryRows(t, test.query), scanID)
+
+			assert.Equal(t, rr.Code, http.StatusOK)
+			assert.Equal(t, rr.Header().Get("Content-Type"), test.contentType)
+			assert.Equal(t, rr.Body.String(), test.want)
+		})
+	}
+
+	t.Run("NotAcceptable", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", "text/csv")
+
+		stream, err := rest.JSONStream(httptest.NewRecorder(), req, "test")
+		assert.Check(t, stream == nil)
+		assert.Equal(t, err.StatusCode, http.StatusNotAcceptable)
+	})
+
+	t.Run("ScanError", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		rr := httptest.NewRecorder()
+		rr.Header().Set(RequestIDHeader, "abc")
+
+		stream, _ := rest.JSONStream(rr, req, "test")
+		stream.WriteRows(queryRows(t, "2"), func(rows *sql.Rows) (any, error) {
+			return nil, errors.New("bad row")
+		})
+
+		assert.Equal(t, rr.Body.String(), "{\"error\":\"A server error occurred\",\"request_id\":\"abc\",\"status\":500}\n")
+	})
+}
@@ -0,0 +1,134 @@
+package admin
+
+import (
+	"net/http"
+
+	"go-rest-starter.jtbergman.me/internal/app"
+	"go-rest-starter.jtbergman.me/internal/models/audit"
+	"go-rest-starter.jtbergman.me/internal/models/permissions"
+	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/pubsub"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/routes/middleware"
+	"go-rest-starter.jtbergman.me/internal/xlogger"
+)
+
+// ============================================================================
+// Admin Type
+// ============================================================================
+
+// Encapsulates the Application dependencies required by routes
+type Admin struct {
+	audit  audit.AuditRepository
+	events *pubsub.Hub
+	logger xlogger.Logger
+	rest   *rest.Rest
+	users  users.UsersRepository
+}
+
+func New(app *app.App) *Admin {
+	return &Admin{
+		audit:  app.Models.Audit,
+		events: app.Events,
+		logger: app.Logger,
+		rest:   app.Rest,
+		users:  app.Models.Users,
+	}
+}
+
+// ============================================================================
+// Route
+// ============================================================================
+
+// All admin routes require the admin permission
+func (admin *Admin) Route(mux *http.ServeMux, mw *middleware.Middleware) {
+	mux.HandleFunc(AuditRoute, mw.RequirePermission(permissions.PermissionAdmin, admin.Audit))
+
+	mux.HandleFunc(UsersRoute, mw.RequirePermission(permissions.PermissionAdmin, admin.Users))
+
+	mux.HandleFunc(UsersExportRoute, mw.RequirePermission(permissions.PermissionAdmin, admin.UsersExport))
+
+	mux.HandleFunc(UserRoute, mw.RequirePermission(permissions.PermissionAdmin, admin.User))
+}
+
+// ============================================================================
+// Audit Log
+// ============================================================================
+
+// Appends an event caused by the request to the audit log
+//
+// Failures are logged but never fail the request.
+func (app *Admin) record(r *http.Request, action string, actorID, subjectID int64, metadata map[string]any) {
+	event := audit.NewEvent(action, actorID, subjectID, metadata).WithRequest(r)
+
+	if err := app.audit.Insert(r.Context(), event); err != nil {
+		app.logger.ErrorContext(r.Context(), err.Error())
+	}
+}
+
+// ============================================================================
+// Audit
+// ============================================================================
+
+const AuditRoute = "/v1/admin/audit"
+
+func (app *Admin) Audit(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		app.auditGet(w, r)
+
+	default:
+		app.rest.MethodNotAllowed(w, r, "GET")
+	}
+}
+
+// ============================================================================
+// Users
+// ============================================================================
+
+const UsersRoute = "/v1/admin/users"
+
+func (app *Admin) Users(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		app.usersGet(w, r)
+
+	default:
+		app.rest.MethodNotAllowed(w, r, "GET")
+	}
+}
+
+// ============================================================================
+// Users Export
+// ============================================================================
+
+const UsersExportRoute = "/v1/admin/users/export"
+
+func (app *Admin) UsersExport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		app.usersExportGet(w, r)
+
+	default:
+		app.rest.MethodNotAllowed(w, r, "GET")
+	}
+}
+
+// ============================================================================
+// User
+// ============================================================================
+
+const UserRoute = "/v1/admin/users/{id}"
+
+func (app *Admin) User(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		app.userGet(w, r)
+
+	case "PATCH":
+		app.userPatch(w, r)
+
+	default:
+		app.rest.MethodNotAllowed(w, r, "GET, PATCH")
+	}
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"net/http"
+
+	"go-rest-starter.jtbergman.me/internal/models/audit"
+	"go-rest-starter.jtbergman.me/internal/pagination"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/validator"
+)
+
+// ============================================================================
+// GET
+// ============================================================================
+
+// Lists audit events, newest first
+//
+// Query parameters:
+//
+//	actor_id, subject_id, action, since, until, page, page_size
+func (app *Admin) auditGet(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	v := validator.New()
+	params := pagination.Parse(qs, nil, v)
+
+	// Parse filters
+	filters := audit.Filters{
+		ActorID:   int64(rest.ReadInt(qs, "actor_id", 0, v)),
+		SubjectID: int64(rest.ReadInt(qs, "subject_id", 0, v)),
+		Action:    rest.ReadString(qs, "action", ""),
+		Since:     rest.ReadTime(qs, "since", v),
+		Until:     rest.ReadTime(qs, "until", v),
+		Page:      params.Page,
+		PageSize:  params.PageSize,
+	}
+
+	// Validate filters
+	filters.Validate(v)
+	if err := v.Valid("admin.auditGet"); err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	// Get events
+	events, metadata, err := app.audit.GetAll(r.Context(), filters)
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	pagination.SetLinks(w, r, metadata)
+
+	env := rest.Envelope{"events": events, "metadata": metadata}
+	app.rest.Write(w, r, "admin.auditGet", http.StatusOK, env)
+}
@@ -0,0 +1,176 @@
+package admin
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"go-rest-starter.jtbergman.me/internal/listing"
+	"go-rest-starter.jtbergman.me/internal/models/audit"
+	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/pagination"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/routes/middleware"
+	"go-rest-starter.jtbergman.me/internal/validator"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// GET
+// ============================================================================
+
+// Lists users, newest first by default
+//
+// Query parameters:
+//
+//	sort (id, email, created_at), filter[id][op], filter[email][eq|contains],
+//	filter[activated], filter[created_at][gt|gte|lt|lte], page, page_size
+func (app *Admin) usersGet(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	v := validator.New()
+
+	// Parse and validate the query
+	params := pagination.Parse(qs, nil, v)
+	query := listing.Parse(qs, users.ListSpec, v)
+	if err := v.Valid("admin.usersGet"); err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	// Get users
+	all, metadata, err := app.users.GetAll(r.Context(), query, params)
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	pagination.SetLinks(w, r, metadata)
+
+	env := rest.Envelope{"users": all, "metadata": metadata}
+	app.rest.Write(w, r, "admin.usersGet", http.StatusOK, env)
+}
+
+// Streams every user matching the filters, newest first by default
+//
+// Query parameters are the same as usersGet, without pagination. Users are
+// written as newline-delimited JSON, or as a JSON array to clients that only
+// accept application/json.
+func (app *Admin) usersExportGet(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	v := validator.New()
+
+	// Parse and validate the query
+	query := listing.Parse(qs, users.ListSpec, v)
+	if err := v.Valid("admin.usersExportGet"); err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	// Negotiate before querying
+	stream, err := app.rest.JSONStream(w, r, "admin.usersExportGet")
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	rows, err := app.users.Export(r.Context(), query)
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	// Exports include every matching user's email
+	admin := middleware.ContextGetUser(r)
+	app.record(r, audit.ActionExport, admin.ID, 0, map[string]any{"query": r.URL.RawQuery})
+
+	stream.WriteRows(rows, func(rows *sql.Rows) (any, error) {
+		return users.Scan(rows)
+	})
+}
+
+// Gets a user, with its version as a strong ETag
+func (app *Admin) userGet(w http.ResponseWriter, r *http.Request) {
+	user, err := app.userByPath(r, "admin.userGet")
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", rest.VersionETag(user.Version))
+	app.rest.Write(w, r, "admin.userGet", http.StatusOK, rest.Envelope{"user": user})
+}
+
+// ============================================================================
+// PATCH
+// ============================================================================
+
+// The user fields admins may patch
+var userPatchFields = []string{"email", "activated"}
+
+// Updates a user's email or activation with a JSON Merge Patch or JSON Patch
+func (app *Admin) userPatch(w http.ResponseWriter, r *http.Request) {
+	admin := middleware.ContextGetUser(r)
+
+	// Get user
+	user, err := app.userByPath(r, "admin.userPatch")
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+	before := *user
+
+	// Only update the version the client read, if it sent one
+	if err := rest.CheckPreconditions(r, "admin.userPatch", rest.VersionETag(user.Version)); err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	// Apply patch
+	if err := app.rest.ReadPatch(w, r, "admin.userPatch", user, userPatchFields); err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	// Update user, which fails if it changed since it was read
+	if err := app.users.Update(r.Context(), user); err != nil {
+		err.If(xerrors.ErrUniqueViolation, func(err *xerrors.AppError) {
+			err.Data = "That email is already taken"
+		})
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	changed := []string{}
+	if user.Email != before.Email {
+		changed = append(changed, "email")
+	}
+	if user.Activated != before.Activated {
+		changed = append(changed, "activated")
+	}
+	app.record(r, audit.ActionUpdate, admin.ID, user.ID, map[string]any{"fields": changed})
+
+	// Tell the user's open event streams
+	app.events.Publish(user.ID, "user.updated", rest.Envelope{"user": user})
+
+	w.Header().Set("ETag", rest.VersionETag(user.Version))
+	app.rest.Write(w, r, "admin.userPatch", http.StatusOK, rest.Envelope{"user": user})
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// Gets the user identified by the {id} path value
+func (app *Admin) userByPath(r *http.Request, op string) (*users.User, *xerrors.AppError) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		return nil, xerrors.ClientError(
+			http.StatusNotFound,
+			"The requested resource does not exist",
+			op+".ParseInt",
+			xerrors.ErrNotFound,
+		)
+	}
+
+	return app.users.GetByID(r.Context(), id)
+}
@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"net/http"
+
+	"go-rest-starter.jtbergman.me/internal/app"
+	"go-rest-starter.jtbergman.me/internal/routes/admin"
+	"go-rest-starter.jtbergman.me/internal/routes/auth"
+	"go-rest-starter.jtbergman.me/internal/routes/middleware"
+)
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// Creates a complete Admin handler including Auth routes and traced middleware
+func adminHandler(app *app.App) http.HandlerFunc {
+	handler := func() http.Handler {
+		mux := http.NewServeMux()
+
+		middleware := middleware.New(app)
+		admin.New(app).Route(mux, middleware)
+		auth.New(app).Route(mux, middleware)
+
+		return middleware.Trace(mux, middleware.User(mux))
+	}()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}
+}
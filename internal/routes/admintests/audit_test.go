@@ -0,0 +1,110 @@
+package admin
+
+import (
+	"net/http"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/mocks"
+	"go-rest-starter.jtbergman.me/internal/models/audit"
+	"go-rest-starter.jtbergman.me/internal/pagination"
+	"go-rest-starter.jtbergman.me/internal/routes/admin"
+	"go-rest-starter.jtbergman.me/internal/routes/routestest"
+)
+
+// Helper audit events type
+type events struct {
+	Events   []audit.Event       `json:"events"`
+	Metadata pagination.Metadata `json:"metadata"`
+}
+
+func TestAudit(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	handler := adminHandler(app)
+
+	// Seed - create admin and user
+	adminToken := routestest.SeedAdmin(handler, app, "admin@example.com")
+	userToken := routestest.SeedUser(handler, app, "test@example.com")
+	assert.Check(t, adminToken != "" && userToken != "")
+
+	// Auth Required
+	assert.RunHandlerTestCase(t, handler, "GET", admin.AuditRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:   "Audit/AuthRequired",
+		Status: http.StatusUnauthorized,
+	})
+
+	// Admin Required
+	assert.RunHandlerTestCase(t, handler, "GET", admin.AuditRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:   "Audit/AdminRequired",
+		Auth:   userToken,
+		Status: http.StatusForbidden,
+	})
+
+	// Validation
+	assert.RunHandlerTestCase(t, handler, "GET", admin.AuditRoute+"?page=0&page_size=1000&since=yesterday", assert.HandlerTestCase[routestest.Failures]{
+		Name:   "Audit/Validation",
+		Auth:   adminToken,
+		Status: http.StatusUnprocessableEntity,
+		FN: func(t *testing.T, result routestest.Failures) {
+			assert.Equal(t, result.Error["page"], "must be greater than zero")
+			assert.Equal(t, result.Error["page_size"], "must be a maximum of 100")
+			assert.Equal(t, result.Error["since"], "must be an RFC 3339 timestamp")
+		},
+	})
+
+	// All events
+	assert.RunHandlerTestCase(t, handler, "GET", admin.AuditRoute, assert.HandlerTestCase[events]{
+		Name:   "Audit/All",
+		Auth:   adminToken,
+		Status: http.StatusOK,
+		FN: func(t *testing.T, result events) {
+			// register, activate, and login for both users
+			assert.Equal(t, result.Metadata.TotalRecords, 6)
+			assert.Equal(t, result.Events[0].Action, audit.ActionLogin)
+		},
+	})
+
+	// Filter and paginate
+	assert.RunHandlerTestCase(t, handler, "GET", admin.AuditRoute+"?action=user.login&page_size=1&page=2", assert.HandlerTestCase[events]{
+		Name:   "Audit/Filtered",
+		Auth:   adminToken,
+		Status: http.StatusOK,
+		ResponseHeaders: map[string]string{
+			"Link": `</v1/admin/audit?action=user.login&page=1&page_size=1>; rel="first", ` +
+				`</v1/admin/audit?action=user.login&page=1&page_size=1>; rel="prev", ` +
+				`</v1/admin/audit?action=user.login&page=2&page_size=1>; rel="last"`,
+		},
+		FN: func(t *testing.T, result events) {
+			assert.Check(t, len(result.Events) == 1)
+			assert.Equal(t, result.Events[0].Action, audit.ActionLogin)
+			assert.Equal(t, result.Metadata.TotalRecords, 2)
+			assert.Equal(t, result.Metadata.LastPage, 2)
+		},
+	})
+}
+
+func TestAuditTracing(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	handler := adminHandler(app)
+
+	// Seed - create admin
+	adminToken := routestest.SeedAdmin(handler, app, "admin@example.com")
+	assert.Check(t, adminToken != "")
+	mocks.Spans(app).Reset()
+
+	assert.RunHandlerTestCase(t, handler, "GET", admin.AuditRoute, assert.HandlerTestCase[events]{
+		Name:   "Audit/Traced",
+		Auth:   adminToken,
+		Status: http.StatusOK,
+	})
+
+	roots := mocks.Spans(app).Named("GET " + admin.AuditRoute)
+	assert.Check(t, len(roots) == 1)
+	for _, name := range []string{"users.GetByToken", "permissions.GetByID", "audit.GetAll"} {
+		spans := mocks.Spans(app).Named(name)
+		assert.Check(t, len(spans) == 1)
+		assert.Equal(t, spans[0].ParentID, roots[0].SpanID)
+	}
+}
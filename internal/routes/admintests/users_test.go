@@ -0,0 +1,321 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/mocks"
+	"go-rest-starter.jtbergman.me/internal/models/audit"
+	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/pagination"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/routes/admin"
+	"go-rest-starter.jtbergman.me/internal/routes/auth"
+	"go-rest-starter.jtbergman.me/internal/routes/routestest"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// Helper users type
+type usersList struct {
+	Users    []users.User        `json:"users"`
+	Metadata pagination.Metadata `json:"metadata"`
+}
+
+func TestUsers(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	handler := adminHandler(app)
+
+	// Seed - create admin, two active users, and an inactive user
+	adminToken := routestest.SeedAdmin(handler, app, "admin@example.com")
+	userToken := routestest.SeedUser(handler, app, "alice@example.com")
+	routestest.SeedUser(handler, app, "bob@example.com")
+	routestest.SendRequest(handler, "POST", auth.RegisterRoute, `{"email": "carol_100%@example.com", "password": "password"}`, nil, "")
+	assert.Check(t, adminToken != "" && userToken != "")
+
+	// Admin Required
+	assert.RunHandlerTestCase(t, handler, "GET", admin.UsersRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:   "Users/AdminRequired",
+		Auth:   userToken,
+		Status: http.StatusForbidden,
+	})
+
+	// Validation
+	invalid := url.Values{
+		"sort":                     {"-password"},
+		"filter[password]":         {"secret"},
+		"filter[activated]":        {"maybe"},
+		"filter[created_at][like]": {"2024-01-01T00:00:00Z"},
+	}
+	assert.RunHandlerTestCase(t, handler, "GET", admin.UsersRoute+"?"+invalid.Encode(), assert.HandlerTestCase[routestest.Failures]{
+		Name:   "Users/Validation",
+		Auth:   adminToken,
+		Status: http.StatusUnprocessableEntity,
+		FN: func(t *testing.T, result routestest.Failures) {
+			assert.Equal(t, result.Error["sort"], "cannot sort by password")
+			assert.Equal(t, result.Error["filter[password]"], "is not a filterable field")
+			assert.Equal(t, result.Error["filter[activated]"], "must be true or false")
+			assert.Equal(t, result.Error["filter[created_at][like]"], "does not support like")
+		},
+	})
+
+	// All users, most recently registered first
+	assert.RunHandlerTestCase(t, handler, "GET", admin.UsersRoute+"?sort=-id", assert.HandlerTestCase[usersList]{
+		Name:   "Users/All",
+		Auth:   adminToken,
+		Status: http.StatusOK,
+		FN: func(t *testing.T, result usersList) {
+			assert.Equal(t, result.Metadata.TotalRecords, 4)
+			assert.Equal(t, result.Users[0].Email, "carol_100%@example.com")
+		},
+	})
+
+	// Filter and sort
+	filtered := url.Values{"filter[activated]": {"true"}, "sort": {"email"}, "page_size": {"2"}}
+	assert.RunHandlerTestCase(t, handler, "GET", admin.UsersRoute+"?"+filtered.Encode(), assert.HandlerTestCase[usersList]{
+		Name:   "Users/Filtered",
+		Auth:   adminToken,
+		Status: http.StatusOK,
+		FN: func(t *testing.T, result usersList) {
+			assert.Equal(t, result.Metadata.TotalRecords, 3)
+			assert.Equal(t, result.Metadata.LastPage, 2)
+			assert.Equal(t, result.Users[0].Email, "admin@example.com")
+			assert.Equal(t, result.Users[1].Email, "alice@example.com")
+		},
+	})
+
+	// Wildcards in contains filters match literally
+	contains := url.Values{"filter[email][contains]": {"_100%"}}
+	assert.RunHandlerTestCase(t, handler, "GET", admin.UsersRoute+"?"+contains.Encode(), assert.HandlerTestCase[usersList]{
+		Name:   "Users/Contains",
+		Auth:   adminToken,
+		Status: http.StatusOK,
+		FN: func(t *testing.T, result usersList) {
+			assert.Check(t, len(result.Users) == 1)
+			assert.Equal(t, result.Users[0].Activated, false)
+		},
+	})
+}
+
+func TestUserPatch(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	handler := adminHandler(app)
+
+	// Seed - create admin and users
+	adminToken := routestest.SeedAdmin(handler, app, "admin@example.com")
+	routestest.SeedUser(handler, app, "bob@example.com")
+	routestest.SeedUser(handler, app, "alice@example.com")
+	assert.Check(t, adminToken != "")
+
+	alice, err := app.Models.Users.GetByEmail(context.Background(), "alice@example.com")
+	assert.Check(t, err == nil)
+	route := strings.Replace(admin.UserRoute, "{id}", strconv.FormatInt(alice.ID, 10), 1)
+
+	type userResult struct {
+		User users.User `json:"user"`
+	}
+
+	merge := map[string]string{"Content-Type": "application/merge-patch+json"}
+	jsonPatch := map[string]string{"Content-Type": "application/json-patch+json"}
+
+	// Merge patch
+	assert.RunHandlerTestCase(t, handler, "PATCH", route, assert.HandlerTestCase[userResult]{
+		Name:    "UserPatch/MergePatch",
+		Auth:    adminToken,
+		Body:    `{"activated": false}`,
+		Headers: merge,
+		Status:  http.StatusOK,
+		FN: func(t *testing.T, result userResult) {
+			assert.Equal(t, result.User.Email, "alice@example.com")
+			assert.Equal(t, result.User.Activated, false)
+		},
+	})
+
+	// JSON Patch
+	assert.RunHandlerTestCase(t, handler, "PATCH", route, assert.HandlerTestCase[userResult]{
+		Name:    "UserPatch/JSONPatch",
+		Auth:    adminToken,
+		Body:    `[{"op": "test", "path": "/activated", "value": false}, {"op": "replace", "path": "/email", "value": "alice2@example.com"}]`,
+		Headers: jsonPatch,
+		Status:  http.StatusOK,
+		FN: func(t *testing.T, result userResult) {
+			assert.Equal(t, result.User.Email, "alice2@example.com")
+		},
+	})
+
+	// The password is unchanged
+	updated, err := app.Models.Users.GetByEmail(context.Background(), "alice2@example.com")
+	assert.Check(t, err == nil)
+	assert.Equal(t, updated.Password, alice.Password)
+	assert.Equal(t, updated.Version, alice.Version+2)
+
+	// Fields outside the allowlist
+	assert.RunHandlerTestCase(t, handler, "PATCH", route, assert.HandlerTestCase[routestest.Failures]{
+		Name:    "UserPatch/NotAllowed",
+		Auth:    adminToken,
+		Body:    `{"id": 1, "password": "password"}`,
+		Headers: merge,
+		Status:  http.StatusUnprocessableEntity,
+		FN: func(t *testing.T, result routestest.Failures) {
+			assert.Equal(t, result.Error["id"], "cannot be changed")
+			assert.Equal(t, result.Error["password"], "cannot be changed")
+		},
+	})
+
+	// Validated after patching
+	assert.RunHandlerTestCase(t, handler, "PATCH", route, assert.HandlerTestCase[routestest.Failures]{
+		Name:    "UserPatch/Validation",
+		Auth:    adminToken,
+		Body:    `{"email": "invalid"}`,
+		Headers: merge,
+		Status:  http.StatusUnprocessableEntity,
+		FN: func(t *testing.T, result routestest.Failures) {
+			assert.Equal(t, result.Error["email"], "is invalid")
+		},
+	})
+
+	// Null would zero the field
+	assert.RunHandlerTestCase(t, handler, "PATCH", route, assert.HandlerTestCase[routestest.Failures]{
+		Name:    "UserPatch/Null",
+		Auth:    adminToken,
+		Body:    `{"activated": null}`,
+		Headers: merge,
+		Status:  http.StatusUnprocessableEntity,
+		FN: func(t *testing.T, result routestest.Failures) {
+			assert.Equal(t, result.Error["activated"], "cannot be null")
+		},
+	})
+
+	// Email taken
+	assert.RunHandlerTestCase(t, handler, "PATCH", route, assert.HandlerTestCase[routestest.Failure]{
+		Name:    "UserPatch/EmailTaken",
+		Auth:    adminToken,
+		Body:    `{"email": "bob@example.com"}`,
+		Headers: merge,
+		Status:  http.StatusConflict,
+		FN: func(t *testing.T, result routestest.Failure) {
+			assert.Equal(t, result.Error, "That email is already taken")
+		},
+	})
+
+	// Not found
+	assert.RunHandlerTestCase(t, handler, "PATCH", strings.Replace(admin.UserRoute, "{id}", "999999", 1), assert.HandlerTestCase[routestest.Failure]{
+		Name:    "UserPatch/NotFound",
+		Auth:    adminToken,
+		Body:    `{"activated": true}`,
+		Headers: merge,
+		Status:  http.StatusNotFound,
+	})
+
+	// The version is the ETag
+	etag := rest.VersionETag(updated.Version)
+	assert.RunHandlerTestCase(t, handler, "GET", route, assert.HandlerTestCase[userResult]{
+		Name:            "UserGet/ETag",
+		Auth:            adminToken,
+		Status:          http.StatusOK,
+		ResponseHeaders: map[string]string{"ETag": etag},
+		FN: func(t *testing.T, result userResult) {
+			assert.Equal(t, result.User.Email, "alice2@example.com")
+		},
+	})
+
+	// If-Match must be the current version
+	assert.RunHandlerTestCase(t, handler, "PATCH", route, assert.HandlerTestCase[routestest.Failure]{
+		Name:    "UserPatch/IfMatch/Stale",
+		Auth:    adminToken,
+		Body:    `{"activated": true}`,
+		Headers: map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": rest.VersionETag(alice.Version)},
+		Status:  http.StatusPreconditionFailed,
+	})
+	assert.RunHandlerTestCase(t, handler, "PATCH", route, assert.HandlerTestCase[userResult]{
+		Name:            "UserPatch/IfMatch",
+		Auth:            adminToken,
+		Body:            `{"activated": true}`,
+		Headers:         map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": etag},
+		Status:          http.StatusOK,
+		ResponseHeaders: map[string]string{"ETag": rest.VersionETag(updated.Version + 1)},
+		FN: func(t *testing.T, result userResult) {
+			assert.Equal(t, result.User.Activated, true)
+		},
+	})
+
+	// Stale versions conflict
+	updated, err = app.Models.Users.GetByID(context.Background(), alice.ID)
+	assert.Check(t, err == nil)
+	stale := *updated
+	stale.Activated = true
+	assert.Check(t, app.Models.Users.Update(context.Background(), updated) == nil)
+	err = app.Models.Users.Update(context.Background(), &stale)
+	assert.Check(t, err != nil && err.Matches(xerrors.ErrEditConflict))
+	assert.Equal(t, err.StatusCode, http.StatusConflict)
+}
+
+func TestUsersExport(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	handler := adminHandler(app)
+
+	// Seed - create admin, a user, and an inactive user
+	adminToken := routestest.SeedAdmin(handler, app, "admin@example.com")
+	userToken := routestest.SeedUser(handler, app, "alice@example.com")
+	routestest.SendRequest(handler, "POST", auth.RegisterRoute, `{"email": "bob@example.com", "password": "password"}`, nil, "")
+	assert.Check(t, adminToken != "" && userToken != "")
+
+	// Sends an export request and returns the response
+	export := func(token, query, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", admin.UsersExportRoute+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", accept)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Admin Required
+	rr := export(userToken, "", "")
+	assert.Equal(t, rr.Code, http.StatusForbidden)
+
+	// Validation
+	rr = export(adminToken, "?sort=-password", "")
+	assert.Equal(t, rr.Code, http.StatusUnprocessableEntity)
+
+	// Not Acceptable
+	rr = export(adminToken, "", "text/csv")
+	assert.Equal(t, rr.Code, http.StatusNotAcceptable)
+
+	// NDJSON, one user per line
+	rr = export(adminToken, "?sort=email", "")
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assert.Equal(t, rr.Header().Get("Content-Type"), rest.NDJSONContentType)
+
+	lines := strings.Split(strings.TrimSuffix(rr.Body.String(), "\n"), "\n")
+	assert.Equal(t, len(lines), 3)
+	var first users.User
+	assert.Check(t, json.Unmarshal([]byte(lines[0]), &first) == nil)
+	assert.Equal(t, first.Email, "admin@example.com")
+	assert.False(t, strings.Contains(rr.Body.String(), "password"))
+
+	// JSON array with filters
+	rr = export(adminToken, "?filter[activated]=false", rest.JSONContentType)
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assert.Equal(t, rr.Header().Get("Content-Type"), rest.JSONContentType)
+
+	var inactive []users.User
+	assert.Check(t, json.Unmarshal(rr.Body.Bytes(), &inactive) == nil)
+	assert.Equal(t, len(inactive), 1)
+	assert.Equal(t, inactive[0].Email, "bob@example.com")
+
+	// Every export is audited
+	events, _, appErr := app.Models.Audit.GetAll(context.Background(), audit.Filters{Action: audit.ActionExport, Page: 1, PageSize: 10})
+	assert.Check(t, appErr == nil)
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[0].Metadata["query"], any("filter%5Bactivated%5D=false"))
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"net/http"
 
+	"go-rest-starter.jtbergman.me/internal/models/audit"
 	"go-rest-starter.jtbergman.me/internal/models/tokens"
 	"go-rest-starter.jtbergman.me/internal/rest"
 )
@@ -18,31 +19,33 @@ func (app *Auth) activatePut(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Read activation token
-	if err := app.rest.ReadJSON(w, r, "auth.activatePut", &input); err != nil {
-		app.rest.Error(w, err)
+	if err := app.rest.Read(w, r, "auth.activatePut", &input, rest.MaxBodySize(maxBodySize)); err != nil {
+		app.rest.Error(w, r, err)
 		return
 	}
 
 	// Get user
-	user, err := app.users.GetByToken(input.Token)
+	user, err := app.users.GetByToken(r.Context(), input.Token)
 	if err != nil {
-		app.rest.Error(w, err)
+		app.rest.Error(w, r, err)
 		return
 	}
 
 	// Activate user
 	user.Activated = true
-	if err := app.users.Update(user); err != nil {
-		app.rest.Error(w, err)
+	if err := app.users.Update(r.Context(), user); err != nil {
+		app.rest.Error(w, r, err)
 		return
 	}
 
 	// Delete activation token
-	if _, err := app.tokens.Delete(input.Token, tokens.ScopeActivation); err != nil {
-		app.rest.Error(w, err)
+	if _, err := app.tokens.Delete(r.Context(), input.Token, tokens.ScopeActivation); err != nil {
+		app.rest.Error(w, r, err)
 		return
 	}
 
+	app.record(r, audit.ActionActivate, user.ID, user.ID, nil)
+
 	// Send the updated user
-	app.rest.WriteJSON(w, "auth.activatePut", http.StatusOK, rest.Envelope{"user": user})
+	app.rest.Write(w, r, "auth.activatePut", http.StatusOK, rest.Envelope{"user": user})
 }
@@ -5,8 +5,10 @@ import (
 
 	"go-rest-starter.jtbergman.me/internal/app"
 	"go-rest-starter.jtbergman.me/internal/mailer"
+	"go-rest-starter.jtbergman.me/internal/models/audit"
 	"go-rest-starter.jtbergman.me/internal/models/tokens"
 	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/pubsub"
 	"go-rest-starter.jtbergman.me/internal/rest"
 	"go-rest-starter.jtbergman.me/internal/routes/middleware"
 	"go-rest-starter.jtbergman.me/internal/xlogger"
@@ -18,7 +20,9 @@ import (
 
 // Encapsulates the Application dependencies required by routes
 type Auth struct {
+	audit  audit.AuditRepository
 	bg     app.Backgrounder
+	events *pubsub.Hub
 	logger xlogger.Logger
 	mailer mailer.Mailer
 	rest   *rest.Rest
@@ -28,7 +32,9 @@ type Auth struct {
 
 func New(app *app.App) *Auth {
 	return &Auth{
+		audit:  app.Models.Audit,
 		bg:     app.BG,
+		events: app.Events,
 		logger: app.Logger,
 		mailer: app.Mailer,
 		rest:   app.Rest,
@@ -41,6 +47,10 @@ func New(app *app.App) *Auth {
 // Route
 // ============================================================================
 
+// Auth requests carry a few short fields, so bodies are capped well below
+// rest.DefaultMaxBodySize
+const maxBodySize = 16 << 10
+
 func (auth *Auth) Route(mux *http.ServeMux, mw *middleware.Middleware) {
 	mux.HandleFunc(ActivateRoute, auth.Activate)
 
@@ -55,6 +65,21 @@ func (auth *Auth) Route(mux *http.ServeMux, mw *middleware.Middleware) {
 	mux.HandleFunc(ResetRoute, auth.Reset)
 }
 
+// ============================================================================
+// Audit
+// ============================================================================
+
+// Appends an event caused by the request to the audit log
+//
+// Failures are logged but never fail the request.
+func (app *Auth) record(r *http.Request, action string, actorID, subjectID int64, metadata map[string]any) {
+	event := audit.NewEvent(action, actorID, subjectID, metadata).WithRequest(r)
+
+	if err := app.audit.Insert(r.Context(), event); err != nil {
+		app.logger.ErrorContext(r.Context(), err.Error())
+	}
+}
+
 // ============================================================================
 // Activate
 // ============================================================================
@@ -64,7 +89,7 @@ const ActivateRoute = "/v1/auth/activate"
 func (app *Auth) Activate(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
-		http.ServeFile(w, r, "static/activate.html")
+		app.rest.WriteHTML(w, r, "auth.Activate", "static/activate.html", middleware.ContextGetNonce(r))
 
 	case "PUT":
 		app.activatePut(w, r)
@@ -147,7 +172,7 @@ const ResetRoute = "/v1/auth/reset"
 func (app *Auth) Reset(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
-		http.ServeFile(w, r, "static/reset.html")
+		app.rest.WriteHTML(w, r, "auth.Reset", "static/reset.html", middleware.ContextGetNonce(r))
 
 	case "POST":
 		app.resetPost(w, r)
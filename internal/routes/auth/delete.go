@@ -3,6 +3,7 @@ package auth
 import (
 	"net/http"
 
+	"go-rest-starter.jtbergman.me/internal/models/audit"
 	"go-rest-starter.jtbergman.me/internal/rest"
 	"go-rest-starter.jtbergman.me/internal/routes/middleware"
 	"go-rest-starter.jtbergman.me/internal/xerrors"
@@ -12,7 +13,7 @@ import (
 // POST
 // ============================================================================
 
-// Deletes an authenticated user
+// Deletes an authenticated user and ends their event streams
 //
 // Users must also provide their credentials to confirm the deletion.
 func (app *Auth) deletePost(w http.ResponseWriter, r *http.Request) {
@@ -25,46 +26,49 @@ func (app *Auth) deletePost(w http.ResponseWriter, r *http.Request) {
 	authUser := middleware.ContextGetUser(r)
 
 	// Get user from request
-	if err := app.rest.ReadJSON(w, r, "auth.deletePost", &input); err != nil {
-		app.rest.Error(w, err)
+	if err := app.rest.Read(w, r, "auth.deletePost", &input, rest.MaxBodySize(maxBodySize)); err != nil {
+		app.rest.Error(w, r, err)
 		return
 	}
 
 	// Get user from DB
-	requestUser, err := app.users.GetByEmail(input.Email)
+	requestUser, err := app.users.GetByEmail(r.Context(), input.Email)
 	if err != nil {
-		app.rest.Error(w, err)
+		app.rest.Error(w, r, err)
 		return
 	}
 
 	// Compare passwords
 	passwordIsCorrect, err := requestUser.PasswordMatches(input.Password)
 	if err != nil {
-		app.rest.Error(w, err)
+		app.rest.Error(w, r, err)
 		return
 	}
 
 	// Password is valid
 	err = xerrors.ClientUnauthorized(!passwordIsCorrect, "auth.deletePost.Password")
 	if err != nil {
-		app.rest.Error(w, err)
+		app.rest.Error(w, r, err)
 		return
 	}
 
 	// Users match
-	err = xerrors.ClientUnauthorized(authUser.ID != requestUser.ID, "auth.deletePost.ID")
+	err = xerrors.ClientForbidden(authUser.ID != requestUser.ID, "auth.deletePost.ID")
 	if err != nil {
-		app.rest.Error(w, err)
+		app.rest.Error(w, r, err)
 		return
 	}
 
 	// Delete user
-	if _, err := app.users.Delete(authUser); err != nil {
-		app.rest.Error(w, err)
+	if _, err := app.users.Delete(r.Context(), authUser); err != nil {
+		app.rest.Error(w, r, err)
 		return
 	}
 
+	app.events.CloseUser(authUser.ID)
+	app.record(r, audit.ActionDelete, authUser.ID, authUser.ID, map[string]any{"email": authUser.Email})
+
 	// Send ID
 	env := rest.Envelope{"message": "Your account has been deleted"}
-	app.rest.WriteJSON(w, "auth.deletePost", http.StatusOK, env)
+	app.rest.Write(w, r, "auth.deletePost", http.StatusOK, env)
 }
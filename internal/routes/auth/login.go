@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"go-rest-starter.jtbergman.me/internal/models/audit"
 	"go-rest-starter.jtbergman.me/internal/models/tokens"
 	"go-rest-starter.jtbergman.me/internal/rest"
 	"go-rest-starter.jtbergman.me/internal/validator"
@@ -14,81 +15,80 @@ import (
 // POST
 // ============================================================================
 
-// Validates the user-provided credentials and returns an access token if valid
-func (app *Auth) loginPost(w http.ResponseWriter, r *http.Request) {
-	var input struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-
-	// Parse request
-	if err := app.rest.ReadJSON(w, r, "auth.loginPost", &input); err != nil {
-		app.rest.Error(w, err)
-		return
-	}
+// The credentials sent to log in
+type loginInput struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
 
-	// Validate parameters
-	v := validator.New()
+func (input *loginInput) Validate(v *validator.Validator) {
 	v.Check(len(input.Email) > 0, "email", "must be provided")
 	v.Check(len(input.Password) > 0, "password", "must be provided")
-	if err := v.Valid("auth.loginPost"); err != nil {
-		app.rest.Error(w, err)
+}
+
+// Validates the user-provided credentials and returns an access token if valid
+func (app *Auth) loginPost(w http.ResponseWriter, r *http.Request) {
+	// Parse and validate request
+	input, err := rest.Decode[loginInput](app.rest, w, r, "auth.loginPost", rest.MaxBodySize(maxBodySize))
+	if err != nil {
+		app.rest.Error(w, r, err)
 		return
 	}
 
 	// Get user
-	user, err := app.users.GetByEmail(input.Email)
+	user, err := app.users.GetByEmail(r.Context(), input.Email)
 	if err != nil {
 		err.If(xerrors.ErrNotFound, func(err *xerrors.AppError) {
 			err.StatusCode = http.StatusUnauthorized
 			err.Data = "The provided credentials are invalid"
+			app.record(r, audit.ActionLoginFailed, 0, 0, map[string]any{"email": input.Email, "reason": "unknown_email"})
 		})
-		app.rest.Error(w, err)
+		app.rest.Error(w, r, err)
 		return
 	}
 
 	// Verify password
 	match, err := user.PasswordMatches(input.Password)
 	if err != nil {
-		app.rest.Error(w, err)
+		app.rest.Error(w, r, err)
 		return
 	}
 	if !match {
+		app.record(r, audit.ActionLoginFailed, 0, user.ID, map[string]any{"reason": "invalid_password"})
 		clientError := xerrors.ClientError(
 			http.StatusUnauthorized,
 			"The provided credentials are invalid",
 			"auth.loginPost",
 			xerrors.ErrUnauthenticated,
 		)
-		app.rest.Error(w, clientError)
+		app.rest.Error(w, r, clientError)
 		return
 	}
 
 	// Verify active
-	if !user.Activated {
-		clientError := xerrors.ClientError(
-			http.StatusUnauthorized,
-			"Activate your account in order to sign in",
-			"auth.loginPost",
-			xerrors.ErrUnauthorized,
-		)
-		app.rest.Error(w, clientError)
+	err = xerrors.ClientForbidden(!user.Activated, "auth.loginPost.Activated")
+	if err != nil {
+		app.record(r, audit.ActionLoginFailed, 0, user.ID, map[string]any{"reason": "not_activated"})
+		err.Data = "Activate your account in order to sign in"
+		app.rest.Error(w, r, err)
 		return
 	}
 
 	// Create token
 	token, err := app.tokens.New(user.ID, 30*24*time.Hour, tokens.ScopeAuthentication)
 	if err != nil {
-		app.rest.Error(w, err)
+		app.rest.Error(w, r, err)
 		return
 	}
 
 	// Insert token
-	if _, err := app.tokens.Insert(token); err != nil {
-		app.rest.Error(w, err)
+	if _, err := app.tokens.Insert(r.Context(), token); err != nil {
+		app.rest.Error(w, r, err)
 		return
 	}
 
+	app.record(r, audit.ActionLogin, user.ID, user.ID, nil)
+
 	// Send response
-	app.rest.WriteJSON(w, "auth.loginPost", http.StatusOK, rest.Envelope{"token": token.Plaintext})
+	app.rest.Write(w, r, "auth.loginPost", http.StatusOK, rest.Envelope{"token": token.Plaintext})
 }
@@ -3,6 +3,7 @@ package auth
 import (
 	"net/http"
 
+	"go-rest-starter.jtbergman.me/internal/models/audit"
 	"go-rest-starter.jtbergman.me/internal/models/tokens"
 	"go-rest-starter.jtbergman.me/internal/routes/middleware"
 )
@@ -11,14 +12,19 @@ import (
 // POST
 // ============================================================================
 
-// Logs the user out by deleting their access token from the tokens table
+// Logs the user out by deleting their access token from the tokens table and
+// ending their event streams
 func (app *Auth) logoutPost(w http.ResponseWriter, r *http.Request) {
+	user := middleware.ContextGetUser(r)
 	token := middleware.ContextGetToken(r)
 
-	if _, err := app.tokens.Delete(token, tokens.ScopeAuthentication); err != nil {
-		app.rest.Error(w, err)
+	if _, err := app.tokens.Delete(r.Context(), token, tokens.ScopeAuthentication); err != nil {
+		app.rest.Error(w, r, err)
 		return
 	}
 
+	app.events.CloseUser(user.ID)
+	app.record(r, audit.ActionLogout, user.ID, user.ID, nil)
+
 	w.WriteHeader(http.StatusNoContent)
 }
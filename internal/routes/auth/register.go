@@ -1,9 +1,11 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 	"time"
 
+	"go-rest-starter.jtbergman.me/internal/models/audit"
 	"go-rest-starter.jtbergman.me/internal/models/tokens"
 	"go-rest-starter.jtbergman.me/internal/rest"
 	"go-rest-starter.jtbergman.me/internal/xerrors"
@@ -21,52 +23,54 @@ func (auth *Auth) registerPost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse request
-	if err := auth.rest.ReadJSON(w, r, "auth.registerPost", &input); err != nil {
-		auth.rest.Error(w, err)
+	if err := auth.rest.Read(w, r, "auth.registerPost", &input, rest.MaxBodySize(maxBodySize)); err != nil {
+		auth.rest.Error(w, r, err)
 		return
 	}
 
 	// Create user
 	user, err := auth.users.New(input.Email, input.Password)
 	if err != nil {
-		auth.rest.Error(w, err)
+		auth.rest.Error(w, r, err)
 		return
 	}
 
 	// Insert user
-	if err := auth.users.Insert(user); err != nil {
+	if err := auth.users.Insert(r.Context(), user); err != nil {
 		err.If(xerrors.ErrUniqueViolation, func(err *xerrors.AppError) {
 			err.Data = "That email is already taken"
 		})
-		auth.rest.Error(w, err)
+		auth.rest.Error(w, r, err)
 		return
 	}
 
 	// Create activation token
 	token, err := auth.tokens.New(user.ID, 7*24*time.Hour, tokens.ScopeActivation)
 	if err != nil {
-		auth.rest.Error(w, err)
+		auth.rest.Error(w, r, err)
 		return
 	}
 
 	// Insert activation token
-	if _, err := auth.tokens.Insert(token); err != nil {
-		auth.rest.Error(w, err)
+	if _, err := auth.tokens.Insert(r.Context(), token); err != nil {
+		auth.rest.Error(w, r, err)
 		return
 	}
 
+	auth.record(r, audit.ActionRegister, user.ID, user.ID, nil)
+
 	// Send welcome email
-	auth.bg.Run(func() {
+	auth.bg.Run(r.Context(), func(ctx context.Context) {
 		data := map[string]string{
 			"activateToken": token.Plaintext,
 		}
 
-		err := auth.mailer.SendWelcomeEmail(user.Email, data)
+		err := auth.mailer.SendWelcomeEmail(ctx, user.Email, data)
 		if err != nil {
-			auth.logger.Error(err.Error())
+			auth.logger.ErrorContext(ctx, err.Error())
 		}
 	})
 
 	// Send the user response
-	auth.rest.WriteJSON(w, "auth.registerPost", http.StatusCreated, rest.Envelope{"user": user})
+	auth.rest.Write(w, r, "auth.registerPost", http.StatusCreated, rest.Envelope{"user": user})
 }
@@ -1,9 +1,11 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 	"time"
 
+	"go-rest-starter.jtbergman.me/internal/models/audit"
 	"go-rest-starter.jtbergman.me/internal/models/tokens"
 	"go-rest-starter.jtbergman.me/internal/rest"
 	"go-rest-starter.jtbergman.me/internal/validator"
@@ -21,108 +23,108 @@ func (auth *Auth) resetPost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse email
-	if err := auth.rest.ReadJSON(w, r, "auth.resetPost", &input); err != nil {
-		auth.rest.Error(w, err)
+	if err := auth.rest.Read(w, r, "auth.resetPost", &input, rest.MaxBodySize(maxBodySize)); err != nil {
+		auth.rest.Error(w, r, err)
 		return
 	}
 
 	// Get user
-	user, err := auth.users.GetByEmail(input.Email)
+	user, err := auth.users.GetByEmail(r.Context(), input.Email)
 	if err != nil {
-		auth.rest.Error(w, err)
+		auth.rest.Error(w, r, err)
 		return
 	}
 
 	// Verify active
-	err = xerrors.ClientUnauthorized(!user.Activated, "auth.resetPost")
+	err = xerrors.ClientForbidden(!user.Activated, "auth.resetPost")
 	if err != nil {
 		err.Data = "Please activate your account to reset password"
-		auth.rest.Error(w, err)
+		auth.rest.Error(w, r, err)
 		return
 	}
 
 	// Create reset token
 	token, err := auth.tokens.New(user.ID, time.Hour, tokens.ScopePasswordReset)
 	if err != nil {
-		auth.rest.Error(w, err)
+		auth.rest.Error(w, r, err)
 		return
 	}
 
 	// Insert the token into the database
-	if _, err := auth.tokens.Insert(token); err != nil {
-		auth.rest.Error(w, err)
+	if _, err := auth.tokens.Insert(r.Context(), token); err != nil {
+		auth.rest.Error(w, r, err)
 		return
 	}
 
+	auth.record(r, audit.ActionPasswordResetRequest, 0, user.ID, nil)
+
 	// Send an email to the user
-	auth.bg.Run(func() {
+	auth.bg.Run(r.Context(), func(ctx context.Context) {
 		data := map[string]string{
 			"passwordResetToken": token.Plaintext,
 		}
 
-		err := auth.mailer.SendPasswordResetEmail(user.Email, data)
+		err := auth.mailer.SendPasswordResetEmail(ctx, user.Email, data)
 		if err != nil {
-			auth.logger.Error(err.Error())
+			auth.logger.ErrorContext(ctx, err.Error())
 		}
 	})
 
 	// Notify the user their request is processing
 	env := rest.Envelope{"message": "An email will be sent with reset instructions"}
-	auth.rest.WriteJSON(w, "auth.resetPost", http.StatusAccepted, env)
+	auth.rest.Write(w, r, "auth.resetPost", http.StatusAccepted, env)
 }
 
 // ============================================================================
 // PUT
 // ============================================================================
 
-// Updates the user's password if they have a valid reset token
-func (auth *Auth) resetPut(w http.ResponseWriter, r *http.Request) {
-	var input struct {
-		Password string `json:"password"`
-		Token    string `json:"token"`
-	}
-
-	// Parse password and token
-	if err := auth.rest.ReadJSON(w, r, "auth.resetPut", &input); err != nil {
-		auth.rest.Error(w, err)
-		return
-	}
+// The new password and the reset token that authorizes it
+type resetInput struct {
+	Password string `json:"password"`
+	Token    string `json:"token"`
+}
 
-	// Validate input
-	v := validator.New()
+func (input *resetInput) Validate(v *validator.Validator) {
 	v.Check(len(input.Password) >= 8, "password", "must be at least 8 characters")
+}
 
-	// Error if invalid
-	if err := v.Valid("auth.resetPut"); err != nil {
-		auth.rest.Error(w, err)
+// Updates the user's password if they have a valid reset token
+func (auth *Auth) resetPut(w http.ResponseWriter, r *http.Request) {
+	// Parse and validate password and token
+	input, err := rest.Decode[resetInput](auth.rest, w, r, "auth.resetPut", rest.MaxBodySize(maxBodySize))
+	if err != nil {
+		auth.rest.Error(w, r, err)
 		return
 	}
 
 	// Get user
-	user, err := auth.users.GetByToken(input.Token)
+	user, err := auth.users.GetByToken(r.Context(), input.Token)
 	if err != nil {
-		auth.rest.Error(w, err)
+		auth.rest.Error(w, r, err)
 		return
 	}
 
 	// Set password
 	if err := user.SetPassword(input.Password); err != nil {
-		auth.rest.Error(w, err)
+		auth.rest.Error(w, r, err)
 		return
 	}
 
 	// Update user
-	if err := auth.users.Update(user); err != nil {
-		auth.rest.Error(w, err)
+	if err := auth.users.Update(r.Context(), user); err != nil {
+		auth.rest.Error(w, r, err)
 		return
 	}
 
 	// Delete token
-	if _, err := auth.tokens.DeleteAllForScope(user.ID, tokens.ScopePasswordReset); err != nil {
-		auth.rest.Error(w, err)
+	if _, err := auth.tokens.DeleteAllForScope(r.Context(), user.ID, tokens.ScopePasswordReset); err != nil {
+		auth.rest.Error(w, r, err)
 		return
 	}
 
+	auth.record(r, audit.ActionPasswordReset, user.ID, user.ID, nil)
+
 	env := rest.Envelope{"message": "Your password was reset successfully"}
-	auth.rest.WriteJSON(w, "auth.resetPut", http.StatusOK, env)
+	auth.rest.Write(w, r, "auth.resetPut", http.StatusOK, env)
 }
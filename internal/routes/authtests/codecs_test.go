@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/mocks"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/routes/auth"
+)
+
+// Round trips every auth route in each registered media type
+func TestAuthCodecs(t *testing.T) {
+	assert.Integration(t)
+
+	for _, mediaType := range []string{rest.JSONContentType, rest.MessagePackContentType, rest.CBORContentType} {
+		t.Run(mediaType, func(t *testing.T) {
+			app := mocks.App(t)
+			handler := authHandler(app)
+
+			// Sends body encoded as mediaType and decodes the response
+			send := func(method, route, bearer string, body map[string]any) (int, map[string]any) {
+				req := httptest.NewRequest(method, route, bytes.NewReader(encode(t, mediaType, body)))
+				req.Header.Set("Content-Type", mediaType)
+				req.Header.Set("Accept", mediaType)
+				if bearer != "" {
+					req.Header.Set("Authorization", "Bearer "+bearer)
+				}
+
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+				if rr.Code == http.StatusNoContent {
+					return rr.Code, nil
+				}
+
+				assert.Equal(t, rr.Header().Get("Content-Type"), mediaType)
+				return rr.Code, decode(t, mediaType, rr.Body.Bytes())
+			}
+			credentials := map[string]any{"email": "test@example.com", "password": "password"}
+
+			// Register
+			status, result := send("POST", auth.RegisterRoute, "", credentials)
+			assert.Equal(t, status, http.StatusCreated)
+			assert.Equal(t, result["user"].(map[string]any)["email"], "test@example.com")
+
+			// Errors use the same media type
+			status, result = send("POST", auth.RegisterRoute, "", map[string]any{"email": "invalid", "password": "password"})
+			assert.Equal(t, status, http.StatusUnprocessableEntity)
+			assert.Equal(t, result["error"].(map[string]any)["email"], "is invalid")
+
+			// Activate
+			app.BG.Wait()
+			status, result = send("PUT", auth.ActivateRoute, "", map[string]any{"token": mocks.Mailer(app).WelcomeActivationToken})
+			assert.Equal(t, status, http.StatusOK)
+			assert.Equal(t, result["user"].(map[string]any)["activated"], true)
+
+			// Login and logout
+			status, result = send("POST", auth.LoginRoute, "", credentials)
+			assert.Equal(t, status, http.StatusOK)
+			bearer, _ := result["token"].(string)
+			assert.NotEqual(t, bearer, "")
+
+			status, _ = send("POST", auth.LogoutRoute, bearer, nil)
+			assert.Equal(t, status, http.StatusNoContent)
+
+			// Reset
+			status, result = send("POST", auth.ResetRoute, "", map[string]any{"email": "test@example.com"})
+			assert.Equal(t, status, http.StatusAccepted)
+			assert.Equal(t, result["message"], "An email will be sent with reset instructions")
+
+			app.BG.Wait()
+			credentials["password"] = "pa55word"
+			status, result = send("PUT", auth.ResetRoute, "", map[string]any{"password": "pa55word", "token": mocks.Mailer(app).PasswordResetToken})
+			assert.Equal(t, status, http.StatusOK)
+			assert.Equal(t, result["message"], "Your password was reset successfully")
+
+			// Delete
+			status, result = send("POST", auth.LoginRoute, "", credentials)
+			assert.Equal(t, status, http.StatusOK)
+			bearer, _ = result["token"].(string)
+
+			status, result = send("POST", auth.DeleteRoute, bearer, credentials)
+			assert.Equal(t, status, http.StatusOK)
+			assert.Equal(t, result["message"], "Your account has been deleted")
+		})
+	}
+
+	t.Run("NotAcceptable", func(t *testing.T) {
+		app := mocks.App(t)
+		handler := authHandler(app)
+
+		req := httptest.NewRequest("POST", auth.RegisterRoute, bytes.NewBufferString(`{"email": "test@example.com", "password": "password"}`))
+		req.Header.Set("Accept", "text/html")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		// The user is not created
+		assert.Equal(t, rr.Code, http.StatusNotAcceptable)
+		_, err := app.Models.Users.GetByEmail(req.Context(), "test@example.com")
+		assert.Check(t, err != nil)
+	})
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// Encodes a request body, or nothing if body is nil
+func encode(t *testing.T, mediaType string, body map[string]any) []byte {
+	if body == nil {
+		return nil
+	}
+
+	var data []byte
+	var err error
+	switch mediaType {
+	case rest.MessagePackContentType:
+		data, err = msgpack.Marshal(body)
+	case rest.CBORContentType:
+		data, err = cbor.Marshal(body)
+	default:
+		data, err = json.Marshal(body)
+	}
+
+	assert.Check(t, err == nil)
+	return data
+}
+
+// Decodes a response body into generic maps
+func decode(t *testing.T, mediaType string, data []byte) map[string]any {
+	var result map[string]any
+	var err error
+	switch mediaType {
+	case rest.MessagePackContentType:
+		err = msgpack.Unmarshal(data, &result)
+	case rest.CBORContentType:
+		var mode cbor.DecMode
+		mode, err = cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]any(nil))}.DecMode()
+		if err == nil {
+			err = mode.Unmarshal(data, &result)
+		}
+	default:
+		err = json.Unmarshal(data, &result)
+	}
+
+	assert.Check(t, err == nil)
+	return result
+}
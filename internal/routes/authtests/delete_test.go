@@ -44,6 +44,15 @@ func TestDelete(t *testing.T) {
 		Status: http.StatusUnauthorized,
 	})
 
+	// Users Mismatch
+	assert.Check(t, registerUser(handler, `{"email": "test2@example.com", "password": "password"}`))
+	assert.RunHandlerTestCase[failure](t, handler, "POST", auth.DeleteRoute, assert.HandlerTestCase[failure]{
+		Name:   "Delete/Forbidden",
+		Body:   `{"email": "test2@example.com", "password": "password"}`,
+		Auth:   token,
+		Status: http.StatusForbidden,
+	})
+
 	// Success
 	assert.RunHandlerTestCase[message](t, handler, "POST", auth.DeleteRoute, assert.HandlerTestCase[message]{
 		Name:   "Delete/CredentialsInvalid",
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/app"
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/mocks"
+	"go-rest-starter.jtbergman.me/internal/routes/auth"
+	"go-rest-starter.jtbergman.me/internal/routes/middleware"
+)
+
+// Test retried registrations with an Idempotency-Key
+func TestRegisterIdempotency(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	handler := idempotentAuthHandler(app)
+
+	var registered user
+	headers := map[string]string{middleware.IdempotencyKeyHeader: "register-1"}
+
+	assert.RunHandlerTestCase(t, handler, "POST", auth.RegisterRoute, assert.HandlerTestCase[user]{
+		Name:    "First",
+		Body:    registerSuccessBody,
+		Headers: headers,
+		Status:  http.StatusCreated,
+		FN: func(t *testing.T, result user) {
+			registered = result
+		},
+	})
+
+	assert.RunHandlerTestCase(t, handler, "POST", auth.RegisterRoute, assert.HandlerTestCase[user]{
+		Name:    "Replay",
+		Body:    registerSuccessBody,
+		Headers: headers,
+		Status:  http.StatusCreated,
+		ResponseHeaders: map[string]string{
+			middleware.IdempotentReplayedHeader: "true",
+		},
+		FN: func(t *testing.T, result user) {
+			assert.Equal(t, result.User.ID, registered.User.ID)
+
+			app.BG.Wait()
+			assert.Equal(t, mocks.Mailer(app).WelcomeCount, 1)
+		},
+	})
+
+	assert.RunHandlerTestCase(t, handler, "POST", auth.RegisterRoute, assert.HandlerTestCase[failure]{
+		Name:    "DifferentBody",
+		Body:    `{"email": "other@example.com", "password": "password"}`,
+		Headers: headers,
+		Status:  http.StatusUnprocessableEntity,
+		FN: func(t *testing.T, result failure) {
+			assert.Equal(t, result.Error, "Idempotency-Key was already used for a different request")
+		},
+	})
+}
+
+// Creates an Auth handler that honors Idempotency-Key
+func idempotentAuthHandler(app *app.App) http.HandlerFunc {
+	mux := http.NewServeMux()
+
+	middleware := middleware.New(app)
+	auth := auth.New(app)
+	auth.Route(mux, middleware)
+
+	return middleware.User(middleware.Idempotency(mux)).ServeHTTP
+}
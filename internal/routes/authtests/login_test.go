@@ -2,6 +2,7 @@ package auth
 
 import (
 	"net/http"
+	"strings"
 	"testing"
 
 	"go-rest-starter.jtbergman.me/internal/assert"
@@ -42,6 +43,22 @@ func TestLoginValidation(t *testing.T) {
 	for _, tc := range tests {
 		assert.RunHandlerTestCase(t, handler, "POST", auth.LoginRoute, tc)
 	}
+
+	assert.RunHandlerTestCase(t, handler, "POST", auth.LoginRoute, assert.HandlerTestCase[struct{ Error string }]{
+		Name:   "Body/TooLarge",
+		Body:   `{"email": "` + strings.Repeat("a", 20_000) + `", "password": "password"}`,
+		Status: http.StatusRequestEntityTooLarge,
+		FN: func(t *testing.T, result struct{ Error string }) {
+			assert.Equal(t, result.Error, "Request body must not be larger than 16KB")
+		},
+	})
+
+	assert.RunHandlerTestCase(t, handler, "POST", auth.LoginRoute, assert.HandlerTestCase[struct{ Error string }]{
+		Name:    "ContentType/Unsupported",
+		Body:    `email=test@example.com&password=password`,
+		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		Status:  http.StatusUnsupportedMediaType,
+	})
 }
 
 // Tests error cases for login
@@ -80,7 +97,7 @@ func TestLoginUnauthorized(t *testing.T) {
 	assert.RunHandlerTestCase(t, handler, "POST", auth.LoginRoute, assert.HandlerTestCase[failure]{
 		Name:   "User/NeedsActivation",
 		Body:   credentials,
-		Status: http.StatusUnauthorized,
+		Status: http.StatusForbidden,
 		FN: func(t *testing.T, result failure) {
 			assert.Equal(t, result.Error, "Activate your account in order to sign in")
 		},
@@ -18,9 +18,28 @@ func TestLogout(t *testing.T) {
 
 	// Require Authed User
 	assert.RunHandlerTestCase(t, handler, "POST", auth.LogoutRoute, assert.HandlerTestCase[failure]{
-		Name:   "Delete/AuthRequire",
-		Body:   ``,
-		Status: http.StatusUnauthorized,
+		Name:            "Delete/AuthRequire",
+		Body:            ``,
+		Status:          http.StatusUnauthorized,
+		ResponseHeaders: map[string]string{"WWW-Authenticate": "Bearer"},
+	})
+
+	// Invalid Token
+	assert.RunHandlerTestCase(t, handler, "POST", auth.LogoutRoute, assert.HandlerTestCase[failure]{
+		Name:            "Delete/InvalidToken",
+		Auth:            "token",
+		Body:            ``,
+		Status:          http.StatusUnauthorized,
+		ResponseHeaders: map[string]string{"WWW-Authenticate": `Bearer error="invalid_token"`},
+	})
+
+	// Malformed Header
+	assert.RunHandlerTestCase(t, handler, "POST", auth.LogoutRoute, assert.HandlerTestCase[failure]{
+		Name:            "Delete/MalformedHeader",
+		Headers:         map[string]string{"Authorization": "Basic dGVzdDp0ZXN0"},
+		Body:            ``,
+		Status:          http.StatusUnauthorized,
+		ResponseHeaders: map[string]string{"WWW-Authenticate": `Bearer error="invalid_token"`},
 	})
 
 	// Seed – create user, activate user, login user
@@ -35,9 +35,9 @@ func TestReset(t *testing.T) {
 
 	// User Inactive
 	assert.RunHandlerTestCase[failure](t, handler, "POST", auth.ResetRoute, assert.HandlerTestCase[failure]{
-		Name:   "Reset/UserDNE",
+		Name:   "Reset/UserInactive",
 		Body:   `{"email": "test@example.com"}`,
-		Status: http.StatusUnauthorized,
+		Status: http.StatusForbidden,
 	})
 
 	// Seed – activate user
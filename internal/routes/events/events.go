@@ -0,0 +1,60 @@
+package events
+
+import (
+	"net/http"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/app"
+	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/pubsub"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/routes/middleware"
+	"go-rest-starter.jtbergman.me/internal/xlogger"
+)
+
+// ============================================================================
+// Events Type
+// ============================================================================
+
+// Encapsulates the Application dependencies required by routes
+type Events struct {
+	heartbeat time.Duration
+	hub       *pubsub.Hub
+	logger    xlogger.Logger
+	rest      *rest.Rest
+	users     users.UsersRepository
+}
+
+func New(app *app.App) *Events {
+	return &Events{
+		heartbeat: app.Config.Events.Heartbeat,
+		hub:       app.Events,
+		logger:    app.Logger,
+		rest:      app.Rest,
+		users:     app.Models.Users,
+	}
+}
+
+// ============================================================================
+// Route
+// ============================================================================
+
+func (events *Events) Route(mux *http.ServeMux, mw *middleware.Middleware) {
+	mux.HandleFunc(EventsRoute, mw.Authenticated(events.Events))
+}
+
+// ============================================================================
+// Events
+// ============================================================================
+
+const EventsRoute = "/v1/events"
+
+func (app *Events) Events(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		app.eventsGet(w, r)
+
+	default:
+		app.rest.MethodNotAllowed(w, r, "GET")
+	}
+}
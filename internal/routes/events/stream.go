@@ -0,0 +1,84 @@
+package events
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/pubsub"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/routes/middleware"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// How long browsers wait before reconnecting to a closed stream
+const retry = 5 * time.Second
+
+// ============================================================================
+// GET
+// ============================================================================
+
+// Streams the user's events as Server-Sent Events
+//
+// Clients that reconnect with Last-Event-ID first receive the events they
+// missed. The stream ends when the client disconnects, the server shuts
+// down, or the token the stream was opened with stops being valid.
+func (app *Events) eventsGet(w http.ResponseWriter, r *http.Request) {
+	user := middleware.ContextGetUser(r)
+	token := middleware.ContextGetToken(r)
+
+	stream, err := app.rest.EventStream(w, r, "events.eventsGet")
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	subscription := app.hub.Subscribe(user.ID, stream.LastEventID())
+	defer subscription.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go app.verify(ctx, token, user.ID, subscription)
+
+	if err := stream.Send(rest.Event{Retry: retry}); err != nil {
+		return
+	}
+
+	if err := stream.Serve(ctx, subscription.Events(), app.heartbeat); err != nil {
+		app.logger.ErrorContext(r.Context(), err.Error())
+	}
+}
+
+// Checks the token every heartbeat until ctx ends, closing the subscription
+// once the token is revoked, expires, or belongs to a deleted user
+//
+// Logout and account deletion close streams immediately, but tokens can also
+// be revoked by other processes, e.g. the admin CLI. Database errors are
+// logged and the stream is kept open.
+func (app *Events) verify(ctx context.Context, token string, userID int64, subscription *pubsub.Subscription) {
+	ticker := time.NewTicker(app.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			user, err := app.users.GetByToken(ctx, token)
+			switch {
+			case err == nil && user.ID == userID:
+				continue
+
+			case err != nil && !err.Matches(xerrors.ErrNotFound):
+				if ctx.Err() == nil {
+					app.logger.ErrorContext(ctx, err.Error())
+				}
+				continue
+			}
+
+			subscription.Close()
+			return
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/app"
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/mocks"
+	"go-rest-starter.jtbergman.me/internal/routes/admin"
+	"go-rest-starter.jtbergman.me/internal/routes/auth"
+	"go-rest-starter.jtbergman.me/internal/routes/events"
+	"go-rest-starter.jtbergman.me/internal/routes/middleware"
+	"go-rest-starter.jtbergman.me/internal/routes/routestest"
+)
+
+func TestEvents(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	handler := eventsHandler(app)
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	// Seed - an admin and a user
+	adminToken := routestest.SeedAdmin(handler, app, "admin@example.com")
+	userToken := routestest.SeedUser(handler, app, "alice@example.com")
+	alice, err := app.Models.Users.GetByToken(context.Background(), userToken)
+	assert.Check(t, adminToken != "" && err == nil)
+
+	// Authentication Required
+	assert.RunHandlerTestCase(t, handler, "GET", events.EventsRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:    "Events/AuthRequired",
+		Headers: map[string]string{"Accept": "text/event-stream"},
+		Status:  http.StatusUnauthorized,
+	})
+
+	// Not Acceptable
+	assert.RunHandlerTestCase(t, handler, "GET", events.EventsRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:    "Events/NotAcceptable",
+		Auth:    userToken,
+		Headers: map[string]string{"Accept": "application/json"},
+		Status:  http.StatusNotAcceptable,
+	})
+
+	// Method Not Allowed
+	assert.RunHandlerTestCase(t, handler, "POST", events.EventsRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:   "Events/MethodNotAllowed",
+		Auth:   userToken,
+		Status: http.StatusMethodNotAllowed,
+	})
+
+	// Admin updates are streamed to the user
+	var lastEventID string
+	t.Run("Events/Stream", func(t *testing.T) {
+		resp, next := openStream(t, server.URL, userToken, "")
+		defer resp.Body.Close()
+
+		assert.Equal(t, resp.StatusCode, http.StatusOK)
+		assert.Equal(t, resp.Header.Get("Content-Type"), "text/event-stream")
+		assert.Equal(t, next()["retry"], "5000")
+
+		patchUser(handler, adminToken, alice.ID, `{"activated": false}`)
+
+		event := next()
+		assert.Equal(t, event["event"], "user.updated")
+		assert.True(t, strings.Contains(event["data"], `"activated":false`))
+		assert.NotEqual(t, event["id"], "")
+		lastEventID = event["id"]
+	})
+
+	// Reconnecting replays missed events
+	t.Run("Events/Resume", func(t *testing.T) {
+		patchUser(handler, adminToken, alice.ID, `{"activated": true}`)
+
+		resp, next := openStream(t, server.URL, userToken, lastEventID)
+		defer resp.Body.Close()
+
+		assert.Equal(t, next()["retry"], "5000")
+		event := next()
+		assert.Equal(t, event["event"], "user.updated")
+		assert.True(t, strings.Contains(event["data"], `"activated":true`))
+	})
+
+	// Logging out ends the user's streams
+	t.Run("Events/Logout", func(t *testing.T) {
+		token := routestest.SeedUser(handler, app, "bob@example.com")
+
+		resp, next := openStream(t, server.URL, token, "")
+		defer resp.Body.Close()
+		assert.Equal(t, next()["retry"], "5000")
+
+		assert.RunHandlerTestCase(t, handler, "POST", auth.LogoutRoute, assert.HandlerTestCase[struct{}]{
+			Name:   "Logout",
+			Auth:   token,
+			Status: http.StatusNoContent,
+		})
+		assert.Equal(t, len(next()), 0)
+	})
+}
+
+func TestEventsRevoked(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	app.Config.Events.Heartbeat = 50 * time.Millisecond
+	handler := eventsHandler(app)
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	token := routestest.SeedUser(handler, app, "alice@example.com")
+	alice, err := app.Models.Users.GetByToken(context.Background(), token)
+	assert.Check(t, err == nil)
+
+	resp, next := openStream(t, server.URL, token, "")
+	defer resp.Body.Close()
+	assert.Equal(t, next()["retry"], "5000")
+
+	// Tokens revoked outside the API, e.g. by the admin CLI, end the stream at
+	// the next heartbeat
+	_, err = app.Models.Tokens.DeleteAllForUser(context.Background(), alice.ID)
+	assert.Check(t, err == nil)
+	assert.Equal(t, len(next()), 0)
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// Creates a complete Events handler including Admin and Auth routes
+func eventsHandler(app *app.App) http.HandlerFunc {
+	handler := func() http.Handler {
+		mux := http.NewServeMux()
+
+		middleware := middleware.New(app)
+		admin.New(app).Route(mux, middleware)
+		auth.New(app).Route(mux, middleware)
+		events.New(app).Route(mux, middleware)
+
+		return middleware.User(mux)
+	}()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}
+}
+
+// Opens an event stream and returns a function that reads the next event's
+// fields
+func openStream(t *testing.T, url, token, lastEventID string) (*http.Response, func() map[string]string) {
+	req, _ := http.NewRequest("GET", url+events.EventsRoute, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.Check(t, err == nil)
+
+	scanner := bufio.NewScanner(resp.Body)
+	return resp, func() map[string]string {
+		fields := map[string]string{}
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				if len(fields) > 0 {
+					return fields
+				}
+				continue
+			}
+			if name, value, ok := strings.Cut(line, ": "); ok && name != "" {
+				fields[name] = value
+			}
+		}
+		return fields
+	}
+}
+
+// Patches a user as an admin
+func patchUser(handler http.HandlerFunc, token string, id int64, body string) int {
+	route := strings.Replace(admin.UserRoute, "{id}", strconv.FormatInt(id, 10), 1)
+	req := httptest.NewRequest("PATCH", route, bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr.Code
+}
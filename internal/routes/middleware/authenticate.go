@@ -13,7 +13,7 @@ func (mw *Middleware) Authenticated(next http.HandlerFunc) http.HandlerFunc {
 
 		err := xerrors.ClientUnauthorized(user.IsAnonymous(), "middleware.Authenticated")
 		if err != nil {
-			mw.rest.Error(w, err)
+			mw.rest.Error(w, r, err)
 			return
 		}
 
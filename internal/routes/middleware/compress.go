@@ -0,0 +1,343 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// ============================================================================
+// Configuration
+// ============================================================================
+
+// The content types worth compressing. Images, archives, and other formats
+// that are already compressed are left alone.
+var compressibleTypes = map[string]bool{
+	"application/javascript":   true,
+	"application/json":         true,
+	"application/problem+json": true,
+	"application/x-ndjson":     true,
+	"image/svg+xml":            true,
+	"text/css":                 true,
+	"text/csv":                 true,
+	"text/html":                true,
+	"text/plain":               true,
+}
+
+// The supported encodings in order of preference
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+// Resets a pooled compressor to write to w
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Pooled compressors, since each allocates large internal buffers. Brotli
+// uses level 4, which compresses better than gzip at a similar speed.
+var encoders = map[string]*sync.Pool{
+	encodingBrotli: {New: func() any { return brotli.NewWriterLevel(io.Discard, 4) }},
+	encodingGzip:   {New: func() any { return gzip.NewWriter(io.Discard) }},
+}
+
+// ============================================================================
+// Middleware
+// ============================================================================
+
+// Compresses responses with brotli or gzip as negotiated by Accept-Encoding
+//
+// Bodies are buffered until minSize bytes are written, so small responses
+// are sent as is. Only compressible content types are compressed, and
+// responses that already have a Content-Encoding are skipped, as are
+// streaming responses that flush before reaching minSize. Compressed
+// responses drop Content-Length, and compressible responses include
+// "Vary: Accept-Encoding".
+//
+// Each encoding is a different representation, so a strong ETag on a
+// compressed response gets the encoding as a suffix, e.g. "3-gzip". The
+// suffix is removed from If-Match and If-None-Match before the request is
+// handled, so handlers and Conditional only see the ETags they set.
+func (mw *Middleware) Compress(minSize int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if r.Method == http.MethodHead {
+			encoding = ""
+		}
+
+		ifNoneMatch := strings.Join(r.Header.Values("If-None-Match"), ",")
+		for _, name := range []string{"If-Match", "If-None-Match"} {
+			if values := r.Header.Values(name); len(values) > 0 {
+				r.Header.Set(name, identityETags(strings.Join(values, ",")))
+			}
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: w,
+			encoding:       encoding,
+			ifNoneMatch:    ifNoneMatch,
+			minSize:        minSize,
+			status:         http.StatusOK,
+		}
+		defer cw.close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// ============================================================================
+// Compress Writer
+// ============================================================================
+
+// Buffers the start of a response to decide whether to compress it
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	ifNoneMatch string
+	minSize     int
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         []byte
+	enc         encoder
+}
+
+// Records the status code, which is written once compression is decided
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+
+	// Informational responses are sent immediately
+	if status >= 100 && status < 200 {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	cw.status = status
+	cw.wroteHeader = true
+}
+
+// Buffers writes until minSize is reached, then writes through
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < cw.minSize {
+			return len(b), nil
+		}
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if cw.enc != nil {
+		return cw.enc.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flushes buffered data. A response that flushes before compression is
+// decided is streaming, so it is sent uncompressed.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.encoding = ""
+		if !cw.wroteHeader {
+			cw.WriteHeader(http.StatusOK)
+		}
+		cw.decide()
+	}
+
+	if cw.enc != nil {
+		cw.enc.Flush()
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Takes over the connection if the underlying writer supports it
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		cw.decided = true
+		return hijacker.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Returns the original writer for http.ResponseController
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Chooses whether to compress, writes the header, and writes the buffer
+func (cw *compressWriter) decide() error {
+	cw.decided = true
+	header := cw.ResponseWriter.Header()
+
+	compressible := cw.compressible()
+	if compressible {
+		header.Add("Vary", "Accept-Encoding")
+	}
+
+	if compressible && cw.encoding != "" && len(cw.buf) > 0 && len(cw.buf) >= cw.minSize {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		if etag := header.Get("ETag"); etag != "" {
+			header.Set("ETag", encodingETag(etag, cw.encoding))
+		}
+
+		cw.enc = encoders[cw.encoding].Get().(encoder)
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+
+	// A 304 confirms the representation the client has, so it repeats the
+	// compressed ETag the client sent
+	if etag := header.Get("ETag"); cw.status == http.StatusNotModified && cw.encoding != "" && etag != "" {
+		if encoded := encodingETag(etag, cw.encoding); strings.Contains(cw.ifNoneMatch, encoded) {
+			header.Set("ETag", encoded)
+		}
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.enc != nil {
+		_, err := cw.enc.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// Returns true if the response may be compressed
+func (cw *compressWriter) compressible() bool {
+	header := cw.ResponseWriter.Header()
+
+	switch {
+	case cw.status < 200, cw.status == http.StatusNoContent, cw.status == http.StatusNotModified:
+		return false
+
+	case header.Get("Content-Encoding") != "", header.Get("Content-Range") != "":
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	return err == nil && compressibleTypes[mediaType]
+}
+
+// Writes anything still buffered and returns the compressor to its pool
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if !cw.wroteHeader && len(cw.buf) == 0 {
+			// Nothing was written, so let net/http send the default response
+			cw.decided = true
+			return
+		}
+		cw.decide()
+	}
+
+	if cw.enc != nil {
+		cw.enc.Close()
+		cw.enc.Reset(io.Discard)
+		encoders[cw.encoding].Put(cw.enc)
+		cw.enc = nil
+	}
+}
+
+// ============================================================================
+// Helper
+// ============================================================================
+
+// Returns the ETag of a response compressed with the encoding. Weak ETags
+// are unchanged since every encoding is semantically equivalent.
+func encodingETag(etag string, encoding string) string {
+	if strings.HasPrefix(etag, "W/") || !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`
+}
+
+// Removes the encoding from the ETags in an If-Match or If-None-Match header,
+// e.g. "3-gzip" becomes "3"
+func identityETags(header string) string {
+	candidates := strings.Split(header, ",")
+	for i, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		for encoding := range encoders {
+			if base, ok := strings.CutSuffix(candidate, "-"+encoding+`"`); ok {
+				candidate = base + `"`
+				break
+			}
+		}
+		candidates[i] = candidate
+	}
+
+	return strings.Join(candidates, ",")
+}
+
+// Returns the preferred supported encoding in an Accept-Encoding header, or
+// an empty string if the response should not be compressed
+//
+// Encodings are ranked by q-value, and brotli is preferred over gzip when
+// they are equal. A "*" matches any encoding not listed explicitly.
+func negotiateEncoding(accept string) string {
+	if accept == "" {
+		return ""
+	}
+
+	qualities := map[string]float64{}
+	wildcard := -1.0
+
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		if name == "*" {
+			wildcard = q
+		} else {
+			qualities[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range []string{encodingBrotli, encodingGzip} {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"go-rest-starter.jtbergman.me/internal/assert"
+)
+
+// Sends a request through Compress to a handler that writes body with the
+// given content type
+func compressRequest(accept, contentType, body string, flush bool) *httptest.ResponseRecorder {
+	mw := &Middleware{}
+	handler := mw.Compress(100, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", "999")
+		if flush {
+			w.(http.Flusher).Flush()
+		}
+		io.WriteString(w, body)
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", accept)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCompress(t *testing.T) {
+	large := `{"data": "` + strings.Repeat("a", 1000) + `"}`
+
+	t.Run("Gzip", func(t *testing.T) {
+		rr := compressRequest("gzip, deflate", "application/json; charset=utf-8", large, false)
+		assert.Equal(t, rr.Header().Get("Content-Encoding"), "gzip")
+		assert.Equal(t, rr.Header().Get("Content-Length"), "")
+		assert.Equal(t, rr.Header().Get("Vary"), "Accept-Encoding")
+		assert.Check(t, rr.Body.Len() < len(large))
+
+		gz, err := gzip.NewReader(rr.Body)
+		assert.Check(t, err == nil)
+		body, _ := io.ReadAll(gz)
+		assert.Equal(t, string(body), large)
+	})
+
+	t.Run("Brotli", func(t *testing.T) {
+		rr := compressRequest("gzip, br", "application/json", large, false)
+		assert.Equal(t, rr.Header().Get("Content-Encoding"), "br")
+
+		body, _ := io.ReadAll(brotli.NewReader(rr.Body))
+		assert.Equal(t, string(body), large)
+	})
+
+	t.Run("Small", func(t *testing.T) {
+		rr := compressRequest("gzip", "application/json", `{}`, false)
+		assert.Equal(t, rr.Header().Get("Content-Encoding"), "")
+		assert.Equal(t, rr.Header().Get("Vary"), "Accept-Encoding")
+		assert.Equal(t, rr.Body.String(), `{}`)
+	})
+
+	t.Run("NotAccepted", func(t *testing.T) {
+		rr := compressRequest("", "application/json", large, false)
+		assert.Equal(t, rr.Header().Get("Content-Encoding"), "")
+		assert.Equal(t, rr.Body.String(), large)
+	})
+
+	t.Run("ContentType", func(t *testing.T) {
+		rr := compressRequest("gzip", "image/png", large, false)
+		assert.Equal(t, rr.Header().Get("Content-Encoding"), "")
+		assert.Equal(t, rr.Header().Get("Vary"), "")
+		assert.Equal(t, rr.Header().Get("Content-Length"), "999")
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		rr := compressRequest("gzip", "application/json", large, true)
+		assert.Equal(t, rr.Header().Get("Content-Encoding"), "")
+		assert.Equal(t, rr.Body.String(), large)
+	})
+}
+
+func TestCompressETag(t *testing.T) {
+	large := `{"data": "` + strings.Repeat("a", 1000) + `"}`
+
+	// Sends a request through Compress and Conditional to a handler that
+	// writes body with the ETag, returning the response and the If-Match
+	// header the handler received
+	send := func(etag, body string, headers map[string]string) (*httptest.ResponseRecorder, string) {
+		var ifMatch string
+		mw := &Middleware{}
+		handler := mw.Compress(100, mw.Conditional(Preconditions{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ifMatch = r.Header.Get("If-Match")
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", etag)
+			io.WriteString(w, body)
+		})))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr, ifMatch
+	}
+
+	t.Run("Strong", func(t *testing.T) {
+		rr, _ := send(`"3"`, large, map[string]string{"Accept-Encoding": "gzip"})
+		assert.Equal(t, rr.Header().Get("Content-Encoding"), "gzip")
+		assert.Equal(t, rr.Header().Get("ETag"), `"3-gzip"`)
+
+		rr, _ = send(`"3"`, large, map[string]string{"Accept-Encoding": "br"})
+		assert.Equal(t, rr.Header().Get("ETag"), `"3-br"`)
+	})
+
+	t.Run("Weak", func(t *testing.T) {
+		rr, _ := send(`W/"abc"`, large, map[string]string{"Accept-Encoding": "gzip"})
+		assert.Equal(t, rr.Header().Get("Content-Encoding"), "gzip")
+		assert.Equal(t, rr.Header().Get("ETag"), `W/"abc"`)
+	})
+
+	t.Run("Identity", func(t *testing.T) {
+		rr, _ := send(`"3"`, `{}`, map[string]string{"Accept-Encoding": "gzip"})
+		assert.Equal(t, rr.Header().Get("Content-Encoding"), "")
+		assert.Equal(t, rr.Header().Get("ETag"), `"3"`)
+	})
+
+	t.Run("IfMatch", func(t *testing.T) {
+		_, ifMatch := send(`"3"`, large, map[string]string{"If-Match": `"3-gzip", "4+cbor-br", W/"abc"`})
+		assert.Equal(t, ifMatch, `"3","4+cbor",W/"abc"`)
+	})
+
+	t.Run("NotModified", func(t *testing.T) {
+		rr, _ := send(`"3"`, large, map[string]string{"Accept-Encoding": "gzip", "If-None-Match": `"3-gzip"`})
+		assert.Equal(t, rr.Code, http.StatusNotModified)
+		assert.Equal(t, rr.Header().Get("ETag"), `"3-gzip"`)
+		assert.Equal(t, rr.Body.Len(), 0)
+
+		rr, _ = send(`"3"`, large, map[string]string{"Accept-Encoding": "gzip", "If-None-Match": `"2-gzip"`})
+		assert.Equal(t, rr.Code, http.StatusOK)
+	})
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"identity":                "",
+		"gzip":                    "gzip",
+		"br, gzip":                "br",
+		"gzip;q=1.0, br;q=0.5":    "gzip",
+		"br;q=0, gzip":            "gzip",
+		"*":                       "br",
+		"*;q=0.1, gzip;q=0.5":     "gzip",
+		"GZIP":                    "gzip",
+		"gzip;q=0, br;q=0, *;q=1": "",
+	}
+
+	for accept, expected := range tests {
+		assert.Equal(t, negotiateEncoding(accept), expected)
+	}
+}
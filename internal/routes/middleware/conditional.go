@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Configuration
+// ============================================================================
+
+// Configures which routes served by Mux require If-Match
+//
+// Routes are keyed by the pattern they were registered with, e.g.
+// admin.UserRoute. Unsafe requests to a route set to true must send
+// If-Match, so clients cannot overwrite changes they have not seen.
+type Preconditions struct {
+	Mux    *http.ServeMux
+	Routes map[string]bool
+}
+
+// Returns true if the request must send If-Match
+func (preconditions Preconditions) required(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+
+	_, pattern := preconditions.Mux.Handler(r)
+	return preconditions.Routes[pattern]
+}
+
+// ============================================================================
+// Middleware
+// ============================================================================
+
+// Handles conditional requests
+//
+// GET and HEAD requests whose If-None-Match lists the ETag of a 200 response
+// receive a 304 without a body. Unsafe requests to routes that require
+// preconditions receive a 428 without If-Match. Handlers evaluate If-Match
+// against the current resource with rest.CheckPreconditions.
+func (mw *Middleware) Conditional(preconditions Preconditions, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if preconditions.required(r) && r.Header.Get("If-Match") == "" {
+			clientError := xerrors.ClientError(
+				http.StatusPreconditionRequired,
+				"This request must include an If-Match header",
+				"middleware.Conditional",
+				xerrors.ErrPreconditionRequired,
+			)
+			mw.rest.Error(w, r, clientError)
+			return
+		}
+
+		ifNoneMatch := strings.Join(r.Header.Values("If-None-Match"), ",")
+		if ifNoneMatch == "" || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(&conditionalWriter{ResponseWriter: w, ifNoneMatch: ifNoneMatch}, r)
+	})
+}
+
+// ============================================================================
+// Response Writer
+// ============================================================================
+
+// Replaces a 200 response with a 304 when its ETag matches If-None-Match
+type conditionalWriter struct {
+	http.ResponseWriter
+	ifNoneMatch string
+	notModified bool
+	wroteHeader bool
+}
+
+// Checks the ETag the handler set before writing the status
+func (cw *conditionalWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+
+	header := cw.ResponseWriter.Header()
+	if status == http.StatusOK && rest.MatchETag(cw.ifNoneMatch, header.Get("ETag"), true) {
+		cw.notModified = true
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		cw.ResponseWriter.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+// Discards the body of a 304
+func (cw *conditionalWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.notModified {
+		return len(b), nil
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flushes buffered data if the underlying writer supports it
+func (cw *conditionalWriter) Flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Returns the original writer for http.ResponseController
+func (cw *conditionalWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
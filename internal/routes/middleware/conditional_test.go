@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/rest"
+)
+
+func TestConditional(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mw := &Middleware{logger: logger, rest: rest.New(logger)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/resource", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Header().Set("ETag", rest.VersionETag(3))
+		}
+		mw.rest.Write(w, r, "test", http.StatusOK, rest.Envelope{"version": 3})
+	})
+	mux.HandleFunc("/content", func(w http.ResponseWriter, r *http.Request) {
+		mw.rest.Write(w, r, "test", http.StatusOK, rest.Envelope{"content": true})
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", rest.VersionETag(3))
+		mw.rest.Write(w, r, "test", http.StatusNotFound, rest.Envelope{"error": "missing"})
+	})
+
+	handler := mw.Conditional(Preconditions{
+		Mux:    mux,
+		Routes: map[string]bool{"/resource": true},
+	}, mux)
+
+	type result struct{ Version int }
+	tests := []struct {
+		name    string
+		method  string
+		url     string
+		headers map[string]string
+		status  int
+	}{
+		{name: "NotModified", method: "GET", url: "/resource", headers: map[string]string{"If-None-Match": `"3"`}, status: http.StatusNotModified},
+		{name: "NotModified/Weak", method: "GET", url: "/resource", headers: map[string]string{"If-None-Match": `"1", W/"3"`}, status: http.StatusNotModified},
+		{name: "NotModified/Wildcard", method: "HEAD", url: "/resource", headers: map[string]string{"If-None-Match": `*`}, status: http.StatusNotModified},
+		{name: "Modified", method: "GET", url: "/resource", headers: map[string]string{"If-None-Match": `"2"`}, status: http.StatusOK},
+		{name: "Errors", method: "GET", url: "/missing", headers: map[string]string{"If-None-Match": `"3"`}, status: http.StatusNotFound},
+		{name: "Required", method: "PATCH", url: "/resource", status: http.StatusPreconditionRequired},
+		{name: "Required/Sent", method: "PATCH", url: "/resource", headers: map[string]string{"If-Match": `"3"`}, status: http.StatusOK},
+		{name: "Required/Safe", method: "GET", url: "/resource", status: http.StatusOK},
+		{name: "NotRequired", method: "PATCH", url: "/content", status: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		assert.RunHandlerTestCase(t, handler.ServeHTTP, test.method, test.url, assert.HandlerTestCase[result]{
+			Name:    test.name,
+			Headers: test.headers,
+			Status:  test.status,
+		})
+	}
+
+	// A 304 keeps the ETag but has no body
+	assert.RunHandlerTestCase(t, handler.ServeHTTP, "GET", "/resource", assert.HandlerTestCase[result]{
+		Name:            "NotModified/Headers",
+		Headers:         map[string]string{"If-None-Match": `"3"`},
+		Status:          http.StatusNotModified,
+		ResponseHeaders: map[string]string{"ETag": `"3"`, "Content-Type": ""},
+	})
+
+	// Content hashes are matched without the handler knowing about them
+	var etag string
+	assert.RunHandlerTestCase(t, func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+		etag = w.Header().Get("ETag")
+	}, "GET", "/content", assert.HandlerTestCase[result]{
+		Name:   "Content",
+		Status: http.StatusOK,
+	})
+	assert.RunHandlerTestCase(t, handler.ServeHTTP, "GET", "/content", assert.HandlerTestCase[result]{
+		Name:    "Content/NotModified",
+		Headers: map[string]string{"If-None-Match": etag},
+		Status:  http.StatusNotModified,
+	})
+}
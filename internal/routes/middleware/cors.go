@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/config"
+)
+
+// ============================================================================
+// Configuration
+// ============================================================================
+
+// The allowed cross-origin requests, normalized from config.Config
+type cors struct {
+	origins          []string
+	methods          []string
+	headers          map[string]bool
+	allowHeaders     string
+	allowMethods     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+// Creates the CORS configuration. CORS is disabled if no origins are allowed.
+func newCORS(cfg config.Config) cors {
+	headers := map[string]bool{}
+	for _, header := range cfg.CORS.AllowedHeaders {
+		headers[http.CanonicalHeaderKey(header)] = true
+	}
+
+	maxAge := ""
+	if cfg.CORS.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(cfg.CORS.MaxAge / time.Second))
+	}
+
+	return cors{
+		origins:          cfg.CORS.AllowedOrigins,
+		methods:          cfg.CORS.AllowedMethods,
+		headers:          headers,
+		allowHeaders:     strings.Join(cfg.CORS.AllowedHeaders, ", "),
+		allowMethods:     strings.Join(cfg.CORS.AllowedMethods, ", "),
+		exposeHeaders:    strings.Join(cfg.CORS.ExposedHeaders, ", "),
+		allowCredentials: cfg.CORS.AllowCredentials,
+		maxAge:           maxAge,
+	}
+}
+
+// Returns the value for Access-Control-Allow-Origin, or an empty string if
+// the origin is not allowed
+func (c cors) allowOrigin(origin string) string {
+	origin = strings.ToLower(origin)
+
+	for _, allowed := range c.origins {
+		switch {
+		case allowed == "*":
+			return "*"
+
+		case allowed == origin:
+			return origin
+
+		case matchWildcardOrigin(allowed, origin):
+			return origin
+		}
+	}
+
+	return ""
+}
+
+// Returns true if the method may be used in a cross-origin request
+func (c cors) allowMethod(method string) bool {
+	for _, allowed := range c.methods {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if every header in an Access-Control-Request-Headers value
+// may be sent in a cross-origin request
+func (c cors) allowRequestHeaders(requested string) bool {
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !c.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================================
+// Middleware
+// ============================================================================
+
+// Adds CORS headers for allowed origins and answers preflight requests
+//
+// Origins come from the cors-allowed-origins flag, and "https://*.example.com"
+// matches any subdomain of example.com (but not example.com itself).
+// Responses to requests with an Origin header include "Vary: Origin" so
+// caches keep the responses for each origin apart.
+//
+// Preflight requests are answered with a 204 without calling next. This must
+// run before User so preflights, which never carry credentials, skip
+// authentication and rate limits and are not answered with a 405 by route
+// handlers. If the origin, method, or headers are not allowed, the preflight
+// receives no CORS headers and the browser blocks the request.
+func (mw *Middleware) CORS(next http.Handler) http.Handler {
+	if len(mw.cors.origins) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		allowOrigin := mw.cors.allowOrigin(origin)
+
+		// Preflight
+		requestMethod := r.Header.Get("Access-Control-Request-Method")
+		if r.Method == http.MethodOptions && requestMethod != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+			requestHeaders := r.Header.Get("Access-Control-Request-Headers")
+			if allowOrigin != "" && mw.cors.allowMethod(requestMethod) && mw.cors.allowRequestHeaders(requestHeaders) {
+				mw.cors.setOrigin(w, allowOrigin)
+				w.Header().Set("Access-Control-Allow-Methods", mw.cors.allowMethods)
+				if mw.cors.allowHeaders != "" {
+					w.Header().Set("Access-Control-Allow-Headers", mw.cors.allowHeaders)
+				}
+				if mw.cors.maxAge != "" {
+					w.Header().Set("Access-Control-Max-Age", mw.cors.maxAge)
+				}
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		// Actual request
+		if allowOrigin != "" {
+			mw.cors.setOrigin(w, allowOrigin)
+			if mw.cors.exposeHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", mw.cors.exposeHeaders)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ============================================================================
+// Helper
+// ============================================================================
+
+// Sets the allowed origin and credentials headers
+func (c cors) setOrigin(w http.ResponseWriter, origin string) {
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if c.allowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// Returns true if origin matches a pattern like "https://*.example.com",
+// i.e. the scheme and suffix match and the wildcard covers one or more
+// subdomain labels
+func matchWildcardOrigin(pattern, origin string) bool {
+	prefix, suffix, ok := strings.Cut(pattern, "*")
+	if !ok || len(origin) <= len(prefix)+len(suffix) {
+		return false
+	}
+	if !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+
+	subdomain := origin[len(prefix) : len(origin)-len(suffix)]
+	for _, c := range subdomain {
+		isLabel := c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '.'
+		if !isLabel {
+			return false
+		}
+	}
+
+	return !strings.HasPrefix(subdomain, ".") && !strings.HasSuffix(subdomain, ".")
+}
@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/config"
+)
+
+// Creates a CORS handler in front of a handler that responds with 200
+func corsHandler(origins []string, credentials bool) http.Handler {
+	cfg := config.Config{}
+	cfg.CORS.AllowedOrigins = origins
+	cfg.CORS.AllowedMethods = []string{"GET", "POST", "DELETE"}
+	cfg.CORS.AllowedHeaders = []string{"Authorization", "Content-Type"}
+	cfg.CORS.ExposedHeaders = []string{"X-Request-ID"}
+	cfg.CORS.AllowCredentials = credentials
+	cfg.CORS.MaxAge = 10 * time.Minute
+
+	mw := &Middleware{cors: newCORS(cfg)}
+	return mw.CORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+// Sends a request with the given headers
+func corsRequest(handler http.Handler, method string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/v1/organizations", nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCORS(t *testing.T) {
+	handler := corsHandler([]string{"https://app.example.com", "https://*.example.org"}, true)
+
+	t.Run("NoOrigin", func(t *testing.T) {
+		rr := corsRequest(handler, "GET", nil)
+		assert.Equal(t, rr.Code, http.StatusOK)
+		assert.Equal(t, rr.Header().Get("Vary"), "")
+		assert.Equal(t, rr.Header().Get("Access-Control-Allow-Origin"), "")
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		rr := corsRequest(handler, "GET", map[string]string{"Origin": "https://app.example.com"})
+		assert.Equal(t, rr.Code, http.StatusOK)
+		assert.Equal(t, rr.Header().Get("Vary"), "Origin")
+		assert.Equal(t, rr.Header().Get("Access-Control-Allow-Origin"), "https://app.example.com")
+		assert.Equal(t, rr.Header().Get("Access-Control-Allow-Credentials"), "true")
+		assert.Equal(t, rr.Header().Get("Access-Control-Expose-Headers"), "X-Request-ID")
+	})
+
+	t.Run("Denied", func(t *testing.T) {
+		rr := corsRequest(handler, "GET", map[string]string{"Origin": "https://evil.com"})
+		assert.Equal(t, rr.Code, http.StatusOK)
+		assert.Equal(t, rr.Header().Get("Vary"), "Origin")
+		assert.Equal(t, rr.Header().Get("Access-Control-Allow-Origin"), "")
+	})
+
+	t.Run("Preflight", func(t *testing.T) {
+		rr := corsRequest(handler, "OPTIONS", map[string]string{
+			"Origin":                         "https://api.staging.example.org",
+			"Access-Control-Request-Method":  "DELETE",
+			"Access-Control-Request-Headers": "authorization, content-type",
+		})
+		assert.Equal(t, rr.Code, http.StatusNoContent)
+		assert.Equal(t, rr.Header().Get("Access-Control-Allow-Origin"), "https://api.staging.example.org")
+		assert.Equal(t, rr.Header().Get("Access-Control-Allow-Methods"), "GET, POST, DELETE")
+		assert.Equal(t, rr.Header().Get("Access-Control-Allow-Headers"), "Authorization, Content-Type")
+		assert.Equal(t, rr.Header().Get("Access-Control-Max-Age"), "600")
+		assert.Equal(t, len(rr.Header().Values("Vary")), 3)
+	})
+
+	t.Run("PreflightDenied", func(t *testing.T) {
+		tests := map[string]map[string]string{
+			"Origin": {
+				"Origin":                        "https://example.org",
+				"Access-Control-Request-Method": "GET",
+			},
+			"Method": {
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "PATCH",
+			},
+			"Headers": {
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "X-Secret",
+			},
+		}
+
+		for name, headers := range tests {
+			t.Run(name, func(t *testing.T) {
+				rr := corsRequest(handler, "OPTIONS", headers)
+				assert.Equal(t, rr.Code, http.StatusNoContent)
+				assert.Equal(t, rr.Header().Get("Access-Control-Allow-Origin"), "")
+				assert.Equal(t, rr.Header().Get("Access-Control-Allow-Methods"), "")
+			})
+		}
+	})
+
+	t.Run("Any", func(t *testing.T) {
+		handler := corsHandler([]string{"*"}, false)
+		rr := corsRequest(handler, "GET", map[string]string{"Origin": "https://anywhere.com"})
+		assert.Equal(t, rr.Header().Get("Access-Control-Allow-Origin"), "*")
+		assert.Equal(t, rr.Header().Get("Access-Control-Allow-Credentials"), "")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		mw := &Middleware{}
+		next := http.NewServeMux()
+		assert.True(t, mw.CORS(next) == http.Handler(next))
+	})
+}
+
+func TestCORSDefaults(t *testing.T) {
+	cfg := config.Config{}
+	cfg.CORS.AllowedOrigins = []string{"https://app.example.com"}
+	cfg.CORS.AllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	cfg.CORS.AllowedHeaders = config.DefaultCORSAllowedHeaders
+	cfg.CORS.ExposedHeaders = config.DefaultCORSExposedHeaders
+
+	mw := &Middleware{cors: newCORS(cfg)}
+	handler := mw.CORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Admin user updates require If-Match, so cross-origin clients must be
+	// able to send it and read the ETag it comes from
+	t.Run("PreflightIfMatch", func(t *testing.T) {
+		rr := corsRequest(handler, "OPTIONS", map[string]string{
+			"Origin":                         "https://app.example.com",
+			"Access-Control-Request-Method":  "PATCH",
+			"Access-Control-Request-Headers": "authorization, content-type, if-match",
+		})
+		assert.Equal(t, rr.Code, http.StatusNoContent)
+		assert.Equal(t, rr.Header().Get("Access-Control-Allow-Origin"), "https://app.example.com")
+		assert.True(t, strings.Contains(rr.Header().Get("Access-Control-Allow-Headers"), "If-Match"))
+	})
+
+	// Event streams resume with Last-Event-ID
+	t.Run("PreflightLastEventID", func(t *testing.T) {
+		rr := corsRequest(handler, "OPTIONS", map[string]string{
+			"Origin":                         "https://app.example.com",
+			"Access-Control-Request-Method":  "GET",
+			"Access-Control-Request-Headers": "authorization, last-event-id",
+		})
+		assert.Equal(t, rr.Code, http.StatusNoContent)
+		assert.Equal(t, rr.Header().Get("Access-Control-Allow-Origin"), "https://app.example.com")
+		assert.True(t, strings.Contains(rr.Header().Get("Access-Control-Allow-Headers"), "Last-Event-ID"))
+	})
+
+	t.Run("ExposesETag", func(t *testing.T) {
+		rr := corsRequest(handler, "GET", map[string]string{"Origin": "https://app.example.com"})
+		assert.True(t, strings.Contains(rr.Header().Get("Access-Control-Expose-Headers"), "ETag"))
+	})
+}
+
+func TestMatchWildcardOrigin(t *testing.T) {
+	tests := []struct {
+		origin  string
+		matches bool
+	}{
+		{"https://app.example.com", true},
+		{"https://a.b.example.com", true},
+		{"https://example.com", false},
+		{"https://.example.com", false},
+		{"http://app.example.com", false},
+		{"https://app.example.com.evil.com", false},
+		{"https://evil.com/.example.com", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, matchWildcardOrigin("https://*.example.com", test.origin), test.matches)
+	}
+}
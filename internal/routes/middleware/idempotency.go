@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/models/idempotency"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Constants
+// ============================================================================
+
+const (
+	// The request header clients use to make a POST safe to retry
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// The response header set when a stored response is replayed
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// The longest Idempotency-Key accepted
+	maxIdempotencyKeyLength = 255
+
+	// The largest request body read to fingerprint a request
+	maxIdempotencyBodySize = rest.DefaultMaxBodySize
+)
+
+// ============================================================================
+// Middleware
+// ============================================================================
+
+// Makes POST requests with an Idempotency-Key header safe to retry
+//
+// Keys are scoped to the user, or the IP for anonymous clients. The first
+// response (status, headers set by the handler, and body) is stored for the
+// configured TTL and replayed to retries with "Idempotent-Replayed: true".
+// A retry while the first request is in flight receives a 409, and reusing a
+// key for a different method, path, or body receives a 422. Server errors
+// are not stored, so the key is released and the request can be retried.
+//
+// An in-flight key is only leased until the request's deadline, so a key
+// that could not be released, e.g. because the instance died, does not block
+// retries for the whole TTL.
+//
+// This must run after Timeout so keys are leased for the request's deadline,
+// and after User so keys can be scoped by user.
+func (mw *Middleware) Idempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get(IdempotencyKeyHeader)
+		if r.Method != http.MethodPost || header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(header) > maxIdempotencyKeyLength {
+			mw.rest.Error(w, r, xerrors.ClientError(
+				http.StatusBadRequest,
+				fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength),
+				"middleware.Idempotency",
+				xerrors.ErrBadRequest,
+			))
+			return
+		}
+
+		// Read the body to fingerprint the request, then restore it
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotencyBodySize))
+		if err != nil {
+			mw.rest.Error(w, r, readBodyError(err, "middleware.Idempotency.ReadAll"))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := idempotency.New(
+			KeyByUser(r)+"|"+header,
+			idempotency.Fingerprint(r.Method, r.URL.RequestURI(), body),
+			mw.idempotencyLease(r),
+		)
+
+		locked, appErr := mw.idempotency.Lock(r.Context(), key)
+		if appErr != nil {
+			mw.rest.Error(w, r, appErr)
+			return
+		}
+		if !locked {
+			mw.replay(w, r, key)
+			return
+		}
+
+		// Release the key if the handler panics so the client can retry
+		defer func() {
+			if err := recover(); err != nil {
+				mw.releaseIdempotencyKey(r, key)
+				panic(err)
+			}
+		}()
+
+		before := w.Header().Clone()
+		rec := newRecordingWriter(w)
+		next.ServeHTTP(rec, r)
+
+		if rec.status >= http.StatusInternalServerError {
+			mw.releaseIdempotencyKey(r, key)
+			return
+		}
+
+		key.Status = rec.status
+		key.Header = addedHeaders(before, w.Header())
+		key.Body = rec.body.Bytes()
+		key.ExpiresAt = time.Now().Add(mw.idempotencyTTL)
+		if err := mw.idempotency.Complete(context.WithoutCancel(r.Context()), key); err != nil {
+			mw.logger.ErrorContext(r.Context(), err.Error())
+		}
+	})
+}
+
+// Replays the stored response for a key, or rejects the request if the key
+// is in flight or was used for a different request
+func (mw *Middleware) replay(w http.ResponseWriter, r *http.Request, key *idempotency.Key) {
+	stored, err := mw.idempotency.Get(r.Context(), key.Key)
+	if err != nil {
+		// The key expired between Lock and Get, so treat it as in flight
+		if err.Matches(xerrors.ErrNotFound) {
+			err = idempotencyInFlight()
+		}
+		mw.rest.Error(w, r, err)
+		return
+	}
+
+	switch {
+	case stored.Fingerprint != key.Fingerprint:
+		mw.rest.Error(w, r, xerrors.ClientError(
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("%s was already used for a different request", IdempotencyKeyHeader),
+			"middleware.Idempotency.Fingerprint",
+			xerrors.ErrIdempotencyKeyReused,
+		))
+
+	case stored.InFlight():
+		mw.rest.Error(w, r, idempotencyInFlight())
+
+	default:
+		for name, values := range stored.Header {
+			w.Header()[name] = values
+		}
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.WriteHeader(stored.Status)
+		w.Write(stored.Body)
+	}
+}
+
+// Deletes an in-flight key, logging any error
+//
+// The request's deadline may have passed, so the key is released without it.
+func (mw *Middleware) releaseIdempotencyKey(r *http.Request, key *idempotency.Key) {
+	if _, err := mw.idempotency.Release(context.WithoutCancel(r.Context()), key); err != nil {
+		mw.logger.ErrorContext(r.Context(), err.Error())
+	}
+}
+
+// Returns how long an in-flight key is held, until the request's deadline or
+// for the TTL on routes without one
+func (mw *Middleware) idempotencyLease(r *http.Request) time.Duration {
+	if deadline, ok := r.Context().Deadline(); ok {
+		return time.Until(deadline)
+	}
+
+	return mw.idempotencyTTL
+}
+
+// ============================================================================
+// Recording Writer
+// ============================================================================
+
+// Wraps a responseWriter to keep a copy of the body
+type recordingWriter struct {
+	*responseWriter
+	body bytes.Buffer
+}
+
+// Wraps w to record the response and its body
+func newRecordingWriter(w http.ResponseWriter) *recordingWriter {
+	return &recordingWriter{responseWriter: newResponseWriter(w)}
+}
+
+// Copies the body before writing it
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.responseWriter.Write(b)
+}
+
+// ============================================================================
+// Helper
+// ============================================================================
+
+// Creates the error sent while the first request for a key is in flight
+func idempotencyInFlight() *xerrors.AppError {
+	err := xerrors.ClientError(
+		http.StatusConflict,
+		"A request with this Idempotency-Key is already in progress",
+		"middleware.Idempotency.InFlight",
+		xerrors.ErrConflict,
+	)
+	err.Header = http.Header{"Retry-After": {"1"}}
+	return err
+}
+
+// Converts an error reading the request body to a client error
+func readBodyError(err error, op string) *xerrors.AppError {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return rest.BodyTooLarge(maxBytesError.Limit, op, err)
+	}
+
+	return xerrors.ClientError(
+		http.StatusBadRequest,
+		"Request body could not be read",
+		op,
+		fmt.Errorf("%w: %v", xerrors.ErrBadRequest, err),
+	)
+}
+
+// Returns the headers in after that are missing from or differ in before,
+// i.e. the headers set by the handler rather than outer middleware
+func addedHeaders(before, after http.Header) http.Header {
+	added := http.Header{}
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			added[name] = slices.Clone(values)
+		}
+	}
+	return added
+}
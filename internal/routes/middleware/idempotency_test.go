@@ -0,0 +1,286 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/models/idempotency"
+	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// Stores idempotency keys in memory
+type memoryIdempotency struct {
+	mu   sync.Mutex
+	keys map[string]*idempotency.Key
+}
+
+func (m *memoryIdempotency) Lock(_ context.Context, key *idempotency.Key) (bool, *xerrors.AppError) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.keys[key.Key]; ok && stored.ExpiresAt.After(time.Now()) {
+		return false, nil
+	}
+	key.CreatedAt = time.Now()
+	stored := *key
+	m.keys[key.Key] = &stored
+	return true, nil
+}
+
+func (m *memoryIdempotency) Get(_ context.Context, key string) (*idempotency.Key, *xerrors.AppError) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.keys[key]
+	if !ok {
+		return nil, xerrors.ClientError(http.StatusNotFound, "not found", "test", xerrors.ErrNotFound)
+	}
+	copy := *stored
+	return &copy, nil
+}
+
+func (m *memoryIdempotency) Complete(ctx context.Context, key *idempotency.Key) *xerrors.AppError {
+	if err := ctx.Err(); err != nil {
+		return xerrors.DatabaseError(err, "test")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *key
+	m.keys[key.Key] = &stored
+	return nil
+}
+
+func (m *memoryIdempotency) Release(ctx context.Context, key *idempotency.Key) (int64, *xerrors.AppError) {
+	if err := ctx.Err(); err != nil {
+		return 0, xerrors.DatabaseError(err, "test")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.keys, key.Key)
+	return 1, nil
+}
+
+func (m *memoryIdempotency) DeleteExpired(context.Context) (int64, *xerrors.AppError) {
+	return 0, nil
+}
+
+// Creates an idempotent handler that counts calls and responds with 201,
+// or 500 when the body is "fail"
+func idempotencyHandler(repo idempotency.IdempotencyRepository, calls *int, block chan struct{}) http.HandlerFunc {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mw := &Middleware{logger: logger, rest: rest.New(logger), idempotency: repo, idempotencyTTL: time.Hour}
+
+	handler := mw.Idempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if block != nil {
+			<-block
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Location", "/v1/items/1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 1}`))
+	}))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(rest.RequestIDHeader, "outer")
+		handler.ServeHTTP(w, contextSetUser(r, &users.User{ID: 1}))
+	}
+}
+
+// Sends a POST with an Idempotency-Key
+func idempotentRequest(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/v1/items", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestIdempotency(t *testing.T) {
+	repo := &memoryIdempotency{keys: map[string]*idempotency.Key{}}
+	calls := 0
+	handler := idempotencyHandler(repo, &calls, nil)
+
+	first := idempotentRequest(handler, "abc", `{"name": "item"}`)
+	assert.Equal(t, first.Code, http.StatusCreated)
+	assert.Equal(t, first.Header().Get(IdempotentReplayedHeader), "")
+
+	t.Run("Replay", func(t *testing.T) {
+		rr := idempotentRequest(handler, "abc", `{"name": "item"}`)
+		assert.Equal(t, calls, 1)
+		assert.Equal(t, rr.Code, http.StatusCreated)
+		assert.Equal(t, rr.Body.String(), `{"id": 1}`)
+		assert.Equal(t, rr.Header().Get("Location"), "/v1/items/1")
+		assert.Equal(t, rr.Header().Get(IdempotentReplayedHeader), "true")
+		assert.Equal(t, len(repo.keys["user:1|abc"].Header[rest.RequestIDHeader]), 0)
+	})
+
+	t.Run("DifferentBody", func(t *testing.T) {
+		rr := idempotentRequest(handler, "abc", `{"name": "other"}`)
+		assert.Equal(t, calls, 1)
+		assert.Equal(t, rr.Code, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("DifferentKey", func(t *testing.T) {
+		rr := idempotentRequest(handler, "def", `{"name": "item"}`)
+		assert.Equal(t, calls, 2)
+		assert.Equal(t, rr.Code, http.StatusCreated)
+	})
+
+	t.Run("NoKey", func(t *testing.T) {
+		idempotentRequest(handler, "", `{"name": "item"}`)
+		idempotentRequest(handler, "", `{"name": "item"}`)
+		assert.Equal(t, calls, 4)
+	})
+
+	t.Run("ServerError", func(t *testing.T) {
+		rr := idempotentRequest(handler, "fail", "fail")
+		assert.Equal(t, rr.Code, http.StatusInternalServerError)
+		_, stored := repo.keys["user:1|fail"]
+		assert.False(t, stored)
+	})
+
+	t.Run("TooLong", func(t *testing.T) {
+		rr := idempotentRequest(handler, strings.Repeat("k", 256), "")
+		assert.Equal(t, rr.Code, http.StatusBadRequest)
+	})
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	repo := &memoryIdempotency{keys: map[string]*idempotency.Key{}}
+	calls := 0
+	block := make(chan struct{})
+	handler := idempotencyHandler(repo, &calls, block)
+
+	done := make(chan struct{})
+	go func() {
+		idempotentRequest(handler, "abc", "{}")
+		close(done)
+	}()
+
+	// Wait for the first request to lock the key
+	for {
+		repo.mu.Lock()
+		_, locked := repo.keys["user:1|abc"]
+		repo.mu.Unlock()
+		if locked {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	rr := idempotentRequest(handler, "abc", "{}")
+	assert.Equal(t, rr.Code, http.StatusConflict)
+	assert.Equal(t, rr.Header().Get("Retry-After"), "1")
+
+	close(block)
+	<-done
+}
+
+func TestIdempotencyTimeout(t *testing.T) {
+	repo := &memoryIdempotency{keys: map[string]*idempotency.Key{}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mw := &Middleware{logger: logger, rest: rest.New(logger), idempotency: repo, idempotencyTTL: time.Hour}
+
+	// The first call runs until its deadline passes, and later calls succeed
+	calls := 0
+	handler := mw.Idempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			<-r.Context().Done()
+			mw.rest.Error(w, r, xerrors.DatabaseError(r.Context().Err(), "test"))
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/v1/items", strings.NewReader("{}"))
+		req.Header.Set(IdempotencyKeyHeader, "abc")
+
+		ctx, cancel := context.WithTimeout(req.Context(), 20*time.Millisecond)
+		defer cancel()
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, contextSetUser(req.WithContext(ctx), &users.User{ID: 1}))
+		return rr
+	}
+
+	t.Run("Timeout", func(t *testing.T) {
+		rr := send()
+		assert.Equal(t, rr.Code, http.StatusGatewayTimeout)
+
+		_, stored := repo.keys["user:1|abc"]
+		assert.False(t, stored)
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		rr := send()
+		assert.Equal(t, calls, 2)
+		assert.Equal(t, rr.Code, http.StatusCreated)
+
+		stored := repo.keys["user:1|abc"]
+		assert.Equal(t, stored.Status, http.StatusCreated)
+		assert.True(t, time.Until(stored.ExpiresAt) > 59*time.Minute)
+	})
+}
+
+func TestIdempotencyLease(t *testing.T) {
+	repo := &memoryIdempotency{keys: map[string]*idempotency.Key{}}
+	calls := 0
+	block := make(chan struct{})
+	handler := idempotencyHandler(repo, &calls, block)
+
+	// A request with a deadline holds the key only until the deadline
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		req := httptest.NewRequest("POST", "/v1/items", strings.NewReader("{}"))
+		req.Header.Set(IdempotencyKeyHeader, "abc")
+
+		ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+		defer cancel()
+
+		handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+	}()
+
+	for {
+		repo.mu.Lock()
+		stored, locked := repo.keys["user:1|abc"]
+		repo.mu.Unlock()
+		if locked {
+			assert.True(t, time.Until(stored.ExpiresAt) <= 5*time.Second)
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(block)
+	<-done
+
+	stored := repo.keys["user:1|abc"]
+	assert.True(t, time.Until(stored.ExpiresAt) > 59*time.Minute)
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/metrics"
+)
+
+// Methods reported as is, others are reported as OTHER to bound the series
+var metricsMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// Middleware to count requests and observe their latency
+//
+// Requests are labeled by the pattern they matched in mux rather than their
+// path, so IDs in paths do not create new series. Unmatched requests are
+// labeled "unmatched". Call this once per registry since it registers the
+// http_requests_total and http_request_duration_seconds metrics.
+func (mw *Middleware) Metrics(mux *http.ServeMux, next http.Handler) http.Handler {
+	requests := mw.metrics.Counter(
+		"http_requests_total",
+		"HTTP requests by route pattern, method, and status.",
+		"route", "method", "status",
+	)
+	latency := mw.metrics.Histogram(
+		"http_request_duration_seconds",
+		"HTTP request latency by route pattern and method.",
+		metrics.DefaultBuckets,
+		"route", "method",
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			rw    = newResponseWriter(w)
+			start = time.Now()
+		)
+
+		next.ServeHTTP(rw, r)
+
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		method := r.Method
+		if !metricsMethods[method] {
+			method = "OTHER"
+		}
+
+		requests.Inc(route, method, strconv.Itoa(rw.status))
+		latency.Observe(time.Since(start).Seconds(), route, method)
+	})
+}
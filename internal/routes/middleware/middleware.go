@@ -1,25 +1,47 @@
 package middleware
 
 import (
+	"time"
+
 	"go-rest-starter.jtbergman.me/internal/app"
+	"go-rest-starter.jtbergman.me/internal/metrics"
+	"go-rest-starter.jtbergman.me/internal/models"
+	"go-rest-starter.jtbergman.me/internal/models/idempotency"
 	"go-rest-starter.jtbergman.me/internal/models/permissions"
 	"go-rest-starter.jtbergman.me/internal/models/users"
 	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/tracing"
 	"go-rest-starter.jtbergman.me/internal/xlogger"
 )
 
 type Middleware struct {
-	logger      xlogger.Logger
-	permissions permissions.PermissionsRepository
-	rest        *rest.Rest
-	users       users.UsersRepository
+	accessLog       accessLog
+	cors            cors
+	forOrganization func(organizationID int64) *models.Organization
+	idempotency     idempotency.IdempotencyRepository
+	idempotencyTTL  time.Duration
+	logger          xlogger.Logger
+	metrics         *metrics.Registry
+	permissions     permissions.PermissionsRepository
+	rest            *rest.Rest
+	security        securityHeaders
+	tracer          *tracing.Tracer
+	users           users.UsersRepository
 }
 
 func New(app *app.App) *Middleware {
 	return &Middleware{
-		logger:      app.Logger,
-		permissions: app.Models.Permissions,
-		rest:        app.Rest,
-		users:       app.Models.Users,
+		accessLog:       newAccessLog(app.Config.Log.SampleRate, app.Config.Log.SkipPaths),
+		cors:            newCORS(app.Config),
+		forOrganization: app.Models.ForOrganization,
+		idempotency:     app.Models.Idempotency,
+		idempotencyTTL:  app.Config.Idempotency.TTL,
+		logger:          app.Logger,
+		metrics:         app.Metrics,
+		permissions:     app.Models.Permissions,
+		rest:            app.Rest,
+		security:        newSecurityHeaders(app.Config),
+		tracer:          app.Tracer,
+		users:           app.Models.Users,
 	}
 }
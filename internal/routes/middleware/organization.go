@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"go-rest-starter.jtbergman.me/internal/models/organizations"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// The header used to select the active organization for a request
+const OrganizationHeader = "X-Organization-ID"
+
+// ===========================================================================
+// Organization Middleware
+// ===========================================================================
+
+// Adds the user's membership in the active organization to the request
+// context. The active organization is selected with the X-Organization-ID
+// header. If the header is not provided, NoMembership will be added to the
+// request. If the header is provided, the user must be authenticated and a
+// member of the organization. Must run after User.
+func (mw *Middleware) Organization(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// "Vary: X-Organization-ID" tells caches this response varies based
+		// on the active organization
+		w.Header().Add("Vary", OrganizationHeader)
+
+		header := r.Header.Get(OrganizationHeader)
+		if header == "" {
+			r = contextSetMembership(r, organizations.NoMembership)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Parse the organization ID
+		organizationID, parseErr := strconv.ParseInt(header, 10, 64)
+		if parseErr != nil || organizationID < 1 {
+			mw.rest.Error(w, r, xerrors.ClientError(
+				http.StatusBadRequest,
+				"The X-Organization-ID header must be a positive integer",
+				"middleware.Organization.ParseInt",
+				xerrors.ErrBadRequest,
+			))
+			return
+		}
+
+		// Only members can select an organization
+		user := ContextGetUser(r)
+		err := xerrors.ClientUnauthorized(user.IsAnonymous(), "middleware.Organization")
+		if err != nil {
+			mw.rest.Error(w, r, err)
+			return
+		}
+
+		membership, err := mw.forOrganization(organizationID).Members.Get(r.Context(), user.ID)
+		if err != nil {
+			err.If(xerrors.ErrNotFound, func(err *xerrors.AppError) {
+				err.Data = "The organization does not exist or you are not a member"
+			})
+			mw.rest.Error(w, r, err)
+			return
+		}
+
+		r = contextSetMembership(r, membership)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Requires an active organization where the user has at least the given role
+//
+// Internally, this will require the user to be authenticated
+func (mw *Middleware) RequireOrganizationRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		membership := ContextGetMembership(r)
+
+		if membership.IsNone() {
+			mw.rest.Error(w, r, xerrors.ClientError(
+				http.StatusBadRequest,
+				"An organization must be selected with the X-Organization-ID header",
+				"middleware.RequireOrganizationRole.IsNone",
+				xerrors.ErrBadRequest,
+			))
+			return
+		}
+
+		err := xerrors.ClientForbidden(!membership.HasRole(role), "middleware.RequireOrganizationRole.HasRole")
+		if err != nil {
+			err.Data = "Your organization role does not allow this action"
+			mw.rest.Error(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return mw.Authenticated(fn)
+}
+
+// ===========================================================================
+// Context: Membership
+// ===========================================================================
+
+// The contextKey for storing the active organization membership
+const membershipContextKey = contextKey("membership")
+
+// Retrieves the user's Membership in the active organization from the request
+// context. This value is set by Organization middleware and can be trusted.
+// However, you should always check for membership.IsNone(). Tenant-owned
+// data should be queried through models.ForOrganization with
+// membership.OrganizationID.
+func ContextGetMembership(r *http.Request) *organizations.Membership {
+	membership, ok := r.Context().Value(membershipContextKey).(*organizations.Membership)
+
+	if !ok {
+		panic("missing membership value in request context")
+	}
+
+	return membership
+}
+
+// Returns a new copy of the request with the Membership added to the context
+func contextSetMembership(r *http.Request, membership *organizations.Membership) *http.Request {
+	ctx := context.WithValue(r.Context(), membershipContextKey, membership)
+	return r.WithContext(ctx)
+}
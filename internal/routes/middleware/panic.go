@@ -19,7 +19,7 @@ func (mw *Middleware) RecoverPanic(next http.Handler) http.Handler {
 					fmt.Errorf("%w: %v", xerrors.ErrServerInternal, err),
 				)
 
-				mw.rest.Error(w, serverError)
+				mw.rest.Error(w, r, serverError)
 			}
 		}()
 
@@ -14,16 +14,16 @@ func (mw *Middleware) RequirePermission(code string, next http.HandlerFunc) http
 		user := ContextGetUser(r)
 
 		// Get permissions
-		permissions, err := mw.permissions.GetByID(user.ID)
+		permissions, err := mw.permissions.GetByID(r.Context(), user.ID)
 		if err != nil {
-			mw.rest.Error(w, err)
+			mw.rest.Error(w, r, err)
 			return
 		}
 
 		// Required permission exists
-		err = xerrors.ClientUnauthorized(!permissions.Include(code), "middleware.RequirePermission.Include")
+		err = xerrors.ClientForbidden(!permissions.Include(code), "middleware.RequirePermission.Include")
 		if err != nil {
-			mw.rest.Error(w, err)
+			mw.rest.Error(w, r, err.Challenge(xerrors.BearerInsufficientScope))
 			return
 		}
 
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"go-rest-starter.jtbergman.me/internal/ratelimit"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// Configuration
+// ============================================================================
+
+// Identifies the client a request is counted against
+type RateLimitKey func(r *http.Request) string
+
+// Configures a limit and how clients are identified for it
+type RateLimit struct {
+	ratelimit.Limit
+	Key RateLimitKey
+}
+
+// Configures rate limits for every route served by Mux
+//
+// Routes are keyed by the pattern they were registered with, e.g.
+// auth.LoginRoute. Requests that do not match a configured route share the
+// Default limit. Each route has its own buckets, and Name separates the
+// buckets of RateLimit middleware that share a Store.
+type RateLimits struct {
+	Default RateLimit
+	Mux     *http.ServeMux
+	Name    string
+	Routes  map[string]RateLimit
+	Store   ratelimit.Store
+}
+
+// Returns the pattern and limit for a request
+func (limits RateLimits) match(r *http.Request) (string, RateLimit) {
+	_, pattern := limits.Mux.Handler(r)
+
+	if limit, ok := limits.Routes[pattern]; ok {
+		return pattern, limit
+	}
+
+	return "*", limits.Default
+}
+
+// ============================================================================
+// Middleware
+// ============================================================================
+
+// Rejects requests once the client has exhausted its token bucket
+//
+// Responses include RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset,
+// and RateLimit-Policy headers. Rejected requests are sent a 429 with
+// Retry-After. If the store fails, the request is allowed and the error is
+// logged so an outage does not take the API down with it.
+//
+// Limits keyed by user must run after User. Limits keyed by IP may also run
+// before it, so requests with invalid tokens are limited before the lookup.
+func (mw *Middleware) RateLimit(limits RateLimits, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pattern, limit := limits.match(r)
+		if limit.IsZero() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := fmt.Sprintf("%s|%s|%s", limits.Name, pattern, limit.Key(r))
+		result, err := limits.Store.Take(r.Context(), key, limit.Limit)
+		if err != nil {
+			mw.logger.ErrorContext(r.Context(), err.Error(), "op", "middleware.RateLimit.Take")
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+		w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, int(limit.Period.Seconds())))
+
+		if !result.Allowed {
+			mw.rest.Error(w, r, xerrors.ClientRateLimited(result.RetryAfter, "middleware.RateLimit"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ============================================================================
+// Keys
+// ============================================================================
+
+// Identifies clients by IP address
+func KeyByIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return "ip:" + ip
+}
+
+// Identifies authenticated clients by user ID and anonymous clients by IP
+func KeyByUser(r *http.Request) string {
+	user := ContextGetUser(r)
+
+	if user.IsAnonymous() {
+		return KeyByIP(r)
+	}
+
+	return fmt.Sprintf("user:%d", user.ID)
+}
+
+// Identifies clients by an API key sent in the given header and falls back
+// to KeyByUser when it is missing
+//
+// The key is hashed so secrets are never held by the store. Keys are not
+// verified here, so only use this on routes that reject unknown keys.
+func KeyByAPIKey(header string) RateLimitKey {
+	return func(r *http.Request) string {
+		apiKey := r.Header.Get(header)
+
+		if apiKey == "" {
+			return KeyByUser(r)
+		}
+
+		hash := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(hash[:])
+	}
+}
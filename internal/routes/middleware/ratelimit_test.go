@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/ratelimit"
+	"go-rest-starter.jtbergman.me/internal/rest"
+)
+
+// A store that always fails
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("unavailable")
+}
+
+// Creates a rate limited handler that responds with 204
+func rateLimitHandler(store ratelimit.Store) http.HandlerFunc {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mw := &Middleware{logger: logger, rest: rest.New(logger)}
+
+	mux := http.NewServeMux()
+	noContent := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	mux.HandleFunc("/limited", noContent)
+	mux.HandleFunc("/other", noContent)
+
+	limits := RateLimits{
+		Default: RateLimit{Limit: ratelimit.Limit{Burst: 5, Period: time.Minute}, Key: KeyByUser},
+		Mux:     mux,
+		Routes: map[string]RateLimit{
+			"/limited": {Limit: ratelimit.Limit{Burst: 1, Period: time.Minute}, Key: KeyByIP},
+		},
+		Store: store,
+	}
+
+	handler := mw.RateLimit(limits, mux)
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, contextSetUser(r, users.AnonymousUser))
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	store := ratelimit.NewMemoryStore(time.Minute)
+	t.Cleanup(store.Close)
+	handler := rateLimitHandler(store)
+
+	assert.RunHandlerTestCase(t, handler, "GET", "/limited", assert.HandlerTestCase[struct{}]{
+		Name:   "RateLimit/Allowed",
+		Status: http.StatusNoContent,
+		ResponseHeaders: map[string]string{
+			"RateLimit-Limit":     "1",
+			"RateLimit-Remaining": "0",
+			"RateLimit-Reset":     "60",
+			"RateLimit-Policy":    "1;w=60",
+		},
+	})
+
+	assert.RunHandlerTestCase(t, handler, "GET", "/limited", assert.HandlerTestCase[struct{ Error string }]{
+		Name:   "RateLimit/Exceeded",
+		Status: http.StatusTooManyRequests,
+		ResponseHeaders: map[string]string{
+			"Retry-After": "60",
+		},
+		FN: func(t *testing.T, result struct{ Error string }) {
+			assert.Equal(t, result.Error, "Rate limit exceeded, please try again later")
+		},
+	})
+
+	assert.RunHandlerTestCase(t, handler, "GET", "/other", assert.HandlerTestCase[struct{}]{
+		Name:   "RateLimit/Default",
+		Status: http.StatusNoContent,
+		ResponseHeaders: map[string]string{
+			"RateLimit-Limit":     "5",
+			"RateLimit-Remaining": "4",
+		},
+	})
+}
+
+func TestRateLimitStoreFailure(t *testing.T) {
+	handler := rateLimitHandler(failingStore{})
+
+	assert.RunHandlerTestCase(t, handler, "GET", "/limited", assert.HandlerTestCase[struct{}]{
+		Name:   "RateLimit/FailOpen",
+		Status: http.StatusNoContent,
+		ResponseHeaders: map[string]string{
+			"RateLimit-Limit": "",
+		},
+	})
+}
+
+func TestRateLimitNames(t *testing.T) {
+	store := ratelimit.NewMemoryStore(time.Minute)
+	t.Cleanup(store.Close)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mw := &Middleware{logger: logger, rest: rest.New(logger)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	limit := RateLimit{Limit: ratelimit.Limit{Burst: 1, Period: time.Minute}, Key: KeyByIP}
+	outer := RateLimits{Default: limit, Mux: mux, Name: "ip", Store: store}
+	inner := RateLimits{Default: limit, Mux: mux, Store: store}
+	handler := mw.RateLimit(outer, mw.RateLimit(inner, mux))
+
+	assert.RunHandlerTestCase(t, handler.ServeHTTP, "GET", "/", assert.HandlerTestCase[struct{}]{
+		Name:   "RateLimit/SeparateBuckets",
+		Status: http.StatusNoContent,
+	})
+
+	assert.RunHandlerTestCase(t, handler.ServeHTTP, "GET", "/", assert.HandlerTestCase[struct{}]{
+		Name:   "RateLimit/OuterExceeded",
+		Status: http.StatusTooManyRequests,
+	})
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.7:5555"
+	r = contextSetUser(r, users.AnonymousUser)
+
+	assert.Equal(t, KeyByIP(r), "ip:203.0.113.7")
+	assert.Equal(t, KeyByUser(r), "ip:203.0.113.7")
+	assert.Equal(t, KeyByAPIKey("X-API-Key")(r), "ip:203.0.113.7")
+
+	r.Header.Set("X-API-Key", "secret")
+	assert.Equal(t, KeyByAPIKey("X-API-Key")(r)[:4], "key:")
+
+	r = contextSetUser(r, &users.User{ID: 7})
+	assert.Equal(t, KeyByUser(r), "user:7")
+}
@@ -1,16 +1,137 @@
 package middleware
 
-import "net/http"
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	mrand "math/rand/v2"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
 
-// Middleware to log requests
+	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/xlogger"
+)
+
+// ===========================================================================
+// Access Log
+// ===========================================================================
+
+// Configures which completed requests are logged
+type accessLog struct {
+	sampleRate float64
+	skipPaths  map[string]bool
+}
+
+// Creates the access log configuration
+func newAccessLog(sampleRate float64, skipPaths []string) accessLog {
+	skip := map[string]bool{}
+	for _, path := range skipPaths {
+		if path = strings.TrimSpace(path); path != "" {
+			skip[path] = true
+		}
+	}
+
+	return accessLog{sampleRate: sampleRate, skipPaths: skip}
+}
+
+// Returns true if a request with the path and status should be logged.
+// Client and server errors are always logged.
+func (log accessLog) sample(path string, status int) bool {
+	if log.skipPaths[path] {
+		return false
+	}
+
+	return status >= 400 || log.sampleRate >= 1 || mrand.Float64() < log.sampleRate
+}
+
+// Holds request details set by inner middleware, e.g. the user
+type accessEntry struct {
+	userID int64
+}
+
+// The contextKey for storing the access log entry
+const accessContextKey = contextKey("access")
+
+// Records the user on the access log entry if there is one
+func contextSetAccessUser(r *http.Request, user *users.User) {
+	if entry, ok := r.Context().Value(accessContextKey).(*accessEntry); ok {
+		entry.userID = user.ID
+	}
+}
+
+// Middleware to log requests once they complete
+//
+// Each line includes the status, response size in bytes, latency, user ID
+// (0 if anonymous), and remote address. Successful requests are sampled at
+// the configured rate, and skip paths (e.g. health checks) are never logged.
 func (mw *Middleware) Requests(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var (
-			method = r.Method
-			uri    = r.URL.RequestURI()
+			entry = &accessEntry{}
+			rw    = newResponseWriter(w)
+			start = time.Now()
 		)
 
-		mw.rest.Logger.Info("request", "method", method, "uri", uri)
+		ctx := context.WithValue(r.Context(), accessContextKey, entry)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		if !mw.accessLog.sample(r.URL.Path, rw.status) {
+			return
+		}
+
+		mw.rest.Logger.InfoContext(
+			r.Context(),
+			"request",
+			"method", r.Method,
+			"uri", r.URL.RequestURI(),
+			"status", rw.status,
+			"size", rw.size,
+			"duration", time.Since(start),
+			"user_id", entry.userID,
+			"remote_addr", r.RemoteAddr,
+		)
+	})
+}
+
+// ===========================================================================
+// Request ID
+// ===========================================================================
+
+// Matches request IDs that are safe to echo and log
+var requestIDRX = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Adds a request ID to the request context and the response headers
+//
+// A valid X-Request-ID from the client (or a proxy) is reused so requests can
+// be traced across services. Otherwise, a random ID is generated. Log lines
+// written with a Context method and error responses include the ID.
+//
+// This should be the outermost middleware so every response has an ID.
+func (mw *Middleware) RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(rest.RequestIDHeader)
+		if !requestIDRX.MatchString(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(rest.RequestIDHeader, id)
+		r = r.WithContext(xlogger.WithRequestID(r.Context(), id))
 		next.ServeHTTP(w, r)
 	})
 }
+
+// Retrieves the request ID from the request context. This value is set by
+// RequestID middleware, and an empty string is returned without it.
+func ContextGetRequestID(r *http.Request) string {
+	return xlogger.RequestID(r.Context())
+}
+
+// Generates a random 128-bit request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
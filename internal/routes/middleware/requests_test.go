@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// Creates a handler that fails with the request ID it received
+func requestIDHandler() http.HandlerFunc {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mw := &Middleware{logger: logger, rest: rest.New(logger)}
+
+	handler := mw.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := xerrors.ClientError(http.StatusTeapot, ContextGetRequestID(r), "test", xerrors.ErrBadRequest)
+		mw.rest.Error(w, r, err)
+	}))
+
+	return handler.ServeHTTP
+}
+
+// Helper error with request ID type
+type requestIDFailure struct {
+	Error     string `json:"error"`
+	RequestID string `json:"request_id"`
+}
+
+func TestRequestID(t *testing.T) {
+	handler := requestIDHandler()
+
+	assert.RunHandlerTestCase(t, handler, "GET", "/", assert.HandlerTestCase[requestIDFailure]{
+		Name:    "RequestID/Accepted",
+		Headers: map[string]string{rest.RequestIDHeader: "upstream-id:1"},
+		Status:  http.StatusTeapot,
+		ResponseHeaders: map[string]string{
+			rest.RequestIDHeader: "upstream-id:1",
+		},
+		FN: func(t *testing.T, result requestIDFailure) {
+			assert.Equal(t, result.Error, "upstream-id:1")
+			assert.Equal(t, result.RequestID, "upstream-id:1")
+		},
+	})
+
+	assert.RunHandlerTestCase(t, handler, "GET", "/", assert.HandlerTestCase[requestIDFailure]{
+		Name:    "RequestID/Replaced",
+		Headers: map[string]string{rest.RequestIDHeader: "bad id\n"},
+		Status:  http.StatusTeapot,
+		FN: func(t *testing.T, result requestIDFailure) {
+			assert.Equal(t, len(result.RequestID), 32)
+			assert.Equal(t, result.Error, result.RequestID)
+		},
+	})
+
+	assert.RunHandlerTestCase(t, handler, "GET", "/", assert.HandlerTestCase[requestIDFailure]{
+		Name:   "RequestID/Generated",
+		Status: http.StatusTeapot,
+		FN: func(t *testing.T, result requestIDFailure) {
+			assert.Equal(t, len(result.RequestID), 32)
+		},
+	})
+}
+
+// ===========================================================================
+// Access Log
+// ===========================================================================
+
+// Captures log records
+type captureHandler struct {
+	records *[]slog.Record
+}
+
+func (h captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h captureHandler) Handle(_ context.Context, record slog.Record) error {
+	*h.records = append(*h.records, record)
+	return nil
+}
+
+func (h captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h captureHandler) WithGroup(string) slog.Handler { return h }
+
+// Returns the attributes of a record by key
+func recordAttrs(record slog.Record) map[string]any {
+	attrs := map[string]any{}
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs[attr.Key] = attr.Value.Any()
+		return true
+	})
+	return attrs
+}
+
+// Creates a logged handler that responds with the status for the path
+func accessLogHandler(records *[]slog.Record, sampleRate float64) http.Handler {
+	logger := slog.New(captureHandler{records: records})
+	mw := &Middleware{
+		accessLog: newAccessLog(sampleRate, []string{"/health"}),
+		logger:    logger,
+		rest:      rest.New(logger),
+	}
+
+	return mw.Requests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contextSetUser(r, &users.User{ID: 7})
+
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Write([]byte("hello"))
+		}
+	}))
+}
+
+func TestRequests(t *testing.T) {
+	t.Run("Logged", func(t *testing.T) {
+		var records []slog.Record
+		handler := accessLogHandler(&records, 1)
+
+		req := httptest.NewRequest("GET", "/hello?name=test", nil)
+		req.RemoteAddr = "203.0.113.7:5555"
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Check(t, len(records) == 1)
+		attrs := recordAttrs(records[0])
+		assert.Equal(t, attrs["uri"].(string), "/hello?name=test")
+		assert.Equal(t, attrs["status"].(int64), 200)
+		assert.Equal(t, attrs["size"].(int64), 5)
+		assert.Equal(t, attrs["user_id"].(int64), 7)
+		assert.Equal(t, attrs["remote_addr"].(string), "203.0.113.7:5555")
+		assert.True(t, attrs["duration"] != nil)
+	})
+
+	t.Run("SkipPaths", func(t *testing.T) {
+		var records []slog.Record
+		handler := accessLogHandler(&records, 1)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+		assert.Equal(t, len(records), 0)
+	})
+
+	t.Run("Sampling", func(t *testing.T) {
+		var records []slog.Record
+		handler := accessLogHandler(&records, 0)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/hello", nil))
+		assert.Equal(t, len(records), 0)
+
+		// Errors are always logged
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+		assert.Check(t, len(records) == 1)
+		assert.Equal(t, recordAttrs(records[0])["status"].(int64), 404)
+	})
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/config"
+	"go-rest-starter.jtbergman.me/internal/rest"
+)
+
+// Creates a handler that renders a static page behind SecurityHeaders
+func securityHandler(env string) http.Handler {
+	cfg := config.Config{Env: env}
+	cfg.Security.CSP = config.DefaultCSP
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mw := &Middleware{logger: logger, rest: rest.New(logger), security: newSecurityHeaders(cfg)}
+
+	return mw.SecurityHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mw.rest.WriteHTML(w, r, "test", "../../../static/reset.html", ContextGetNonce(r))
+	}))
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	handler := securityHandler(config.EnvLocal)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/auth/reset", nil))
+
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assert.Equal(t, rr.Header().Get("Strict-Transport-Security"), "")
+	assert.Equal(t, rr.Header().Get("X-Content-Type-Options"), "nosniff")
+	assert.Equal(t, rr.Header().Get("Referrer-Policy"), "no-referrer")
+	assert.Equal(t, rr.Header().Get("X-Frame-Options"), "DENY")
+	assert.Equal(t, rr.Header().Get("Permissions-Policy"), permissionsPolicyValue)
+
+	// The nonce in the policy is used by the page's script
+	csp := rr.Header().Get("Content-Security-Policy")
+	assert.Check(t, strings.Contains(csp, "frame-ancestors 'none'"))
+	assert.Check(t, !strings.Contains(csp, cspNoncePlaceholder))
+
+	_, nonce, _ := strings.Cut(csp, "script-src 'nonce-")
+	nonce, _, _ = strings.Cut(nonce, "'")
+	assert.Check(t, len(nonce) > 0)
+	assert.Check(t, strings.Contains(rr.Body.String(), `<script nonce="`+nonce+`">`))
+
+	t.Run("UniqueNonce", func(t *testing.T) {
+		next := httptest.NewRecorder()
+		handler.ServeHTTP(next, httptest.NewRequest("GET", "/v1/auth/reset", nil))
+		assert.Check(t, next.Header().Get("Content-Security-Policy") != csp)
+	})
+
+	t.Run("HSTS", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		securityHandler(config.EnvProd).ServeHTTP(rr, httptest.NewRequest("GET", "/v1/auth/reset", nil))
+		assert.Equal(t, rr.Header().Get("Strict-Transport-Security"), hstsValue)
+	})
+}
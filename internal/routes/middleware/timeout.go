@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// ============================================================================
+// Configuration
+// ============================================================================
+
+// Configures the request timeout for every route served by Mux
+//
+// Routes are keyed by the pattern they were registered with, e.g.
+// admin.AuditRoute. Requests that do not match a configured route use the
+// Default timeout, and a zero timeout means no deadline.
+type Timeouts struct {
+	Default time.Duration
+	Mux     *http.ServeMux
+	Routes  map[string]time.Duration
+}
+
+// Returns the timeout for a request
+func (timeouts Timeouts) match(r *http.Request) time.Duration {
+	_, pattern := timeouts.Mux.Handler(r)
+
+	if timeout, ok := timeouts.Routes[pattern]; ok {
+		return timeout
+	}
+
+	return timeouts.Default
+}
+
+// ============================================================================
+// Middleware
+// ============================================================================
+
+// Bounds each request with a deadline for its route
+//
+// Repositories and other work started from the request context stop when the
+// deadline passes or the client disconnects, and repositories then return a
+// 504 or 503 AppError. The deadline is cooperative, so handlers should pass
+// r.Context() to anything that may block.
+func (mw *Middleware) Timeout(timeouts Timeouts, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := timeouts.match(r)
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
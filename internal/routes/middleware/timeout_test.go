@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+func TestTimeout(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mw := &Middleware{logger: logger, rest: rest.New(logger)}
+
+	// Waits for the deadline like a slow query would
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		mw.rest.Error(w, r, xerrors.DatabaseError(r.Context().Err(), "test.slow"))
+	})
+	mux.HandleFunc("/deadline", func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		if !ok || time.Until(deadline) < time.Minute {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/none", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	handler := mw.Timeout(Timeouts{
+		Default: 10 * time.Millisecond,
+		Mux:     mux,
+		Routes: map[string]time.Duration{
+			"/deadline": time.Hour,
+			"/none":     0,
+		},
+	}, mux)
+
+	assert.RunHandlerTestCase(t, handler.ServeHTTP, "GET", "/slow", assert.HandlerTestCase[struct{ Error string }]{
+		Name:   "Timeout/Default",
+		Status: http.StatusGatewayTimeout,
+		FN: func(t *testing.T, result struct{ Error string }) {
+			assert.Equal(t, result.Error, "The request took too long to complete")
+		},
+	})
+
+	assert.RunHandlerTestCase(t, handler.ServeHTTP, "GET", "/deadline", assert.HandlerTestCase[struct{}]{
+		Name:   "Timeout/Route",
+		Status: http.StatusNoContent,
+	})
+
+	assert.RunHandlerTestCase(t, handler.ServeHTTP, "GET", "/none", assert.HandlerTestCase[struct{}]{
+		Name:   "Timeout/Disabled",
+		Status: http.StatusNoContent,
+	})
+}
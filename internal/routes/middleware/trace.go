@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go-rest-starter.jtbergman.me/internal/tracing"
+)
+
+// Middleware to start a span for each request
+//
+// A valid traceparent header continues the caller's trace. The span is named
+// after the pattern matched in mux, and handlers can start child spans with
+// tracing.Start(r.Context(), ...). Requests pass through untraced if tracing
+// is disabled.
+func (mw *Middleware) Trace(mux *http.ServeMux, next http.Handler) http.Handler {
+	if mw.tracer == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if remote, ok := tracing.Extract(r.Header); ok {
+			ctx = tracing.ContextWithRemote(ctx, remote)
+		}
+
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx, span := mw.tracer.Start(ctx, fmt.Sprintf("%s %s", r.Method, route))
+		defer span.End()
+
+		rw := newResponseWriter(w)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.status_code", rw.status)
+		span.SetAttribute("request_id", ContextGetRequestID(r))
+		if rw.status >= http.StatusInternalServerError {
+			span.RecordError(errors.New(http.StatusText(rw.status)))
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/tracing"
+)
+
+func TestTrace(t *testing.T) {
+	exporter := tracing.NewMemoryExporter()
+	mw := &Middleware{tracer: tracing.New(exporter)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Start(r.Context(), "items.GetByID")
+		span.End()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	handler := mw.RequestID(mw.Trace(mux, mux))
+
+	req := httptest.NewRequest("GET", "/items/1", nil)
+	req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	spans := exporter.Spans()
+	assert.Check(t, len(spans) == 2)
+	child, root := spans[0], spans[1]
+
+	assert.Equal(t, root.Name, "GET /items/{id}")
+	assert.Equal(t, root.TraceID.String(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Equal(t, root.ParentID.String(), "00f067aa0ba902b7")
+	assert.Equal(t, root.Attributes["http.status_code"].(int), http.StatusInternalServerError)
+	assert.Equal(t, root.Attributes["request_id"].(string), rr.Header().Get("X-Request-ID"))
+	assert.Equal(t, root.Error, "Internal Server Error")
+
+	assert.Equal(t, child.TraceID, root.TraceID)
+	assert.Equal(t, child.ParentID, root.SpanID)
+
+	t.Run("Disabled", func(t *testing.T) {
+		mw := &Middleware{}
+		assert.True(t, mw.Trace(mux, mux) == http.Handler(mux))
+	})
+}
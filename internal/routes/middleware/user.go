@@ -25,9 +25,8 @@ func (mw *Middleware) User(next http.Handler) http.Handler {
 
 		// Read the token from the header
 		token, validHeader := readAuthorizationHeader(r)
-		err := xerrors.ClientUnauthorized(!validHeader, "middleware.Authenticate")
-		if err != nil {
-			mw.rest.Error(w, err)
+		if !validHeader {
+			mw.rest.Error(w, r, xerrors.ClientInvalidToken("middleware.Authenticate"))
 			return
 		}
 		if token == "" {
@@ -38,13 +37,12 @@ func (mw *Middleware) User(next http.Handler) http.Handler {
 		}
 
 		// Fetch the user's details and add them to the context
-		user, err := mw.users.GetByToken(token)
+		user, err := mw.users.GetByToken(r.Context(), token)
 		if err != nil {
-			err.If(xerrors.ErrNotFound, func(err *xerrors.AppError) {
-				err.StatusCode = http.StatusUnauthorized
-				err.Data = "Auth token is invalid"
-			})
-			mw.rest.Error(w, err)
+			if err.Matches(xerrors.ErrNotFound) {
+				err = xerrors.ClientInvalidToken("middleware.Authenticate.GetByToken")
+			}
+			mw.rest.Error(w, r, err)
 			return
 		}
 
@@ -80,6 +78,7 @@ func ContextGetUser(r *http.Request) *users.User {
 
 // Returns a new copy of the request with the User struct added to the context
 func contextSetUser(r *http.Request, user *users.User) *http.Request {
+	contextSetAccessUser(r, user)
 	ctx := context.WithValue(r.Context(), userContextKey, user)
 	return r.WithContext(ctx)
 }
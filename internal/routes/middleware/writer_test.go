@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+)
+
+func TestResponseWriter(t *testing.T) {
+	t.Run("DefaultStatus", func(t *testing.T) {
+		rw := newResponseWriter(httptest.NewRecorder())
+		rw.Write([]byte("hello"))
+		assert.Equal(t, rw.status, http.StatusOK)
+		assert.Equal(t, rw.size, 5)
+	})
+
+	t.Run("FirstStatus", func(t *testing.T) {
+		rw := newResponseWriter(httptest.NewRecorder())
+		rw.WriteHeader(http.StatusCreated)
+		rw.WriteHeader(http.StatusInternalServerError)
+		assert.Equal(t, rw.status, http.StatusCreated)
+	})
+
+	t.Run("Flush", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		rw := newResponseWriter(rec)
+		assert.True(t, http.NewResponseController(rw).Flush() == nil)
+		assert.True(t, rec.Flushed)
+	})
+
+	t.Run("Hijack", func(t *testing.T) {
+		rw := newResponseWriter(httptest.NewRecorder())
+		_, _, err := rw.Hijack()
+		assert.Is(t, err, http.ErrNotSupported)
+	})
+
+	t.Run("Unwrap", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		rw := newResponseWriter(rec)
+		assert.True(t, rw.Unwrap() == rec)
+	})
+}
@@ -0,0 +1,285 @@
+package orgs
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/models"
+	"go-rest-starter.jtbergman.me/internal/models/audit"
+	"go-rest-starter.jtbergman.me/internal/models/invitations"
+	"go-rest-starter.jtbergman.me/internal/models/organizations"
+	"go-rest-starter.jtbergman.me/internal/models/tokens"
+	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/routes/middleware"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// GET
+// ============================================================================
+
+// Lists the pending invitations for the active organization
+func (app *Orgs) invitationsGet(w http.ResponseWriter, r *http.Request) {
+	membership := middleware.ContextGetMembership(r)
+
+	pending, err := app.forOrganization(membership.OrganizationID).Invitations.GetAll(r.Context())
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	app.rest.Write(w, r, "orgs.invitationsGet", http.StatusOK, rest.Envelope{"invitations": pending})
+}
+
+// ============================================================================
+// POST
+// ============================================================================
+
+// Invites an email address to the active organization with a role
+//
+// Inviting the same email again replaces the pending invitation.
+func (app *Orgs) invitationsPost(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+
+	user := middleware.ContextGetUser(r)
+	membership := middleware.ContextGetMembership(r)
+
+	// Parse request
+	if err := app.rest.Read(w, r, "orgs.invitationsPost", &input); err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+	if input.Role == "" {
+		input.Role = organizations.RoleMember
+	}
+
+	// Members cannot grant a role higher than their own
+	err := xerrors.ClientForbidden(
+		organizations.ValidRole(input.Role) && !membership.HasRole(input.Role),
+		"orgs.invitationsPost.HasRole",
+	)
+	if err != nil {
+		err.Data = "You cannot invite a member with a higher role than your own"
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	// Get organization
+	organization, err := app.organizations.GetByID(r.Context(), membership.OrganizationID)
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	// Create invitation token
+	token, err := app.tokens.New(user.ID, 7*24*time.Hour, tokens.ScopeInvitation)
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	// Create invitation
+	pending := app.forOrganization(organization.ID).Invitations
+	invitation, err := pending.New(input.Email, input.Role, token)
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	// Insert invitation
+	if err := pending.Insert(r.Context(), invitation); err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+	app.record(r, audit.ActionInvitationCreate, user.ID, 0, map[string]any{
+		"organization_id": organization.ID,
+		"invitation_id":   invitation.ID,
+		"email":           invitation.Email,
+		"role":            invitation.Role,
+	})
+
+	// Send invitation email
+	app.bg.Run(r.Context(), func(ctx context.Context) {
+		data := map[string]string{
+			"invitationToken":  token.Plaintext,
+			"organizationName": organization.Name,
+			"role":             invitation.Role,
+		}
+
+		err := app.mailer.SendInvitationEmail(ctx, invitation.Email, data)
+		if err != nil {
+			app.logger.ErrorContext(ctx, err.Error())
+		}
+	})
+
+	env := rest.Envelope{"invitation": invitation}
+	app.rest.Write(w, r, "orgs.invitationsPost", http.StatusCreated, env)
+}
+
+// ============================================================================
+// DELETE
+// ============================================================================
+
+// Revokes a pending invitation for the active organization
+func (app *Orgs) invitationDelete(w http.ResponseWriter, r *http.Request) {
+	user := middleware.ContextGetUser(r)
+	membership := middleware.ContextGetMembership(r)
+
+	// Parse ID
+	id, parseErr := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if parseErr != nil || id < 1 {
+		clientError := xerrors.ClientError(
+			http.StatusNotFound,
+			"The requested resource does not exist",
+			"orgs.invitationDelete.ParseInt",
+			xerrors.ErrNotFound,
+		)
+		app.rest.Error(w, r, clientError)
+		return
+	}
+
+	// Revoke invitation
+	revoked, err := app.forOrganization(membership.OrganizationID).Invitations.Revoke(r.Context(), id)
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+	if revoked == 0 {
+		clientError := xerrors.ClientError(
+			http.StatusNotFound,
+			"The requested resource does not exist",
+			"orgs.invitationDelete.Revoke",
+			xerrors.ErrNotFound,
+		)
+		app.rest.Error(w, r, clientError)
+		return
+	}
+	app.record(r, audit.ActionInvitationRevoke, user.ID, 0, map[string]any{
+		"organization_id": membership.OrganizationID,
+		"invitation_id":   id,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ============================================================================
+// PUT
+// ============================================================================
+
+// Accepts an invitation given its token
+//
+// Existing users are added to the organization. Otherwise, a password must be
+// provided and an account is created for the invited email. Since the token
+// proves ownership of the email, the account is activated immediately.
+func (app *Orgs) acceptPut(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	// Parse request
+	if err := app.rest.Read(w, r, "orgs.acceptPut", &input); err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	var (
+		status     = http.StatusOK
+		invitation *invitations.Invitation
+		user       *users.User
+		membership *organizations.Membership
+	)
+
+	// Nothing is changed, and the invitation can be used again, unless every
+	// step succeeds
+	err := app.transaction(r.Context(), func(models *models.Models) *xerrors.AppError {
+		// Claim invitation, so concurrent accepts cannot both use it
+		var err *xerrors.AppError
+		invitation, err = models.Invitations.Claim(r.Context(), input.Token)
+		if err != nil {
+			err.If(xerrors.ErrNotFound, func(err *xerrors.AppError) {
+				err.Data = "The invitation is invalid or has been revoked"
+			})
+			return err
+		}
+
+		// Verify unexpired
+		if invitation.IsExpired() {
+			return xerrors.ClientError(
+				http.StatusGone,
+				"The invitation has expired, please ask for a new one",
+				"orgs.acceptPut.IsExpired",
+				xerrors.ErrExpired,
+			)
+		}
+
+		// Get or create the invited user
+		user, err = models.Users.GetByEmail(r.Context(), invitation.Email)
+		switch {
+		case err == nil:
+			err = activate(r.Context(), models.Users, user)
+
+		case err.Matches(xerrors.ErrNotFound):
+			status = http.StatusCreated
+			user, err = signup(r.Context(), models.Users, invitation.Email, input.Password)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Add membership
+		members := models.ForOrganization(invitation.OrganizationID).Members
+		if err := members.Add(r.Context(), user.ID, invitation.Role); err != nil {
+			return err
+		}
+
+		membership, err = members.Get(r.Context(), user.ID)
+		return err
+	})
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+	app.record(r, audit.ActionInvitationAccept, user.ID, user.ID, map[string]any{
+		"organization_id": invitation.OrganizationID,
+		"invitation_id":   invitation.ID,
+	})
+	app.record(r, audit.ActionMemberAdd, user.ID, user.ID, map[string]any{
+		"organization_id": membership.OrganizationID,
+		"role":            membership.Role,
+	})
+
+	env := rest.Envelope{"user": user, "membership": membership}
+	app.rest.Write(w, r, "orgs.acceptPut", status, env)
+}
+
+// Activates an existing user since the invitation proves their email
+func activate(ctx context.Context, repository users.UsersRepository, user *users.User) *xerrors.AppError {
+	if user.Activated {
+		return nil
+	}
+
+	user.Activated = true
+	return repository.Update(ctx, user)
+}
+
+// Creates an activated user for the invited email
+func signup(ctx context.Context, repository users.UsersRepository, email, password string) (*users.User, *xerrors.AppError) {
+	user, err := repository.New(email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Activated = true
+	if err := repository.Insert(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
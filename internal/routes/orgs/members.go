@@ -0,0 +1,25 @@
+package orgs
+
+import (
+	"net/http"
+
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/routes/middleware"
+)
+
+// ============================================================================
+// GET
+// ============================================================================
+
+// Lists the members of the active organization
+func (app *Orgs) membersGet(w http.ResponseWriter, r *http.Request) {
+	membership := middleware.ContextGetMembership(r)
+
+	members, err := app.forOrganization(membership.OrganizationID).Members.GetAll(r.Context())
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	app.rest.Write(w, r, "orgs.membersGet", http.StatusOK, rest.Envelope{"members": members})
+}
@@ -0,0 +1,64 @@
+package orgs
+
+import (
+	"context"
+	"net/http"
+
+	"go-rest-starter.jtbergman.me/internal/models/audit"
+	"go-rest-starter.jtbergman.me/internal/models/organizations"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/routes/middleware"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+)
+
+// ============================================================================
+// GET
+// ============================================================================
+
+// Lists the organizations the authenticated user is a member of
+func (app *Orgs) organizationsGet(w http.ResponseWriter, r *http.Request) {
+	user := middleware.ContextGetUser(r)
+
+	all, err := app.organizations.GetAllForUser(r.Context(), user.ID)
+	if err != nil {
+		app.rest.Error(w, r, err)
+		return
+	}
+
+	app.rest.Write(w, r, "orgs.organizationsGet", http.StatusOK, rest.Envelope{"organizations": all})
+}
+
+// ============================================================================
+// POST
+// ============================================================================
+
+// The organization sent to create one
+type organizationInput struct {
+	Name string `json:"name"`
+}
+
+// Creates an organization owned by the authenticated user
+func (app *Orgs) organizationsPost(w http.ResponseWriter, r *http.Request) {
+	user := middleware.ContextGetUser(r)
+
+	create := func(ctx context.Context, input organizationInput) (rest.Envelope, *xerrors.AppError) {
+		// Create organization
+		organization, err := app.organizations.New(input.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		// Insert organization with the user as owner
+		if err := app.organizations.Insert(ctx, organization, user.ID); err != nil {
+			return nil, err
+		}
+		app.record(r, audit.ActionMemberAdd, user.ID, user.ID, map[string]any{
+			"organization_id": organization.ID,
+			"role":            organizations.RoleOwner,
+		})
+
+		return rest.Envelope{"organization": organization}, nil
+	}
+
+	rest.Handle(app.rest, "orgs.organizationsPost", http.StatusCreated, create)(w, r)
+}
@@ -0,0 +1,171 @@
+package orgs
+
+import (
+	"context"
+	"net/http"
+
+	"go-rest-starter.jtbergman.me/internal/app"
+	"go-rest-starter.jtbergman.me/internal/mailer"
+	"go-rest-starter.jtbergman.me/internal/models"
+	"go-rest-starter.jtbergman.me/internal/models/audit"
+	"go-rest-starter.jtbergman.me/internal/models/organizations"
+	"go-rest-starter.jtbergman.me/internal/models/tokens"
+	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/routes/middleware"
+	"go-rest-starter.jtbergman.me/internal/xerrors"
+	"go-rest-starter.jtbergman.me/internal/xlogger"
+)
+
+// ============================================================================
+// Orgs Type
+// ============================================================================
+
+// Encapsulates the Application dependencies required by routes
+type Orgs struct {
+	audit           audit.AuditRepository
+	bg              app.Backgrounder
+	forOrganization func(organizationID int64) *models.Organization
+	logger          xlogger.Logger
+	mailer          mailer.Mailer
+	organizations   organizations.OrganizationsRepository
+	rest            *rest.Rest
+	tokens          tokens.TokensRepository
+	transaction     func(ctx context.Context, fn func(models *models.Models) *xerrors.AppError) *xerrors.AppError
+	users           users.UsersRepository
+}
+
+func New(app *app.App) *Orgs {
+	return &Orgs{
+		audit:           app.Models.Audit,
+		bg:              app.BG,
+		forOrganization: app.Models.ForOrganization,
+		logger:          app.Logger,
+		mailer:          app.Mailer,
+		organizations:   app.Models.Organizations,
+		rest:            app.Rest,
+		tokens:          app.Models.Tokens,
+		transaction:     app.Models.Transaction,
+		users:           app.Models.Users,
+	}
+}
+
+// ============================================================================
+// Route
+// ============================================================================
+
+func (orgs *Orgs) Route(mux *http.ServeMux, mw *middleware.Middleware) {
+	mux.HandleFunc(OrganizationsRoute, mw.Authenticated(orgs.Organizations))
+
+	mux.HandleFunc(MembersRoute, mw.RequireOrganizationRole(organizations.RoleMember, orgs.Members))
+
+	mux.HandleFunc(InvitationsRoute, mw.RequireOrganizationRole(organizations.RoleAdmin, orgs.Invitations))
+
+	mux.HandleFunc(InvitationRoute, mw.RequireOrganizationRole(organizations.RoleAdmin, orgs.Invitation))
+
+	mux.HandleFunc(AcceptRoute, orgs.Accept)
+}
+
+// ============================================================================
+// Audit
+// ============================================================================
+
+// Appends an event caused by the request to the audit log
+//
+// Failures are logged but never fail the request.
+func (app *Orgs) record(r *http.Request, action string, actorID, subjectID int64, metadata map[string]any) {
+	event := audit.NewEvent(action, actorID, subjectID, metadata).WithRequest(r)
+
+	if err := app.audit.Insert(r.Context(), event); err != nil {
+		app.logger.ErrorContext(r.Context(), err.Error())
+	}
+}
+
+// ============================================================================
+// Organizations
+// ============================================================================
+
+const OrganizationsRoute = "/v1/organizations"
+
+func (app *Orgs) Organizations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		app.organizationsGet(w, r)
+
+	case "POST":
+		app.organizationsPost(w, r)
+
+	default:
+		app.rest.MethodNotAllowed(w, r, "GET, POST")
+	}
+}
+
+// ============================================================================
+// Members
+// ============================================================================
+
+const MembersRoute = "/v1/organizations/members"
+
+func (app *Orgs) Members(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		app.membersGet(w, r)
+
+	default:
+		app.rest.MethodNotAllowed(w, r, "GET")
+	}
+}
+
+// ============================================================================
+// Invitations
+// ============================================================================
+
+const InvitationsRoute = "/v1/organizations/invitations"
+
+func (app *Orgs) Invitations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		app.invitationsGet(w, r)
+
+	case "POST":
+		app.invitationsPost(w, r)
+
+	default:
+		app.rest.MethodNotAllowed(w, r, "GET, POST")
+	}
+}
+
+// ============================================================================
+// Invitation
+// ============================================================================
+
+const InvitationRoute = "/v1/organizations/invitations/{id}"
+
+func (app *Orgs) Invitation(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "DELETE":
+		app.invitationDelete(w, r)
+
+	default:
+		app.rest.MethodNotAllowed(w, r, "DELETE")
+	}
+}
+
+// ============================================================================
+// Accept
+// ============================================================================
+
+const AcceptRoute = "/v1/organizations/invitations/accept"
+
+func (app *Orgs) Accept(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		app.rest.WriteHTML(w, r, "orgs.Accept", "static/invitation.html", middleware.ContextGetNonce(r))
+
+	case "PUT":
+		app.acceptPut(w, r)
+
+	default:
+		app.rest.MethodNotAllowed(w, r, "GET, PUT")
+	}
+}
@@ -0,0 +1,264 @@
+package orgs
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/mocks"
+	"go-rest-starter.jtbergman.me/internal/models/audit"
+	"go-rest-starter.jtbergman.me/internal/models/invitations"
+	"go-rest-starter.jtbergman.me/internal/models/organizations"
+	"go-rest-starter.jtbergman.me/internal/models/users"
+	"go-rest-starter.jtbergman.me/internal/routes/orgs"
+	"go-rest-starter.jtbergman.me/internal/routes/routestest"
+)
+
+// Helper invitation type
+type invitation struct {
+	Invitation invitations.Invitation `json:"invitation"`
+}
+
+// Helper invitations type
+type invitationList struct {
+	Invitations []invitations.Invitation `json:"invitations"`
+}
+
+// Helper accepted type
+type accepted struct {
+	User       users.User               `json:"user"`
+	Membership organizations.Membership `json:"membership"`
+}
+
+func TestInvitations(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	handler := orgsHandler(app)
+
+	// Seed - create owner, existing user and organization
+	owner := routestest.SeedUser(handler, app, "owner@example.com")
+	existing := routestest.SeedUser(handler, app, "existing@example.com")
+	organizationID := createOrganization(handler, owner)
+	assert.Check(t, organizationID > 0)
+
+	// Organization Required
+	assert.RunHandlerTestCase(t, handler, "POST", orgs.InvitationsRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:   "Invitations/OrganizationRequired",
+		Auth:   owner,
+		Body:   `{"email": "new@example.com"}`,
+		Status: http.StatusBadRequest,
+	})
+
+	// Validation
+	assert.RunHandlerTestCase(t, handler, "POST", orgs.InvitationsRoute, assert.HandlerTestCase[routestest.Failures]{
+		Name:    "Invitations/Validation",
+		Auth:    owner,
+		Body:    `{"email": "new", "role": "janitor"}`,
+		Headers: selectOrganization(organizationID),
+		Status:  http.StatusUnprocessableEntity,
+		FN: func(t *testing.T, result routestest.Failures) {
+			assert.Equal(t, result.Error["email"], "is invalid")
+			assert.Equal(t, result.Error["role"], "must be owner, admin, or member")
+		},
+	})
+
+	// Invite new user
+	assert.RunHandlerTestCase(t, handler, "POST", orgs.InvitationsRoute, assert.HandlerTestCase[invitation]{
+		Name:    "Invitations/New",
+		Auth:    owner,
+		Body:    `{"email": "new@example.com", "role": "admin"}`,
+		Headers: selectOrganization(organizationID),
+		Status:  http.StatusCreated,
+		FN: func(t *testing.T, result invitation) {
+			assert.Equal(t, result.Invitation.Email, "new@example.com")
+			assert.Equal(t, result.Invitation.Role, organizations.RoleAdmin)
+		},
+	})
+	app.BG.Wait()
+	newToken := mocks.Mailer(app).InvitationToken
+	assert.Check(t, newToken != "")
+
+	// Invite existing user
+	assert.RunHandlerTestCase(t, handler, "POST", orgs.InvitationsRoute, assert.HandlerTestCase[invitation]{
+		Name:    "Invitations/Existing",
+		Auth:    owner,
+		Body:    `{"email": "existing@example.com"}`,
+		Headers: selectOrganization(organizationID),
+		Status:  http.StatusCreated,
+		FN: func(t *testing.T, result invitation) {
+			assert.Equal(t, result.Invitation.Role, organizations.RoleMember)
+		},
+	})
+	app.BG.Wait()
+	existingToken := mocks.Mailer(app).InvitationToken
+	assert.Equal(t, mocks.Mailer(app).InvitationCount, 2)
+
+	// List pending
+	assert.RunHandlerTestCase(t, handler, "GET", orgs.InvitationsRoute, assert.HandlerTestCase[invitationList]{
+		Name:    "Invitations/List",
+		Auth:    owner,
+		Headers: selectOrganization(organizationID),
+		Status:  http.StatusOK,
+		FN: func(t *testing.T, result invitationList) {
+			assert.Equal(t, len(result.Invitations), 2)
+		},
+	})
+
+	// Accept new user requires a password
+	assert.RunHandlerTestCase(t, handler, "PUT", orgs.AcceptRoute, assert.HandlerTestCase[routestest.Failures]{
+		Name:   "Accept/PasswordRequired",
+		Body:   fmt.Sprintf(`{"token": "%s"}`, newToken),
+		Status: http.StatusUnprocessableEntity,
+	})
+
+	// Accept new user
+	assert.RunHandlerTestCase(t, handler, "PUT", orgs.AcceptRoute, assert.HandlerTestCase[accepted]{
+		Name:   "Accept/New",
+		Body:   fmt.Sprintf(`{"token": "%s", "password": "password"}`, newToken),
+		Status: http.StatusCreated,
+		FN: func(t *testing.T, result accepted) {
+			assert.True(t, result.User.Activated)
+			assert.Equal(t, result.User.Email, "new@example.com")
+			assert.Equal(t, result.Membership.OrganizationID, organizationID)
+			assert.Equal(t, result.Membership.Role, organizations.RoleAdmin)
+		},
+	})
+
+	// Accept existing user
+	assert.RunHandlerTestCase(t, handler, "PUT", orgs.AcceptRoute, assert.HandlerTestCase[accepted]{
+		Name:   "Accept/Existing",
+		Body:   fmt.Sprintf(`{"token": "%s"}`, existingToken),
+		Status: http.StatusOK,
+		FN: func(t *testing.T, result accepted) {
+			assert.Equal(t, result.User.Email, "existing@example.com")
+			assert.Equal(t, result.Membership.Role, organizations.RoleMember)
+		},
+	})
+
+	// Accepted invitations cannot be reused
+	assert.RunHandlerTestCase(t, handler, "PUT", orgs.AcceptRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:   "Accept/Reused",
+		Body:   fmt.Sprintf(`{"token": "%s"}`, existingToken),
+		Status: http.StatusNotFound,
+	})
+
+	// Members cannot manage invitations
+	assert.RunHandlerTestCase(t, handler, "GET", orgs.InvitationsRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:    "Invitations/MemberForbidden",
+		Auth:    existing,
+		Headers: selectOrganization(organizationID),
+		Status:  http.StatusForbidden,
+	})
+
+	// Invitations and memberships are audited
+	assert.Equal(t, countEvents(t, app, audit.ActionInvitationCreate), 2)
+	assert.Equal(t, countEvents(t, app, audit.ActionInvitationAccept), 2)
+	assert.Equal(t, countEvents(t, app, audit.ActionMemberAdd), 3)
+}
+
+func TestInvitationRevoke(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	handler := orgsHandler(app)
+
+	// Seed - create owner, organization and invitation
+	owner := routestest.SeedUser(handler, app, "owner@example.com")
+	organizationID := createOrganization(handler, owner)
+	assert.Check(t, organizationID > 0)
+
+	var created invitation
+	assert.RunHandlerTestCase(t, handler, "POST", orgs.InvitationsRoute, assert.HandlerTestCase[invitation]{
+		Name:    "Seed/Invitation",
+		Auth:    owner,
+		Body:    `{"email": "new@example.com"}`,
+		Headers: selectOrganization(organizationID),
+		Status:  http.StatusCreated,
+		FN: func(t *testing.T, result invitation) {
+			created = result
+		},
+	})
+	app.BG.Wait()
+	route := fmt.Sprintf("/v1/organizations/invitations/%d", created.Invitation.ID)
+
+	// Not Found
+	assert.RunHandlerTestCase(t, handler, "DELETE", "/v1/organizations/invitations/0", assert.HandlerTestCase[routestest.Failure]{
+		Name:    "Revoke/NotFound",
+		Auth:    owner,
+		Headers: selectOrganization(organizationID),
+		Status:  http.StatusNotFound,
+	})
+
+	// Success
+	assert.RunHandlerTestCase(t, handler, "DELETE", route, assert.HandlerTestCase[struct{}]{
+		Name:    "Revoke/Success",
+		Auth:    owner,
+		Headers: selectOrganization(organizationID),
+		Status:  http.StatusNoContent,
+	})
+
+	// Revoked invitations cannot be accepted
+	assert.RunHandlerTestCase(t, handler, "PUT", orgs.AcceptRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:   "Accept/Revoked",
+		Body:   fmt.Sprintf(`{"token": "%s", "password": "password"}`, mocks.Mailer(app).InvitationToken),
+		Status: http.StatusNotFound,
+		FN: func(t *testing.T, result routestest.Failure) {
+			assert.Equal(t, result.Error, "The invitation is invalid or has been revoked")
+		},
+	})
+
+	assert.Equal(t, countEvents(t, app, audit.ActionInvitationRevoke), 1)
+}
+
+func TestInvitationAccept(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	handler := orgsHandler(app)
+
+	// Seed - create owner and organization
+	owner := routestest.SeedUser(handler, app, "owner@example.com")
+	organizationID := createOrganization(handler, owner)
+	assert.Check(t, organizationID > 0)
+
+	// Invites an email as a member and returns the token
+	invite := func(email string) string {
+		body := fmt.Sprintf(`{"email": "%s", "role": "member"}`, email)
+		req := httptest.NewRequest("POST", orgs.InvitationsRoute, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+owner)
+		for key, value := range selectOrganization(organizationID) {
+			req.Header.Set(key, value)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		app.BG.Wait()
+		return mocks.Mailer(app).InvitationToken
+	}
+
+	// Accepting a lower role keeps the owner
+	assert.RunHandlerTestCase(t, handler, "PUT", orgs.AcceptRoute, assert.HandlerTestCase[accepted]{
+		Name:   "Accept/KeepsRole",
+		Body:   fmt.Sprintf(`{"token": "%s"}`, invite("owner@example.com")),
+		Status: http.StatusOK,
+		FN: func(t *testing.T, result accepted) {
+			assert.Equal(t, result.Membership.Role, organizations.RoleOwner)
+		},
+	})
+
+	// Concurrent accepts use the invitation once
+	token := invite("new@example.com")
+	statuses := make(chan int, 2)
+	for range 2 {
+		go func() {
+			body := fmt.Sprintf(`{"token": "%s", "password": "password"}`, token)
+			req := httptest.NewRequest("PUT", orgs.AcceptRoute, bytes.NewBufferString(body))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			statuses <- rr.Code
+		}()
+	}
+
+	first, second := <-statuses, <-statuses
+	assert.Equal(t, first+second, http.StatusCreated+http.StatusNotFound)
+}
@@ -0,0 +1,127 @@
+package orgs
+
+import (
+	"net/http"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/mocks"
+	"go-rest-starter.jtbergman.me/internal/models/organizations"
+	"go-rest-starter.jtbergman.me/internal/routes/orgs"
+	"go-rest-starter.jtbergman.me/internal/routes/routestest"
+)
+
+func TestOrganizations(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	handler := orgsHandler(app)
+
+	// Seed - create owner and outsider
+	owner := routestest.SeedUser(handler, app, "owner@example.com")
+	outsider := routestest.SeedUser(handler, app, "outsider@example.com")
+	assert.Check(t, owner != "" && outsider != "")
+
+	// Auth Required
+	assert.RunHandlerTestCase(t, handler, "POST", orgs.OrganizationsRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:   "Organizations/AuthRequired",
+		Body:   `{"name": "Acme"}`,
+		Status: http.StatusUnauthorized,
+	})
+
+	// Validation
+	assert.RunHandlerTestCase(t, handler, "POST", orgs.OrganizationsRoute, assert.HandlerTestCase[struct{}]{
+		Name:   "Organizations/Validation",
+		Auth:   owner,
+		Body:   `{"name": "  "}`,
+		Status: http.StatusUnprocessableEntity,
+	})
+
+	// Create
+	var organizationID int64
+	assert.RunHandlerTestCase(t, handler, "POST", orgs.OrganizationsRoute, assert.HandlerTestCase[organization]{
+		Name:   "Organizations/Create",
+		Auth:   owner,
+		Body:   `{"name": "Acme"}`,
+		Status: http.StatusCreated,
+		FN: func(t *testing.T, result organization) {
+			organizationID = result.Organization.ID
+			assert.True(t, organizationID > 0)
+			assert.Equal(t, result.Organization.Name, "Acme")
+		},
+	})
+
+	// List
+	assert.RunHandlerTestCase(t, handler, "GET", orgs.OrganizationsRoute, assert.HandlerTestCase[organizationList]{
+		Name:   "Organizations/List",
+		Auth:   owner,
+		Status: http.StatusOK,
+		FN: func(t *testing.T, result organizationList) {
+			assert.Check(t, len(result.Organizations) == 1)
+			assert.Equal(t, result.Organizations[0].ID, organizationID)
+		},
+	})
+
+	// List (Outsider)
+	assert.RunHandlerTestCase(t, handler, "GET", orgs.OrganizationsRoute, assert.HandlerTestCase[organizationList]{
+		Name:   "Organizations/ListOutsider",
+		Auth:   outsider,
+		Status: http.StatusOK,
+		FN: func(t *testing.T, result organizationList) {
+			assert.Equal(t, len(result.Organizations), 0)
+		},
+	})
+}
+
+func TestMembers(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	handler := orgsHandler(app)
+
+	// Seed - create owner, outsider and organization
+	owner := routestest.SeedUser(handler, app, "owner@example.com")
+	outsider := routestest.SeedUser(handler, app, "outsider@example.com")
+	organizationID := createOrganization(handler, owner)
+	assert.Check(t, organizationID > 0)
+
+	// Organization Required
+	assert.RunHandlerTestCase(t, handler, "GET", orgs.MembersRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:   "Members/OrganizationRequired",
+		Auth:   owner,
+		Status: http.StatusBadRequest,
+	})
+
+	// Malformed Header
+	assert.RunHandlerTestCase(t, handler, "GET", orgs.MembersRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:    "Members/MalformedHeader",
+		Auth:    owner,
+		Headers: map[string]string{"X-Organization-ID": "acme"},
+		Status:  http.StatusBadRequest,
+	})
+
+	// Not A Member
+	assert.RunHandlerTestCase(t, handler, "GET", orgs.MembersRoute, assert.HandlerTestCase[routestest.Failure]{
+		Name:    "Members/NotAMember",
+		Auth:    outsider,
+		Headers: selectOrganization(organizationID),
+		Status:  http.StatusNotFound,
+	})
+
+	// Success
+	assert.RunHandlerTestCase(t, handler, "GET", orgs.MembersRoute, assert.HandlerTestCase[memberList]{
+		Name:    "Members/Success",
+		Auth:    owner,
+		Headers: selectOrganization(organizationID),
+		Status:  http.StatusOK,
+		FN: func(t *testing.T, result memberList) {
+			assert.Check(t, len(result.Members) == 1)
+			assert.Equal(t, result.Members[0].Role, organizations.RoleOwner)
+		},
+	})
+}
+
+// Helper to create an organization and return its ID
+func createOrganization(handler http.HandlerFunc, token string) int64 {
+	var result organization
+	routestest.SendRequest(handler, "POST", orgs.OrganizationsRoute, `{"name": "Acme"}`, &result, token)
+	return result.Organization.ID
+}
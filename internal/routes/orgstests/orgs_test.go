@@ -0,0 +1,65 @@
+package orgs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/app"
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/models/audit"
+	"go-rest-starter.jtbergman.me/internal/models/organizations"
+	"go-rest-starter.jtbergman.me/internal/routes/auth"
+	"go-rest-starter.jtbergman.me/internal/routes/middleware"
+	"go-rest-starter.jtbergman.me/internal/routes/orgs"
+)
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// Creates a complete Orgs handler including Auth routes and middleware
+func orgsHandler(app *app.App) http.HandlerFunc {
+	handler := func() http.Handler {
+		mux := http.NewServeMux()
+
+		middleware := middleware.New(app)
+		auth.New(app).Route(mux, middleware)
+		orgs.New(app).Route(mux, middleware)
+
+		return middleware.User(middleware.Organization(mux))
+	}()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}
+}
+
+// Helper organization type
+type organization struct {
+	Organization organizations.Organization `json:"organization"`
+}
+
+// Helper organizations type
+type organizationList struct {
+	Organizations []organizations.Organization `json:"organizations"`
+}
+
+// Helper members type
+type memberList struct {
+	Members []organizations.Membership `json:"members"`
+}
+
+// Helper to select the active organization
+func selectOrganization(id int64) map[string]string {
+	return map[string]string{middleware.OrganizationHeader: fmt.Sprint(id)}
+}
+
+// Counts the audit events with an action
+func countEvents(t *testing.T, app *app.App, action string) int {
+	filters := audit.Filters{Action: action, Page: 1, PageSize: 100}
+	events, _, err := app.Models.Audit.GetAll(context.Background(), filters)
+	assert.Check(t, err == nil)
+	return len(events)
+}
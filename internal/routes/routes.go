@@ -3,11 +3,16 @@ package routes
 import (
 	"expvar"
 	"net/http"
+	"time"
 
 	"go-rest-starter.jtbergman.me/internal/app"
 	"go-rest-starter.jtbergman.me/internal/models/permissions"
+	"go-rest-starter.jtbergman.me/internal/ratelimit"
+	"go-rest-starter.jtbergman.me/internal/routes/admin"
 	"go-rest-starter.jtbergman.me/internal/routes/auth"
+	"go-rest-starter.jtbergman.me/internal/routes/events"
 	"go-rest-starter.jtbergman.me/internal/routes/middleware"
+	"go-rest-starter.jtbergman.me/internal/routes/orgs"
 )
 
 // Add all routes
@@ -16,10 +21,16 @@ func Mux(app *app.App) http.Handler {
 
 	// Routes
 	middleware := middleware.New(app)
+	admin := admin.New(app)
 	auth := auth.New(app)
+	events := events.New(app)
+	orgs := orgs.New(app)
 
 	// Register
+	admin.Route(mux, middleware)
 	auth.Route(mux, middleware)
+	events.Route(mux, middleware)
+	orgs.Route(mux, middleware)
 
 	// Example permission check
 	mux.Handle(
@@ -32,10 +43,142 @@ func Mux(app *app.App) http.Handler {
 		),
 	)
 
-	// All requests should recover panics and have a User
-	return middleware.RecoverPanic(
-		middleware.Requests(
-			middleware.User(mux),
+	// Metrics for admins, unless they are served on a separate listener
+	if app.Config.Metrics.Addr == "" && app.Config.Metrics.Path != "" {
+		mux.Handle(
+			"GET "+app.Config.Metrics.Path,
+			middleware.RequirePermission(permissions.PermissionAdmin, app.Metrics.Handler().ServeHTTP),
+		)
+	}
+
+	// Rate limits, timeouts, and preconditions
+	ipLimits := ipLimits(app, mux)
+	limits := rateLimits(app, mux)
+	timeouts := timeouts(app, mux)
+	preconditions := preconditions(mux)
+
+	// All requests should have an ID and security headers, be compressed,
+	// traced, logged, and measured, recover panics, have a deadline, answer
+	// CORS preflights, be limited by IP, have a User, be rate limited, be
+	// conditional, replay retried POSTs, and have a Membership
+	return middleware.RequestID(
+		middleware.SecurityHeaders(
+			middleware.Compress(
+				app.Config.Compression.MinSize,
+				middleware.Trace(
+					mux,
+					middleware.Requests(
+						middleware.Metrics(
+							mux,
+							middleware.RecoverPanic(
+								middleware.Timeout(
+									timeouts,
+									middleware.CORS(
+										middleware.RateLimit(
+											ipLimits,
+											middleware.User(
+												middleware.RateLimit(
+													limits,
+													middleware.Conditional(
+														preconditions,
+														middleware.Idempotency(
+															middleware.Organization(mux),
+														),
+													),
+												),
+											),
+										),
+									),
+								),
+							),
+						),
+					),
+				),
+			),
 		),
 	)
 }
+
+// Configures the limit every client shares before authentication
+//
+// User looks up bearer tokens in the database, so requests with invalid
+// tokens are limited by IP before they reach it.
+func ipLimits(app *app.App, mux *http.ServeMux) middleware.RateLimits {
+	return middleware.RateLimits{
+		Default: middleware.RateLimit{
+			Limit: ratelimit.Limit{Burst: 600, Period: time.Minute},
+			Key:   middleware.KeyByIP,
+		},
+		Mux:   mux,
+		Name:  "ip",
+		Store: app.Limits,
+	}
+}
+
+// Configures the rate limits for every route
+//
+// Routes without an entry share the default limit.
+func rateLimits(app *app.App, mux *http.ServeMux) middleware.RateLimits {
+	return middleware.RateLimits{
+		Default: middleware.RateLimit{
+			Limit: ratelimit.Limit{Burst: 120, Period: time.Minute},
+			Key:   middleware.KeyByUser,
+		},
+		Mux: mux,
+		Routes: map[string]middleware.RateLimit{
+			// Credentials and emails are limited by IP to slow down guessing
+			auth.LoginRoute: {
+				Limit: ratelimit.Limit{Burst: 10, Period: time.Minute},
+				Key:   middleware.KeyByIP,
+			},
+			auth.RegisterRoute: {
+				Limit: ratelimit.Limit{Burst: 5, Period: time.Minute},
+				Key:   middleware.KeyByIP,
+			},
+			auth.ResetRoute: {
+				Limit: ratelimit.Limit{Burst: 5, Period: time.Minute},
+				Key:   middleware.KeyByIP,
+			},
+			orgs.InvitationsRoute: {
+				Limit: ratelimit.Limit{Burst: 20, Period: time.Hour},
+				Key:   middleware.KeyByUser,
+			},
+		},
+		Store: app.Limits,
+	}
+}
+
+// Configures the request timeout for every route
+//
+// Routes without an entry use the -request-timeout flag. Timeouts must stay
+// below config.ServerWriteTimeout so clients receive the error response,
+// unless the route streams its response and lifts the write timeout itself.
+func timeouts(app *app.App, mux *http.ServeMux) middleware.Timeouts {
+	return middleware.Timeouts{
+		Default: app.Config.Timeout.Request,
+		Mux:     mux,
+		Routes: map[string]time.Duration{
+			// Filtering and counting the audit log can be slow
+			admin.AuditRoute: 8 * time.Second,
+
+			// Exports are streamed, and the stream extends the write timeout
+			admin.UsersExportRoute: 2 * time.Minute,
+
+			// Event streams stay open until the client disconnects
+			events.EventsRoute: 0,
+		},
+	}
+}
+
+// Configures the routes that require If-Match on unsafe requests
+//
+// Clients get the ETag from a GET and send it back, so an update fails with
+// a 412 instead of overwriting changes the client has not seen.
+func preconditions(mux *http.ServeMux) middleware.Preconditions {
+	return middleware.Preconditions{
+		Mux: mux,
+		Routes: map[string]bool{
+			admin.UserRoute: true,
+		},
+	}
+}
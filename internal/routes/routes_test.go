@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-rest-starter.jtbergman.me/internal/app"
+	"go-rest-starter.jtbergman.me/internal/assert"
+	"go-rest-starter.jtbergman.me/internal/mocks"
+	"go-rest-starter.jtbergman.me/internal/models/permissions"
+	"go-rest-starter.jtbergman.me/internal/models/tokens"
+	"go-rest-starter.jtbergman.me/internal/rest"
+	"go-rest-starter.jtbergman.me/internal/routes/orgs"
+)
+
+func TestMetrics(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	app.Config.Metrics.Path = "/metrics"
+	handler := Mux(app).ServeHTTP
+
+	user := seed(t, app, "user@example.com")
+	admin := seed(t, app, "admin@example.com", permissions.PermissionAdmin)
+
+	assert.RunHandlerTestCase(t, handler, "GET", "/metrics", assert.HandlerTestCase[struct{}]{
+		Name:   "Metrics/Anonymous",
+		Status: http.StatusUnauthorized,
+	})
+
+	assert.RunHandlerTestCase(t, handler, "GET", "/metrics", assert.HandlerTestCase[struct{}]{
+		Name:   "Metrics/User",
+		Auth:   user,
+		Status: http.StatusForbidden,
+	})
+
+	assert.RunHandlerTestCase(t, handler, "GET", "/metrics", assert.HandlerTestCase[struct{}]{
+		Name:   "Metrics/Admin",
+		Auth:   admin,
+		Status: http.StatusOK,
+		ResponseHeaders: map[string]string{
+			"Content-Type": "text/plain; version=0.0.4; charset=utf-8",
+		},
+	})
+}
+
+// Handlers adapted with rest.Handle behave like the others behind the full
+// middleware chain
+func TestHandle(t *testing.T) {
+	assert.Integration(t)
+	app := mocks.App(t)
+	handler := Mux(app).ServeHTTP
+	token := seed(t, app, "user@example.com")
+
+	assert.RunHandlerTestCase(t, handler, "POST", orgs.OrganizationsRoute, assert.HandlerTestCase[struct{}]{
+		Name:   "Handle/Created",
+		Auth:   token,
+		Body:   `{"name": "Acme"}`,
+		Status: http.StatusCreated,
+	})
+
+	t.Run("Handle/Problem", func(t *testing.T) {
+		req := httptest.NewRequest("POST", orgs.OrganizationsRoute, strings.NewReader(`{"name": `))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", rest.ProblemContentType)
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+
+		var problem rest.Problem
+		assert.Check(t, json.NewDecoder(rr.Body).Decode(&problem) == nil)
+		assert.Equal(t, rr.Code, http.StatusBadRequest)
+		assert.Equal(t, rr.Header().Get("Content-Type"), rest.ProblemContentType)
+		assert.Equal(t, problem.Status, http.StatusBadRequest)
+		assert.NotEqual(t, problem.RequestID, "")
+		assert.Equal(t, problem.RequestID, rr.Header().Get(rest.RequestIDHeader))
+	})
+
+	t.Run("Handle/Validation", func(t *testing.T) {
+		req := httptest.NewRequest("POST", orgs.OrganizationsRoute, strings.NewReader(`{"name": "  "}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+
+		var result struct {
+			Error     map[string]string `json:"error"`
+			RequestID string            `json:"request_id"`
+		}
+		assert.Check(t, json.NewDecoder(rr.Body).Decode(&result) == nil)
+		assert.Equal(t, rr.Code, http.StatusUnprocessableEntity)
+		assert.NotEqual(t, result.Error["name"], "")
+		assert.Equal(t, result.RequestID, rr.Header().Get(rest.RequestIDHeader))
+	})
+}
+
+// ============================================================================
+// Seeds
+// ============================================================================
+
+// Creates an activated user with the permissions and returns an
+// authentication token
+func seed(t *testing.T, app *app.App, email string, codes ...string) string {
+	ctx := context.Background()
+
+	user, err := app.Models.Users.New(email, "password")
+	assert.Check(t, err == nil)
+	user.Activated = true
+	assert.Check(t, app.Models.Users.Insert(ctx, user) == nil)
+
+	if len(codes) > 0 {
+		_, err = app.Models.Permissions.Insert(ctx, user.ID, codes...)
+		assert.Check(t, err == nil)
+	}
+
+	token, err := app.Models.Tokens.New(user.ID, time.Hour, tokens.ScopeAuthentication)
+	assert.Check(t, err == nil)
+	_, err = app.Models.Tokens.Insert(ctx, token)
+	assert.Check(t, err == nil)
+
+	return token.Plaintext
+}
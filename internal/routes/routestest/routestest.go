@@ -0,0 +1,91 @@
+// routestest provides the seeds and request helpers shared by route tests
+package routestest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"go-rest-starter.jtbergman.me/internal/app"
+	"go-rest-starter.jtbergman.me/internal/mocks"
+	"go-rest-starter.jtbergman.me/internal/models/permissions"
+	"go-rest-starter.jtbergman.me/internal/routes/auth"
+)
+
+// ============================================================================
+// Responses
+// ============================================================================
+
+// An error response with a message
+type Failure struct {
+	Error string `json:"error"`
+}
+
+// An error response with a message for each invalid field
+type Failures struct {
+	Error map[string]string `json:"error"`
+}
+
+// ============================================================================
+// Seeds
+// ============================================================================
+
+// Registers, activates, and logs in a user, returning the token
+//
+// The handler must serve the Auth routes.
+func SeedUser(handler http.HandlerFunc, app *app.App, email string) string {
+	credentials := fmt.Sprintf(`{"email": "%s", "password": "password"}`, email)
+	SendRequest(handler, "POST", auth.RegisterRoute, credentials, nil, "")
+
+	app.BG.Wait()
+	body := fmt.Sprintf(`{"token": "%s"}`, mocks.Mailer(app).WelcomeActivationToken)
+	SendRequest(handler, "PUT", auth.ActivateRoute, body, nil, "")
+
+	var result struct {
+		Token string `json:"token"`
+	}
+	SendRequest(handler, "POST", auth.LoginRoute, credentials, &result, "")
+	return result.Token
+}
+
+// Seeds a user with the admin permission, returning the token or an empty
+// string if the permission could not be granted
+func SeedAdmin(handler http.HandlerFunc, app *app.App, email string) string {
+	token := SeedUser(handler, app, email)
+
+	user, err := app.Models.Users.GetByToken(context.Background(), token)
+	if err != nil {
+		return ""
+	}
+
+	if _, err := app.Models.Permissions.Insert(context.Background(), user.ID, permissions.PermissionAdmin); err != nil {
+		return ""
+	}
+
+	return token
+}
+
+// ============================================================================
+// Requests
+// ============================================================================
+
+// Sends a request, authenticated if token is not empty, and decodes the
+// result into dst if provided
+func SendRequest(handler http.HandlerFunc, method, route, body string, dst any, token string) int {
+	req := httptest.NewRequest(method, route, bytes.NewBufferString(body))
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	resp := rr.Result()
+	defer resp.Body.Close()
+
+	if dst != nil {
+		json.NewDecoder(resp.Body).Decode(dst)
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// JSON
+// ============================================================================
+
+// Writes each span as a line of JSON, e.g. to stdout
+type JSONExporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// Creates an exporter that writes to w
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{enc: json.NewEncoder(w)}
+}
+
+// The JSON representation of a span
+type jsonSpan struct {
+	Name       string         `json:"name"`
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	ParentID   string         `json:"parent_id,omitempty"`
+	Start      time.Time      `json:"start"`
+	DurationMS float64        `json:"duration_ms"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+func (e *JSONExporter) Export(span *Span) {
+	out := jsonSpan{
+		Name:       span.Name,
+		TraceID:    span.TraceID.String(),
+		SpanID:     span.SpanID.String(),
+		Start:      span.StartTime,
+		DurationMS: float64(span.EndTime.Sub(span.StartTime).Microseconds()) / 1000,
+		Attributes: span.Attributes,
+		Error:      span.Error,
+	}
+	if span.ParentID.IsValid() {
+		out.ParentID = span.ParentID.String()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.enc.Encode(out)
+}
+
+// ============================================================================
+// Memory
+// ============================================================================
+
+// Keeps ended spans in memory so tests can inspect them
+type MemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// Creates an empty MemoryExporter
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+func (e *MemoryExporter) Export(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Returns the spans exported so far in the order they ended
+func (e *MemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Span{}, e.spans...)
+}
+
+// Returns the exported spans with the given name
+func (e *MemoryExporter) Named(name string) []*Span {
+	named := []*Span{}
+	for _, span := range e.Spans() {
+		if span.Name == name {
+			named = append(named, span)
+		}
+	}
+	return named
+}
+
+// Removes all exported spans
+func (e *MemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
@@ -0,0 +1,225 @@
+// tracing provides spans carried via context and exported when they end
+//
+// A Tracer starts root spans, usually one per HTTP request. Code further down
+// the call stack calls Start with the request context to create child spans
+// and does not need a Tracer. Without a span in the context, Start returns a
+// nil *Span, and every Span method is safe to call on nil, so tracing can be
+// disabled without changing callers.
+//
+// Traces are propagated between services with the W3C traceparent header.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// IDs
+// ============================================================================
+
+// Identifies a trace across services
+type TraceID [16]byte
+
+// Identifies a span within a trace
+type SpanID [8]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// Identifies a span and whether it is sampled, which is what crosses process
+// boundaries
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// Returns true if both IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// ============================================================================
+// Tracer
+// ============================================================================
+
+// Defines where ended spans are sent
+//
+// Export is called synchronously when a span ends, so implementations should
+// be fast and must be safe for concurrent use.
+type Exporter interface {
+	Export(span *Span)
+}
+
+// Starts spans and sends them to an exporter
+type Tracer struct {
+	exporter Exporter
+}
+
+// Creates a tracer that sends sampled spans to exporter
+func New(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Returns the exporter spans are sent to
+func (t *Tracer) Exporter() Exporter {
+	return t.exporter
+}
+
+// Starts a span as a child of the span or remote span context in ctx, or as
+// the root of a new trace, and returns a context carrying it
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	span := &Span{
+		Name:       name,
+		StartTime:  time.Now(),
+		Attributes: map[string]any{},
+		tracer:     t,
+	}
+
+	switch parent := SpanFromContext(ctx); {
+	case parent != nil:
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+		span.sampled = parent.sampled
+
+	case remoteFromContext(ctx).IsValid():
+		remote := remoteFromContext(ctx)
+		span.TraceID = remote.TraceID
+		span.ParentID = remote.SpanID
+		span.sampled = remote.Sampled
+
+	default:
+		rand.Read(span.TraceID[:])
+		span.sampled = true
+	}
+	rand.Read(span.SpanID[:])
+
+	return ContextWithSpan(ctx, span), span
+}
+
+// Starts a child of the span in ctx. Returns a nil span, which is safe to
+// use, if there is no span in ctx.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+
+	return parent.tracer.Start(ctx, name)
+}
+
+// ============================================================================
+// Span
+// ============================================================================
+
+// Describes a single operation within a trace
+type Span struct {
+	Name       string
+	TraceID    TraceID
+	SpanID     SpanID
+	ParentID   SpanID
+	StartTime  time.Time
+	EndTime    time.Time
+	Attributes map[string]any
+	Error      string
+
+	mu      sync.Mutex
+	ended   bool
+	sampled bool
+	tracer  *Tracer
+}
+
+// Returns the span context to propagate to other services
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return SpanContext{TraceID: s.TraceID, SpanID: s.SpanID, Sampled: s.sampled}
+}
+
+// Sets an attribute describing the operation
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes[key] = value
+}
+
+// Marks the span as failed
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Error = err.Error()
+}
+
+// Ends the span and exports it if sampled. Calling End more than once has no
+// effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+
+	if s.sampled && s.tracer.exporter != nil {
+		s.tracer.exporter.Export(s)
+	}
+}
+
+// ============================================================================
+// Context
+// ============================================================================
+
+// A custom contextKey type to prevent key collisions
+type contextKey string
+
+const (
+	spanContextKey   = contextKey("span")
+	remoteContextKey = contextKey("remote")
+)
+
+// Returns a copy of ctx carrying the span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey, span)
+}
+
+// Retrieves the current span or nil if there is none
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey).(*Span)
+	return span
+}
+
+// Returns a copy of ctx carrying a span context received from another
+// service, which becomes the parent of the next span started by a Tracer
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteContextKey, sc)
+}
+
+// Retrieves the remote span context or an invalid one if there is none
+func remoteFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(remoteContextKey).(SpanContext)
+	return sc
+}
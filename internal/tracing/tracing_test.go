@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+)
+
+func TestSpans(t *testing.T) {
+	exporter := NewMemoryExporter()
+	tracer := New(exporter)
+
+	t.Run("Children", func(t *testing.T) {
+		exporter.Reset()
+
+		ctx, root := tracer.Start(context.Background(), "root")
+		_, child := Start(ctx, "child")
+		child.SetAttribute("key", "value")
+		child.RecordError(errors.New("failed"))
+		child.End()
+		root.End()
+		root.End()
+
+		spans := exporter.Spans()
+		assert.Check(t, len(spans) == 2)
+		assert.Equal(t, spans[0].Name, "child")
+		assert.Equal(t, spans[0].TraceID, root.TraceID)
+		assert.Equal(t, spans[0].ParentID, root.SpanID)
+		assert.Equal(t, spans[0].Attributes["key"].(string), "value")
+		assert.Equal(t, spans[0].Error, "failed")
+		assert.False(t, spans[1].ParentID.IsValid())
+	})
+
+	t.Run("NoTracer", func(t *testing.T) {
+		exporter.Reset()
+
+		ctx, span := Start(context.Background(), "orphan")
+		span.SetAttribute("key", "value")
+		span.RecordError(errors.New("failed"))
+		span.End()
+
+		assert.True(t, span == nil)
+		assert.True(t, SpanFromContext(ctx) == nil)
+		assert.Equal(t, len(exporter.Spans()), 0)
+	})
+
+	t.Run("Remote", func(t *testing.T) {
+		exporter.Reset()
+
+		header := http.Header{}
+		header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		remote, ok := Extract(header)
+		assert.Check(t, ok)
+
+		_, span := tracer.Start(ContextWithRemote(context.Background(), remote), "server")
+		span.End()
+
+		assert.Equal(t, span.TraceID.String(), "4bf92f3577b34da6a3ce929d0e0e4736")
+		assert.Equal(t, span.ParentID.String(), "00f067aa0ba902b7")
+		assert.Equal(t, len(exporter.Spans()), 1)
+	})
+
+	t.Run("NotSampled", func(t *testing.T) {
+		exporter.Reset()
+
+		remote := SpanContext{TraceID: TraceID{1}, SpanID: SpanID{1}, Sampled: false}
+		ctx, span := tracer.Start(ContextWithRemote(context.Background(), remote), "server")
+		_, child := Start(ctx, "child")
+		child.End()
+		span.End()
+
+		assert.Equal(t, len(exporter.Spans()), 0)
+	})
+}
+
+func TestPropagation(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		valid   bool
+		sampled bool
+	}{
+		{name: "Sampled", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", valid: true, sampled: true},
+		{name: "NotSampled", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", valid: true},
+		{name: "FutureVersion", header: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", valid: true, sampled: true},
+		{name: "Missing", header: ""},
+		{name: "InvalidVersion", header: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "ExtraFields", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{name: "ZeroTraceID", header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "ZeroSpanID", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{name: "Uppercase", header: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{name: "Short", header: "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(TraceparentHeader, tt.header)
+
+			sc, ok := Extract(header)
+			assert.Equal(t, ok, tt.valid)
+			assert.Equal(t, sc.Sampled, tt.sampled)
+		})
+	}
+
+	t.Run("Inject", func(t *testing.T) {
+		ctx, span := New(nil).Start(context.Background(), "client")
+		header := http.Header{}
+		Inject(ctx, header)
+
+		sc, ok := Extract(header)
+		assert.Check(t, ok)
+		assert.Equal(t, sc, span.Context())
+	})
+}
+
+func TestJSONExporter(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := New(NewJSONExporter(&buf))
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := Start(ctx, "child")
+	child.SetAttribute("db.rows", 2)
+	child.End()
+	root.End()
+
+	dec := json.NewDecoder(&buf)
+	var first, second map[string]any
+	assert.Check(t, dec.Decode(&first) == nil)
+	assert.Check(t, dec.Decode(&second) == nil)
+
+	assert.Equal(t, first["name"].(string), "child")
+	assert.Equal(t, first["parent_id"].(string), root.SpanID.String())
+	assert.Equal(t, first["attributes"].(map[string]any)["db.rows"].(float64), 2)
+	assert.Equal(t, second["trace_id"].(string), root.TraceID.String())
+	assert.True(t, second["parent_id"] == nil)
+}
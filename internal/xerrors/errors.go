@@ -5,10 +5,14 @@
 package xerrors
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/lib/pq"
 )
@@ -29,11 +33,30 @@ var (
 
 // Abstract errors from the api into easy-to-check types
 var (
-	ErrBadRequest       = errors.New("bad_request")
-	ErrEntityTooLarge   = errors.New("entity_too_large")
-	ErrFailedValidation = errors.New("failed_validation")
-	ErrUnauthenticated  = errors.New("unauthenticated")
-	ErrUnauthorized     = errors.New("unauthorized")
+	ErrBadRequest           = errors.New("bad_request")
+	ErrCanceled             = errors.New("canceled")
+	ErrConflict             = errors.New("conflict")
+	ErrEditConflict         = errors.New("edit_conflict")
+	ErrEntityTooLarge       = errors.New("entity_too_large")
+	ErrExpired              = errors.New("expired")
+	ErrFailedValidation     = errors.New("failed_validation")
+	ErrForbidden            = errors.New("forbidden")
+	ErrIdempotencyKeyReused = errors.New("idempotency_key_reused")
+	ErrInvalidPatch         = errors.New("invalid_patch")
+	ErrNotAcceptable        = errors.New("not_acceptable")
+	ErrPreconditionFailed   = errors.New("precondition_failed")
+	ErrPreconditionRequired = errors.New("precondition_required")
+	ErrRateLimited          = errors.New("rate_limited")
+	ErrTimeout              = errors.New("timeout")
+	ErrUnauthenticated      = errors.New("unauthenticated")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrUnsupportedMediaType = errors.New("unsupported_media_type")
+)
+
+// Bearer token error codes used in WWW-Authenticate challenges (RFC 6750)
+const (
+	BearerInvalidToken      = "invalid_token"
+	BearerInsufficientScope = "insufficient_scope"
 )
 
 // Abstracts unknown errors
@@ -42,6 +65,36 @@ var (
 	ErrMailerInternal = errors.New("mailer_error")
 )
 
+// The sentinels whose text is used as an error code, see AppError.Code
+var codes = []error{
+	ErrCheckViolation,
+	ErrDeadlockDetected,
+	ErrForeignKeyViolation,
+	ErrNotFound,
+	ErrNullViolation,
+	ErrUniqueViolation,
+	ErrBadRequest,
+	ErrCanceled,
+	ErrConflict,
+	ErrEditConflict,
+	ErrEntityTooLarge,
+	ErrExpired,
+	ErrFailedValidation,
+	ErrForbidden,
+	ErrIdempotencyKeyReused,
+	ErrInvalidPatch,
+	ErrNotAcceptable,
+	ErrPreconditionFailed,
+	ErrPreconditionRequired,
+	ErrRateLimited,
+	ErrTimeout,
+	ErrUnauthenticated,
+	ErrUnauthorized,
+	ErrUnsupportedMediaType,
+	ErrServerInternal,
+	ErrMailerInternal,
+}
+
 // ============================================================================
 // Type
 // ============================================================================
@@ -52,6 +105,23 @@ type AppError struct {
 	Data       any
 	Op         string
 	Err        error
+	Header     http.Header
+}
+
+// Adds a Bearer WWW-Authenticate challenge to the error. An empty code means
+// no credentials were provided, so no error code is included (RFC 6750).
+func (e *AppError) Challenge(code string) *AppError {
+	if e.Header == nil {
+		e.Header = http.Header{}
+	}
+
+	if code == "" {
+		e.Header.Set("WWW-Authenticate", "Bearer")
+	} else {
+		e.Header.Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="%s"`, code))
+	}
+
+	return e
 }
 
 // If an error is a specific error type, replace the data
@@ -66,6 +136,23 @@ func (e *AppError) Matches(target error) bool {
 	return errors.Is(e, target)
 }
 
+// Returns a stable, machine-readable code for the error, e.g. "not_found"
+//
+// The code is the text of the sentinel the error wraps. Errors without a
+// sentinel are "server_error" or "bad_request" depending on the status.
+func (e *AppError) Code() string {
+	for _, code := range codes {
+		if errors.Is(e.Err, code) {
+			return code.Error()
+		}
+	}
+
+	if e.StatusCode >= http.StatusInternalServerError {
+		return ErrServerInternal.Error()
+	}
+	return ErrBadRequest.Error()
+}
+
 // Supports unwrapping the errors for use with errors.Is(...)
 func (e *AppError) Unwrap() error {
 	return e.Err
@@ -91,6 +178,9 @@ func ClientError(statusCode int, data any, op string, err error) *AppError {
 }
 
 // Returns an authorization error if the value is true
+//
+// Use this when the client is not authenticated. The response includes a
+// WWW-Authenticate challenge so clients know to log in.
 func ClientUnauthorized(value bool, op string) *AppError {
 	if value {
 		return ClientError(
@@ -98,11 +188,65 @@ func ClientUnauthorized(value bool, op string) *AppError {
 			"Authorization required",
 			op,
 			ErrUnauthorized,
+		).Challenge("")
+	}
+	return nil
+}
+
+// Returns an authorization error for a token that is malformed, expired, or
+// revoked, which tells clients to log in again
+func ClientInvalidToken(op string) *AppError {
+	return ClientError(
+		http.StatusUnauthorized,
+		"Auth token is invalid",
+		op,
+		ErrUnauthorized,
+	).Challenge(BearerInvalidToken)
+}
+
+// Returns a forbidden error if the value is true
+//
+// Use this when the client is authenticated but not allowed to perform the
+// request. Logging in again will not help, so clients should not retry.
+func ClientForbidden(value bool, op string) *AppError {
+	if value {
+		return ClientError(
+			http.StatusForbidden,
+			"You do not have permission to perform this action",
+			op,
+			ErrForbidden,
 		)
 	}
 	return nil
 }
 
+// Returns a too many requests error telling the client when to retry
+//
+// The Retry-After header is rounded up to whole seconds so clients that
+// honor it never retry early.
+func ClientRateLimited(retryAfter time.Duration, op string) *AppError {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+
+	return &AppError{
+		StatusCode: http.StatusTooManyRequests,
+		Data:       "Rate limit exceeded, please try again later",
+		Op:         op,
+		Err:        ErrRateLimited,
+		Header:     http.Header{"Retry-After": {strconv.Itoa(max(seconds, 1))}},
+	}
+}
+
+// Creates a conflict error for an update that lost a race, i.e. the version
+// it read changed before it was written
+func ClientEditConflict(op string) *AppError {
+	return ClientError(
+		http.StatusConflict,
+		"The resource was modified by another request, please try again",
+		op,
+		ErrEditConflict,
+	)
+}
+
 // Creates a server error with the appropriate status code and message
 func ServerError(op string, err error) *AppError {
 	return &AppError{
@@ -113,6 +257,27 @@ func ServerError(op string, err error) *AppError {
 	}
 }
 
+// Creates an error for a request that ran past its deadline
+func ServerTimeout(op string, err error) *AppError {
+	return &AppError{
+		StatusCode: http.StatusGatewayTimeout,
+		Data:       "The request took too long to complete",
+		Op:         op,
+		Err:        fmt.Errorf("%w: %v", ErrTimeout, err),
+	}
+}
+
+// Creates an error for a request that was canceled before it completed, e.g.
+// because the client disconnected or the server is shutting down
+func ServerUnavailable(op string, err error) *AppError {
+	return &AppError{
+		StatusCode: http.StatusServiceUnavailable,
+		Data:       "The request could not be completed, please try again",
+		Op:         op,
+		Err:        fmt.Errorf("%w: %v", ErrCanceled, err),
+	}
+}
+
 // ============================================================================
 // Database Errors
 // ============================================================================
@@ -128,6 +293,14 @@ func DatabaseError(err error, op string) *AppError {
 		)
 	}
 
+	// The request context ended, either at its deadline or on cancellation
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ServerTimeout(op, err)
+	}
+	if errors.Is(err, context.Canceled) {
+		return ServerUnavailable(op, err)
+	}
+
 	if pqErr, ok := err.(*pq.Error); ok {
 		switch pqErr.Code {
 		case "23505":
@@ -162,6 +335,10 @@ func DatabaseError(err error, op string) *AppError {
 				fmt.Errorf("%w: %v", ErrCheckViolation, err),
 			)
 
+		case "57014":
+			// Postgres canceled the query because its context ended
+			return ServerTimeout(op, err)
+
 		case "40P01":
 			return ServerError(
 				op,
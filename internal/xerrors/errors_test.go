@@ -1,10 +1,13 @@
 package xerrors
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/lib/pq"
 	"go-rest-starter.jtbergman.me/internal/assert"
@@ -55,6 +58,24 @@ func TestDatabaseError(t *testing.T) {
 			wantStatus: http.StatusInternalServerError,
 			wantError:  ErrDeadlockDetected,
 		},
+		{
+			name:       "DeadlineExceeded",
+			error:      fmt.Errorf("query: %w", context.DeadlineExceeded),
+			wantStatus: http.StatusGatewayTimeout,
+			wantError:  ErrTimeout,
+		},
+		{
+			name:       "QueryCanceled",
+			error:      &pq.Error{Code: "57014"},
+			wantStatus: http.StatusGatewayTimeout,
+			wantError:  ErrTimeout,
+		},
+		{
+			name:       "Canceled",
+			error:      context.Canceled,
+			wantStatus: http.StatusServiceUnavailable,
+			wantError:  ErrCanceled,
+		},
 		{
 			name:       "Unexpected",
 			error:      errors.New("some_random_error"),
@@ -86,5 +107,84 @@ func TestClientUnauthorized(t *testing.T) {
 		assert.Equal(t, clientError.StatusCode, http.StatusUnauthorized)
 		assert.Equal(t, clientError.Op, "xerrors.Error")
 		assert.Is(t, clientError, ErrUnauthorized)
+		assert.Equal(t, clientError.Header.Get("WWW-Authenticate"), "Bearer")
+	})
+}
+
+func TestClientInvalidToken(t *testing.T) {
+	t.Parallel()
+
+	clientError := ClientInvalidToken("xerrors.InvalidToken")
+	assert.Equal(t, clientError.StatusCode, http.StatusUnauthorized)
+	assert.Is(t, clientError, ErrUnauthorized)
+	assert.Equal(t, clientError.Header.Get("WWW-Authenticate"), `Bearer error="invalid_token"`)
+}
+
+func TestClientForbidden(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ClientForbidden/Nil", func(t *testing.T) {
+		clientError := ClientForbidden(false, "xerrors.Nil")
+		assert.True(t, clientError == nil)
 	})
+
+	t.Run("ClientForbidden/Error", func(t *testing.T) {
+		clientError := ClientForbidden(true, "xerrors.Error")
+		assert.True(t, clientError != nil)
+		assert.Equal(t, clientError.StatusCode, http.StatusForbidden)
+		assert.Is(t, clientError, ErrForbidden)
+		assert.False(t, clientError.Matches(ErrUnauthorized))
+		assert.Equal(t, clientError.Header.Get("WWW-Authenticate"), "")
+	})
+
+	t.Run("ClientForbidden/InsufficientScope", func(t *testing.T) {
+		clientError := ClientForbidden(true, "xerrors.Error").Challenge(BearerInsufficientScope)
+		assert.Equal(t, clientError.Header.Get("WWW-Authenticate"), `Bearer error="insufficient_scope"`)
+	})
+}
+
+func TestClientRateLimited(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		retryAfter time.Duration
+		want       string
+	}{
+		{name: "Rounded", retryAfter: 1500 * time.Millisecond, want: "2"},
+		{name: "Exact", retryAfter: 30 * time.Second, want: "30"},
+		{name: "Minimum", retryAfter: 0, want: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientError := ClientRateLimited(tt.retryAfter, "xerrors.RateLimited")
+			assert.Equal(t, clientError.StatusCode, http.StatusTooManyRequests)
+			assert.Is(t, clientError, ErrRateLimited)
+			assert.Equal(t, clientError.Header.Get("Retry-After"), tt.want)
+		})
+	}
+}
+
+func TestCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  *AppError
+		want string
+	}{
+		{"Sentinel", DatabaseError(sql.ErrNoRows, "TestOperation"), "not_found"},
+		{"Wrapped", ClientError(http.StatusBadRequest, "", "TestOperation", fmt.Errorf("%w: detail", ErrExpired)), "expired"},
+		{"RateLimited", ClientRateLimited(time.Second, "TestOperation"), "rate_limited"},
+		{"EditConflict", ClientEditConflict("TestOperation"), "edit_conflict"},
+		{"ServerFallback", ServerError("TestOperation", errors.New("unknown")), "server_error"},
+		{"ClientFallback", ClientError(http.StatusBadRequest, "", "TestOperation", errors.New("unknown")), "bad_request"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.err.Code(), tc.want)
+		})
+	}
 }
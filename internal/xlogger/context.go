@@ -0,0 +1,58 @@
+package xlogger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ============================================================================
+// Context: Request ID
+// ============================================================================
+
+// A custom contextKey type to prevent key collisions
+type contextKey string
+
+// The contextKey for storing the request ID
+const requestIDContextKey = contextKey("request_id")
+
+// Returns a copy of the context with the request ID added
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// Retrieves the request ID from the context or an empty string if there is
+// none, e.g. outside of a request
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// ============================================================================
+// Handler
+// ============================================================================
+
+// Wraps a slog.Handler to add the request ID from the context to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+// Creates a handler that adds a request_id attribute to records logged with
+// a context carrying a request ID
+func NewHandler(handler slog.Handler) slog.Handler {
+	return contextHandler{Handler: handler}
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,34 @@
+package xlogger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go-rest-starter.jtbergman.me/internal/assert"
+)
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewTextHandler(&buf, nil)))
+
+	t.Run("RequestID", func(t *testing.T) {
+		buf.Reset()
+		logger.InfoContext(WithRequestID(context.Background(), "abc123"), "request")
+		assert.True(t, strings.Contains(buf.String(), "request_id=abc123"))
+	})
+
+	t.Run("WithAttrs", func(t *testing.T) {
+		buf.Reset()
+		logger.With("op", "test").InfoContext(WithRequestID(context.Background(), "abc123"), "request")
+		assert.True(t, strings.Contains(buf.String(), "op=test request_id=abc123"))
+	})
+
+	t.Run("NoRequestID", func(t *testing.T) {
+		buf.Reset()
+		logger.InfoContext(context.Background(), "startup")
+		assert.False(t, strings.Contains(buf.String(), "request_id"))
+	})
+}
@@ -1,14 +1,21 @@
 package xlogger
 
-import "log/slog"
+import (
+	"context"
+	"log/slog"
+)
 
 // ============================================================================
 // Interace
 // ============================================================================
 
+// Use the Context methods during a request so log lines include its request ID
 type Logger interface {
 	Handler() slog.Handler
 	Debug(msg string, args ...any)
 	Info(msg string, args ...any)
 	Error(msg string, args ...any)
+	DebugContext(ctx context.Context, msg string, args ...any)
+	InfoContext(ctx context.Context, msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
 }
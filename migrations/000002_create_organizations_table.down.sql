@@ -0,0 +1,11 @@
+BEGIN;
+
+-- The order of dropping tables is important due to constraints!
+
+-- Drop the organization_members table
+DROP TABLE IF EXISTS organization_members;
+
+-- Drop the organizations table
+DROP TABLE IF EXISTS organizations;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Drop the invitations table
+DROP TABLE IF EXISTS invitations;
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+-- Create the invitations table
+CREATE TABLE IF NOT EXISTS invitations (
+    id bigserial PRIMARY KEY,
+    hash bytea UNIQUE NOT NULL,
+    organization_id bigint NOT NULL REFERENCES organizations ON DELETE CASCADE,
+    email citext NOT NULL,
+    role text NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+    invited_by bigint REFERENCES users ON DELETE SET NULL,
+    expiry timestamp with time zone NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT NOW(),
+    UNIQUE (organization_id, email)
+);
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+-- Drop the audit_events table and its trigger
+DROP TABLE IF EXISTS audit_events;
+
+-- Drop the append-only trigger function
+DROP FUNCTION IF EXISTS audit_events_append_only;
+
+COMMIT;
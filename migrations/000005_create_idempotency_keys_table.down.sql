@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Drop the idempotency_keys table
+DROP TABLE IF EXISTS idempotency_keys;
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+-- Create the idempotency_keys table
+--
+-- key combines the client (user or IP) with the Idempotency-Key header, and
+-- status is NULL while the first request is in flight
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key text PRIMARY KEY,
+    fingerprint text NOT NULL,
+    status integer,
+    header jsonb NOT NULL DEFAULT '{}',
+    body bytea NOT NULL DEFAULT '',
+    created_at timestamp with time zone NOT NULL DEFAULT NOW(),
+    expires_at timestamp with time zone NOT NULL
+);
+
+-- Support deleting expired keys
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+COMMIT;